@@ -38,17 +38,107 @@ func ConnectDB() {
 }
 
 func InitialMigration() {
+	prepareMedicineCodes()
+
 	DB.AutoMigrate(
 		&schema.User{},
 		&schema.Admin{},
 		&schema.Doctor{},
+		&schema.MedicineCategory{},
+		&schema.MedicineType{},
 		&schema.Medicine{},
 		&schema.Article{},
 		&schema.DoctorTransaction{},
+		&schema.Address{},
 		&schema.MedicineTransaction{},
 		&schema.MedicineDetails{},
 		&schema.Checkout{},
+		&schema.FulfilmentEvent{},
 		&schema.Roomchat{},
 		&schema.Message{},
+		&schema.RoomchatRead{},
+		&schema.RoomchatExtension{},
+		&schema.Prescription{},
+		&schema.PrescriptionDetails{},
+		&schema.DoctorSchedule{},
+		&schema.DoctorScheduleException{},
+		&schema.DoctorSlot{},
+		&schema.ConsultationTransition{},
+		&schema.DoctorReview{},
+		&schema.PaymentEvent{},
+		&schema.Refund{},
+		&schema.StockMovement{},
+		&schema.MedicineBatch{},
+		&schema.MedicineBatchAllocation{},
+		&schema.CartItem{},
+		&schema.Voucher{},
+		&schema.VoucherTarget{},
+		&schema.VoucherRedemption{},
 	)
+
+	backfillConsultationStatus()
+	backfillReservationStatus()
+	backfillStockLedger()
+	backfillMedicineTaxonomy()
+}
+
+// backfillConsultationStatus derives the consultation status of transactions created before the lifecycle existed.
+// New transactions never stay 'booked' with a settled payment, so rerunning it on every start is harmless.
+func backfillConsultationStatus() {
+	booked := DB.Table("doctor_transactions").Where("consultation_status = ?", "booked")
+
+	booked.Session(&gorm.Session{}).Where("payment_status = ?", "cancelled").Update("consultation_status", "cancelled")
+
+	paid := booked.Session(&gorm.Session{}).Where("payment_status = ?", "success")
+	paid.Session(&gorm.Session{}).Where("patient_status = ?", "referred").Update("consultation_status", "referred")
+	paid.Session(&gorm.Session{}).Where("patient_status = ?", "recovered").Update("consultation_status", "closed")
+	paid.Session(&gorm.Session{}).Where("id IN (?)", DB.Table("roomchats").Select("transaction_id")).Update("consultation_status", "in consultation")
+	paid.Session(&gorm.Session{}).Update("consultation_status", "paid")
+}
+
+// backfillReservationStatus marks the orders placed before stock reservations existed, a paid order
+// already had its stock reduced and anything else never held stock. New orders always start reserved.
+func backfillReservationStatus() {
+	legacy := DB.Table("medicine_transactions").Where("reservation_status IS NULL")
+
+	paid := DB.Table("checkouts").Select("medicine_transaction_id").Where("payment_status = ?", "success")
+	legacy.Session(&gorm.Session{}).Where("id IN (?)", paid).Update("reservation_status", "committed")
+	legacy.Session(&gorm.Session{}).Update("reservation_status", "released")
+}
+
+// backfillStockLedger opens the ledger of every medicine that has stock but no movements yet, so the
+// ledger adds up to the stock. Medicines created since the ledger exists always have their movements.
+func backfillStockLedger() {
+	DB.Exec(`INSERT INTO stock_movements (medicine_id, type, quantity, stock_after, actor_role, actor_id, reason, created_at)
+		SELECT id, 'adjustment', stock, stock, 'system', 0, 'opening balance', NOW() FROM medicines
+		WHERE stock <> 0 AND NOT EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.medicine_id = medicines.id)`)
+}
+
+// prepareMedicineCodes makes existing medicine codes unique before the unique index is created, a repeated
+// code keeps its oldest medicine and the others get their id appended
+func prepareMedicineCodes() {
+	if !DB.Migrator().HasTable(&schema.Medicine{}) {
+		return
+	}
+
+	DB.Exec(`UPDATE medicines JOIN (
+			SELECT code, MIN(id) AS keep_id FROM medicines GROUP BY code HAVING COUNT(*) > 1
+		) AS repeated ON medicines.code = repeated.code AND medicines.id <> repeated.keep_id
+		SET medicines.code = CONCAT(LEFT(medicines.code, 80), '-', medicines.id)`)
+}
+
+// backfillMedicineTaxonomy turns the free text categories and types of medicines into managed rows and links
+// the medicines to them. Medicines always get their ids on create and update, so rerunning it is harmless.
+func backfillMedicineTaxonomy() {
+	DB.Exec(`INSERT IGNORE INTO medicine_categories (name, created_at, updated_at)
+		SELECT DISTINCT LEFT(TRIM(category), 100), NOW(), NOW() FROM medicines WHERE category_id IS NULL AND TRIM(category) <> ''`)
+	DB.Exec(`UPDATE medicines JOIN medicine_categories ON medicine_categories.name = LEFT(TRIM(medicines.category), 100)
+		SET medicines.category_id = medicine_categories.id, medicines.category = medicine_categories.name
+		WHERE medicines.category_id IS NULL`)
+
+	DB.Exec(`INSERT IGNORE INTO medicine_types (name, created_at, updated_at)
+		SELECT DISTINCT LEFT(TRIM(type), 100), NOW(), NOW() FROM medicines WHERE type_id IS NULL AND TRIM(type) <> ''`)
+	DB.Exec(`UPDATE medicines JOIN medicine_types ON medicine_types.name = LEFT(TRIM(medicines.type), 100)
+		SET medicines.type_id = medicine_types.id, medicines.type = medicine_types.name
+		WHERE medicines.type_id IS NULL`)
 }
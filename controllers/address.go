@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errAddressNotFound = errors.New("address " + constanta.ErrNotFound)
+	errAddressRequired = errors.New("delivery address is required, choose a saved address or fill in name, address and hp")
+)
+
+// applyDeliveryAddress copies the delivery address of an order onto it. A chosen address is used first,
+// then a fully typed in address, and the default address of the user when neither was given.
+func applyDeliveryAddress(tx *gorm.DB, medicineTransaction *schema.MedicineTransaction, addressID uint) error {
+
+	var address schema.Address
+
+	switch {
+	case addressID != 0:
+		if err := tx.Where("id = ? AND user_id = ?", addressID, medicineTransaction.UserID).First(&address).Error; err != nil {
+			return errAddressNotFound
+		}
+	case medicineTransaction.Name != "" && medicineTransaction.Address != "" && medicineTransaction.HP != "":
+		return nil
+	default:
+		if err := tx.Where("user_id = ? AND is_default = ?", medicineTransaction.UserID, true).First(&address).Error; err != nil {
+			return errAddressRequired
+		}
+	}
+
+	medicineTransaction.AddressID = &address.ID
+	medicineTransaction.Name = address.Recipient
+	medicineTransaction.HP = address.HP
+	medicineTransaction.Address = strings.Join([]string{address.Street, address.District, address.City, address.Province, address.PostalCode}, ", ")
+	medicineTransaction.Province = address.Province
+	medicineTransaction.City = address.City
+	medicineTransaction.District = address.District
+	medicineTransaction.PostalCode = address.PostalCode
+	medicineTransaction.AddressNotes = address.Notes
+
+	return nil
+}
+
+// makeDefaultAddress makes one address the only default address of its user
+func makeDefaultAddress(tx *gorm.DB, address *schema.Address) error {
+	if err := tx.Model(&schema.Address{}).
+		Where("user_id = ? AND id <> ? AND is_default = ?", address.UserID, address.ID, true).
+		Update("is_default", false).Error; err != nil {
+		return err
+	}
+	address.IsDefault = true
+	return tx.Model(address).Update("is_default", true).Error
+}
+
+// lockAddressBook serializes the default address changes of one user
+func lockAddressBook(tx *gorm.DB, userID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).Find(&[]schema.Address{}).Error
+}
+
+// User Get All Addresses, the default address first
+func GetAddressesController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	var addresses []schema.Address
+	if err := configs.DB.Where("user_id = ?", userID).Order("is_default DESC, created_at DESC").Find(&addresses).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"addresses"))
+	}
+
+	if len(addresses) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("addresses "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToAddressListResponse(addresses)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"addresses", response))
+}
+
+// User Get Address by ID
+func GetAddressByIDController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	addressID, err := strconv.Atoi(c.Param("address_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var address schema.Address
+	if err := configs.DB.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(errAddressNotFound.Error()))
+	}
+
+	response := response.ConvertToAddressResponse(&address)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"address", response))
+}
+
+// User Create Address, the first address becomes the default one
+func CreateAddressController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	var addressRequest web.AddressRequest
+
+	if err := c.Bind(&addressRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(addressRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	address := request.ConvertToAddressRequest(addressRequest, uint(userID))
+
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockAddressBook(tx, address.UserID); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&schema.Address{}).Where("user_id = ?", address.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		isDefault := address.IsDefault || count == 0
+		address.IsDefault = false
+
+		if err := tx.Create(address).Error; err != nil {
+			return err
+		}
+
+		if !isDefault {
+			return nil
+		}
+		return makeDefaultAddress(tx, address)
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"address"))
+	}
+
+	response := response.ConvertToAddressResponse(address)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"address", response))
+}
+
+// User Update Address, orders placed before keep their copy of the old address
+func UpdateAddressController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	addressID, err := strconv.Atoi(c.Param("address_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var addressRequest web.AddressRequest
+
+	if err := c.Bind(&addressRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(addressRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	updated := request.ConvertToAddressRequest(addressRequest, uint(userID))
+
+	var address schema.Address
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockAddressBook(tx, uint(userID)); err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+			return errAddressNotFound
+		}
+
+		// the default address only moves by making another address the default
+		if err := tx.Model(&address).Updates(map[string]interface{}{
+			"label":       updated.Label,
+			"recipient":   updated.Recipient,
+			"hp":          updated.HP,
+			"street":      updated.Street,
+			"province":    updated.Province,
+			"city":        updated.City,
+			"district":    updated.District,
+			"postal_code": updated.PostalCode,
+			"notes":       updated.Notes,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&address, address.ID).Error; err != nil {
+			return err
+		}
+
+		if !updated.IsDefault || address.IsDefault {
+			return nil
+		}
+		return makeDefaultAddress(tx, &address)
+	})
+	if err != nil {
+		if errors.Is(err, errAddressNotFound) {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"address"))
+	}
+
+	response := response.ConvertToAddressResponse(&address)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"address", response))
+}
+
+// User Delete Address, the newest remaining address takes over as default
+func DeleteAddressController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	addressID, err := strconv.Atoi(c.Param("address_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockAddressBook(tx, uint(userID)); err != nil {
+			return err
+		}
+
+		var address schema.Address
+		if err := tx.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+			return errAddressNotFound
+		}
+
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next schema.Address
+		err := tx.Where("user_id = ?", userID).Order("created_at DESC, id DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return makeDefaultAddress(tx, &next)
+	})
+	if err != nil {
+		if errors.Is(err, errAddressNotFound) {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionDeleted+"address"))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionDeleted+"address", nil))
+}
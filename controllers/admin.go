@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"healthcare/configs"
 	"healthcare/middlewares"
@@ -8,6 +9,7 @@ import (
 	"healthcare/models/web"
 	"healthcare/utils/helper"
 	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
 	"healthcare/utils/response"
 	"net/http"
 	"sort"
@@ -134,10 +136,13 @@ func UpdatePaymentStatusByAdminController(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve transaction"))
 	}
 
-	configs.DB.Model(&existingTransaction).Updates(updateRequest)
+	adminID, _ := c.Get("userID").(int)
 
-	result = configs.DB.Model(&existingTransaction).Updates(updateRequest)
-	if result.Error != nil {
+	err = settleDoctorTransactionPayment(configs.DB, &existingTransaction, updateRequest.PaymentStatus, lifecycle.ActorAdmin, uint(adminID), "payment status set to "+updateRequest.PaymentStatus)
+	if err != nil {
+		if errors.Is(err, lifecycle.ErrInvalidTransition) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"payment status"))
 	}
 
@@ -211,6 +216,22 @@ func GetAllDoctorsPaymentsByAdminsController(c echo.Context) error {
 	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionCreated+"doctor transactions", Responses, pagination))
 }
 
+// Admin Get Consultation History of Doctor Transaction
+func GetDoctorTransactionHistoryByAdminController(c echo.Context) error {
+
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid transaction id"))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, transactionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction "+constanta.ErrNotFound))
+	}
+
+	return getConsultationHistory(c, doctorTransaction.ID)
+}
+
 func GetDoctorTransactionByIDController(c echo.Context) error {
 
 	var doctorTransaction schema.DoctorTransaction
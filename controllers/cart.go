@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/helper/stock"
+	"healthcare/utils/helper/voucher"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errCartEmpty        = errors.New("cart is empty")
+	errCartPriceChanged = errors.New("prices in the cart have changed, accept the price changes to order")
+)
+
+// cartItems loads the cart of a user with the current medicine data, oldest line first
+func cartItems(tx *gorm.DB, userID uint) ([]schema.CartItem, error) {
+	var items []schema.CartItem
+	err := tx.Preload("Medicine").Where("user_id = ?", userID).Order("created_at ASC, id ASC").Find(&items).Error
+	return items, err
+}
+
+// cartResponse answers with the whole cart, as every change of a line can change its totals and warnings
+func cartResponse(c echo.Context, status int, message string, userID uint) error {
+	items, err := cartItems(configs.DB, userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"cart"))
+	}
+
+	response := response.ConvertToCartResponse(items)
+
+	return c.JSON(status, helper.SuccessResponse(message, response))
+}
+
+// User Get Cart
+func GetCartController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	return cartResponse(c, http.StatusOK, constanta.SuccessActionGet+"cart", uint(userID))
+}
+
+// User Add Medicine to Cart, a medicine already in the cart gets the quantity added
+func AddCartItemController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	var cartItemRequest web.CartItemRequest
+
+	if err := c.Bind(&cartItemRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(cartItemRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	cartItem := request.ConvertToCartItemRequest(cartItemRequest, uint(userID))
+
+	var medicine schema.Medicine
+	if err := configs.DB.First(&medicine, cartItem.MedicineID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(errMedicineNotFound.Error()))
+	}
+
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var existing schema.CartItem
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND medicine_id = ?", cartItem.UserID, cartItem.MedicineID).
+			First(&existing).Error
+
+		switch {
+		case err == nil:
+			if existing.Quantity+cartItem.Quantity > medicine.Stock {
+				return errInsufficientStock
+			}
+			return tx.Model(&existing).Update("quantity", existing.Quantity+cartItem.Quantity).Error
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if cartItem.Quantity > medicine.Stock {
+				return errInsufficientStock
+			}
+			cartItem.PriceAtAdd = medicine.Price
+			return tx.Create(cartItem).Error
+		default:
+			return err
+		}
+	})
+	if err != nil {
+		if errors.Is(err, errInsufficientStock) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"cart item"))
+	}
+
+	return cartResponse(c, http.StatusCreated, constanta.SuccessActionCreated+"cart item", uint(userID))
+}
+
+// User Update Quantity of a Cart Item
+func UpdateCartItemController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	itemID, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var cartItemRequest web.CartItemUpdateRequest
+
+	if err := c.Bind(&cartItemRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(cartItemRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	var cartItem schema.CartItem
+	if err := configs.DB.Preload("Medicine").Where("id = ? AND user_id = ?", itemID, userID).First(&cartItem).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("cart item "+constanta.ErrNotFound))
+	}
+
+	if cartItem.Medicine.ID == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(errMedicineNotFound.Error()))
+	}
+
+	if cartItemRequest.Quantity > cartItem.Medicine.Stock {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse(errInsufficientStock.Error()))
+	}
+
+	if err := configs.DB.Model(&cartItem).Update("quantity", cartItemRequest.Quantity).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"cart item"))
+	}
+
+	return cartResponse(c, http.StatusOK, constanta.SuccessActionUpdated+"cart item", uint(userID))
+}
+
+// User Remove Cart Item
+func DeleteCartItemController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	itemID, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	result := configs.DB.Where("id = ? AND user_id = ?", itemID, userID).Delete(&schema.CartItem{})
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionDeleted+"cart item"))
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("cart item "+constanta.ErrNotFound))
+	}
+
+	return cartResponse(c, http.StatusOK, constanta.SuccessActionDeleted+"cart item", uint(userID))
+}
+
+// User Clear Cart
+func ClearCartController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	if err := configs.DB.Where("user_id = ?", userID).Delete(&schema.CartItem{}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionDeleted+"cart"))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionDeleted+"cart", nil))
+}
+
+// User Order Cart, the cart becomes a reserved medicine transaction and is emptied in the same database transaction
+func CreateCartMedicineTransactionController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	var cartOrderRequest web.CartOrderRequest
+
+	if err := c.Bind(&cartOrderRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(cartOrderRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	if _, err := payment.ForMethod(cartOrderRequest.PaymentMethod); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(paymentMethodError()))
+	}
+
+	var items []schema.CartItem
+	var medicineTransaction *schema.MedicineTransaction
+
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		// only the cart lines are locked, the medicines are locked in id order by the reservation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).Find(&[]schema.CartItem{}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		items, err = cartItems(tx, uint(userID))
+		if err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return errCartEmpty
+		}
+
+		if response.ConvertToCartResponse(items).PriceChanged && !cartOrderRequest.AcceptPriceChanges {
+			return errCartPriceChanged
+		}
+
+		medicineTransaction = request.ConvertToCartMedicineTransactionRequest(cartOrderRequest, items, uint(userID))
+
+		if err := applyDeliveryAddress(tx, medicineTransaction, cartOrderRequest.AddressID); err != nil {
+			return err
+		}
+
+		if err := applyShipping(tx, medicineTransaction); err != nil {
+			return err
+		}
+
+		if err := applyMedicineVoucher(tx, medicineTransaction); err != nil {
+			return err
+		}
+
+		if err := stock.Reserve(tx, medicineTransaction, lifecycle.ActorUser, uint(userID)); err != nil {
+			return err
+		}
+
+		if err := voucher.RedeemMedicineTransaction(tx, medicineTransaction); err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&schema.CartItem{}).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errCartEmpty):
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+		case errors.Is(err, errCartPriceChanged):
+			return c.JSON(http.StatusConflict, helper.ErrorResponseWithData(err.Error(), response.ConvertToCartResponse(items)))
+		}
+		if status := medicineTransactionErrorStatus(err); status != http.StatusInternalServerError {
+			return c.JSON(status, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine transaction"))
+	}
+
+	response := response.ConvertToMedicineTransactionResponse(medicineTransaction)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"medicine transaction", response))
+}
@@ -9,12 +9,17 @@ import (
 	"healthcare/models/web"
 	"healthcare/utils/helper"
 	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/helper/stock"
 	"healthcare/utils/request"
 	"healthcare/utils/response"
 	"net/http"
-	"path/filepath"
 	"strconv"
 	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Create Checkout By User
@@ -37,60 +42,69 @@ func CreateCheckoutController(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
 	}
 
-	// Upload files
-	err := c.Request().ParseMultipartForm(10 << 20) // 10 MB limit
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	var medicineTransaction schema.MedicineTransaction
+	if err := configs.DB.Preload("MedicineDetails").Where("id = ? AND user_id = ?", medicinetransactionID, userID).First(&medicineTransaction).Error; err != nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
 	}
 
-	file, fileHeader, err := c.Request().FormFile("payment_confirmation")
+	provider, err := payment.ForMethod(medicineTransaction.PaymentMethod)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrImageFileRequired))
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(paymentMethodError()))
 	}
-	defer file.Close()
 
-	allowedExtensions := []string{".jpg", ".jpeg", ".png"}
-	ext := filepath.Ext(fileHeader.Filename)
-	allowed := false
-	for _, validExt := range allowedExtensions {
-		if ext == validExt {
-			allowed = true
-			break
-		}
-	}
-	if !allowed {
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidImageFormat))
-	}
-
-	imageURL, err := helper.UploadFilesToGCS(c, fileHeader)
+	paymentConfirmation, err := uploadPaymentConfirmation(c, provider.RequiresConfirmation())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("error upload image to cloud storage"))
+		return c.JSON(paymentConfirmationStatus(err), helper.ErrorResponse(err.Error()))
 	}
 
-	checkout.PaymentConfirmation = imageURL
+	checkout.PaymentConfirmation = paymentConfirmation
 
-	var medicineTransaction schema.MedicineTransaction
-	if err := configs.DB.Where("id = ? AND user_id = ?", medicinetransactionID, userID).First(&medicineTransaction).Error; err != nil {
-		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
-	}
+	// a reservation that expired or was released by a cancelled payment is taken again before paying. The order
+	// is locked while its checkouts are counted, so two requests cannot both start paying it.
+	checkoutRequest := request.ConvertToCheckoutRequest(checkout)
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schema.MedicineTransaction{}, medicineTransaction.ID).Error; err != nil {
+			return err
+		}
 
-	var existingCheckout schema.Checkout
-	if err := configs.DB.Where("medicine_transaction_id = ?", medicinetransactionID).First(&existingCheckout).Error; err == nil {
-		if existingCheckout.PaymentStatus == "pending" {
-			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("checkout is still pending"))
+		var active int64
+		if err := tx.Model(&schema.Checkout{}).
+			Where("medicine_transaction_id = ? AND payment_status IN ?", medicineTransaction.ID, []string{payment.StatusPending, payment.StatusSuccess}).
+			Count(&active).Error; err != nil {
+			return err
 		}
-		if existingCheckout.PaymentStatus == "success" {
-			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("checkout is success"))
+		if active > 0 {
+			return errActiveCheckout
 		}
-	}
 
-	checkoutRequest := request.ConvertToCheckoutRequest(checkout)
-	if err := configs.DB.Create(&checkoutRequest).Error; err != nil {
+		if err := stock.Renew(tx, medicineTransaction.ID, lifecycle.ActorUser, uint(userID)); err != nil {
+			return err
+		}
+		// a voucher that could not be claimed again was dropped from the order, the charge uses the total it left
+		if err := tx.Preload("MedicineDetails").First(&medicineTransaction, medicineTransaction.ID).Error; err != nil {
+			return err
+		}
+		return tx.Create(&checkoutRequest).Error
+	})
+	if err != nil {
+		if errors.Is(err, errActiveCheckout) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
+		if status := medicineTransactionErrorStatus(err); status != http.StatusInternalServerError {
+			return c.JSON(status, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"checkout"))
 	}
 
-	if err := configs.DB.Model(&medicineTransaction).Update("status_transaction", "sudah dibayar").Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"medicine transaction status"))
+	if err := chargeCheckout(provider, checkoutRequest, medicineTransaction); err != nil {
+		return c.JSON(http.StatusBadGateway, helper.ErrorResponse("failed to create payment charge"))
+	}
+
+	// a charge the provider cancelled right away already put the order back to unpaid
+	if checkoutRequest.PaymentStatus != payment.StatusCancelled {
+		if err := configs.DB.Model(&medicineTransaction).Update("status_transaction", "sudah dibayar").Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"medicine transaction status"))
+		}
 	}
 
 	var created schema.Checkout
@@ -196,6 +210,7 @@ func GetUserCheckoutByIDController(c echo.Context) error {
 	result := configs.DB.
 		Joins("JOIN medicine_transactions ON checkouts.medicine_transaction_id = medicine_transactions.id").
 		Preload("MedicineTransaction.MedicineDetails").
+		Preload("FulfilmentEvents", preloadTimeline).
 		Where("medicine_transactions.user_id = ? AND checkouts.id = ?", userID, checkoutID).
 		First(&checkout)
 
@@ -229,23 +244,19 @@ func UpdateCheckoutController(c echo.Context) error {
 
 	updatedCheckout := request.ConvertToCheckoutUpdate(updatedCheckoutRequest)
 
-	if updatedCheckout.PaymentStatus == "success" {
-		medicineTransaction := existingCheckout.MedicineTransaction
-		if err := reduceStock(medicineTransaction.MedicineDetails); err != nil {
-			return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
-		}
+	if !payment.StatusIsValid(updatedCheckout.PaymentStatus) {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid input payment status data ('pending', 'success', 'cancelled')"))
 	}
 
-	if updatedCheckout.PaymentStatus == "cancelled" {
-		if err := configs.DB.Table("medicine_transactions").
-			Where("id = ?", existingCheckout.MedicineTransactionID).
-			Update("status_transaction", "belum dibayar").Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"medicine transaction status"))
-		}
-	}
+	adminID, _ := c.Get("userID").(int)
 
-	result = configs.DB.Table("checkouts").Where("id = ?", checkoutID).Updates(updatedCheckout)
-	if result.Error != nil {
+	if err := settleCheckoutPayment(configs.DB, &existingCheckout, updatedCheckout.PaymentStatus, lifecycle.ActorAdmin, uint(adminID)); err != nil {
+		if errors.Is(err, errInsufficientStock) || errors.Is(err, errMedicineNotFound) {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+		}
+		if errors.Is(err, errPaymentSettled) || errors.Is(err, errPaymentRefunded) || errors.Is(err, errExpiredStock) || errors.Is(err, errCheckoutShipped) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"checkout"))
 	}
 
@@ -259,23 +270,11 @@ func UpdateCheckoutController(c echo.Context) error {
 	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"checkout", response))
 }
 
-func reduceStock(medicineDetails []schema.MedicineDetails) error {
-	for _, md := range medicineDetails {
-		medicine := schema.Medicine{}
-		if err := configs.DB.First(&medicine, md.MedicineID).Error; err != nil {
-			return errors.New("invalid medicine id")
-		}
-
-		if medicine.Stock < md.Quantity {
-			return errors.New("insufficient stock")
-		}
-
-		newStock := medicine.Stock - md.Quantity
-		if err := configs.DB.Model(&medicine).Update("stock", newStock).Error; err != nil {
-			return errors.New(constanta.ErrActionUpdated + "medicine stock")
-		}
-	}
-	return nil
+// deliveryRegion filters checkouts by the address their medicines are delivered to, for batching couriers
+type deliveryRegion struct {
+	Province string
+	City     string
+	District string
 }
 
 // Get Checkout By Admin
@@ -293,6 +292,13 @@ func GetAdminCheckoutController(c echo.Context) error {
 	}
 
 	paymentStatus := params.Get("payment_status")
+	fulfilmentStatus := params.Get("fulfilment_status")
+
+	region := deliveryRegion{
+		Province: params.Get("province"),
+		City:     params.Get("city"),
+		District: params.Get("district"),
+	}
 
 	userIDStr := params.Get("user_id")
 
@@ -306,7 +312,7 @@ func GetAdminCheckoutController(c echo.Context) error {
 
 	var checkouts []schema.Checkout
 
-	checkouts, total, err := GetAdminAllCheckoutPagination(offset, limit, userID, paymentStatus, []schema.Checkout{})
+	checkouts, total, err := GetAdminAllCheckoutPagination(offset, limit, userID, paymentStatus, fulfilmentStatus, region, []schema.Checkout{})
 
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
@@ -322,7 +328,7 @@ func GetAdminCheckoutController(c echo.Context) error {
 	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"checkouts", response, pagination))
 }
 
-func GetAdminAllCheckoutPagination(offset, limit, userID int, paymentStatus string, queryInput []schema.Checkout) ([]schema.Checkout, int64, error) {
+func GetAdminAllCheckoutPagination(offset, limit, userID int, paymentStatus, fulfilmentStatus string, region deliveryRegion, queryInput []schema.Checkout) ([]schema.Checkout, int64, error) {
 	if offset < 0 || limit < 0 {
 		return nil, 0, nil
 	}
@@ -342,6 +348,27 @@ func GetAdminAllCheckoutPagination(offset, limit, userID int, paymentStatus stri
 		query = query.Where("checkouts.payment_status = ?", paymentStatus)
 	}
 
+	// 'awaiting' lists the paid checkouts that still have to be packed
+	switch fulfilmentStatus {
+	case "":
+	case "awaiting":
+		query = query.Where("checkouts.payment_status = ? AND checkouts.fulfilment_status IS NULL", "success")
+	default:
+		query = query.Where("checkouts.fulfilment_status = ?", fulfilmentStatus)
+	}
+
+	if region.Province != "" {
+		query = query.Where("medicine_transactions.province = ?", region.Province)
+	}
+
+	if region.City != "" {
+		query = query.Where("medicine_transactions.city = ?", region.City)
+	}
+
+	if region.District != "" {
+		query = query.Where("medicine_transactions.district = ?", region.District)
+	}
+
 	query = query.Preload("MedicineTransaction.MedicineDetails").
 		Order("checkouts.created_at DESC")
 
@@ -372,6 +399,7 @@ func GetAdminCheckoutByIDController(c echo.Context) error {
 	var checkout schema.Checkout
 	result := configs.DB.
 		Preload("MedicineTransaction.MedicineDetails").
+		Preload("FulfilmentEvents", preloadTimeline).
 		Joins("JOIN medicine_transactions ON checkouts.medicine_transaction_id = medicine_transactions.id").
 		Where("checkouts.id = ?", checkoutID).
 		First(&checkout)
@@ -384,3 +412,46 @@ func GetAdminCheckoutByIDController(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"checkout", response))
 }
+
+// User Check Checkout Payment Status
+func CheckCheckoutPaymentController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	checkoutID, err := strconv.Atoi(c.Param("checkout_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid checkout id"))
+	}
+
+	var checkout schema.Checkout
+	result := configs.DB.
+		Joins("JOIN medicine_transactions ON checkouts.medicine_transaction_id = medicine_transactions.id").
+		Preload("MedicineTransaction.MedicineDetails").
+		Where("medicine_transactions.user_id = ? AND checkouts.id = ?", userID, checkoutID).
+		First(&checkout)
+
+	if result.Error != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(constanta.ErrNotFound+" checkout"))
+	}
+
+	// providers without a status query, like manual transfers, keep the stored status
+	if provider, err := payment.ByName(checkout.PaymentProvider); err == nil && checkout.PaymentStatus == payment.StatusPending {
+		status, err := provider.QueryStatus(checkout.PaymentReference)
+		if err != nil && !errors.Is(err, payment.ErrNotSupported) {
+			return c.JSON(http.StatusBadGateway, helper.ErrorResponse("failed to query payment status"))
+		}
+
+		if err == nil {
+			if err := settleCheckoutPayment(configs.DB, &checkout, status, lifecycle.ActorSystem, 0); err != nil {
+				return c.JSON(paymentSettleStatus(err), helper.ErrorResponse(err.Error()))
+			}
+		}
+	}
+
+	response := response.ConvertToGetCheckoutResponse(&checkout)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"payment status", response))
+}
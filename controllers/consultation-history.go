@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/transcript"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// endedConsultationStatuses are the consultation statuses listed in the history, a refunded consultation
+// only shows up when it had a roomchat, so refunds of consultations that never started stay out
+var endedConsultationStatuses = []string{lifecycle.StatusClosed, lifecycle.StatusReferred, lifecycle.StatusRefunded}
+
+// User Get Consultation History
+func GetConsultationHistoryController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	var doctorTransactions []schema.DoctorTransaction
+	var total int64
+
+	query := configs.DB.Model(&schema.DoctorTransaction{}).
+		Joins("JOIN roomchats ON roomchats.transaction_id = doctor_transactions.id").
+		Where("doctor_transactions.user_id = ? AND doctor_transactions.consultation_status IN ?", userID, endedConsultationStatuses)
+
+	query.Count(&total)
+
+	if err := query.Preload("Roomchat").Order("roomchats.created_at DESC").Limit(limit).Offset(offset).Find(&doctorTransactions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"consultation history"))
+	}
+
+	if len(doctorTransactions) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("consultation history "+constanta.ErrNotFound))
+	}
+
+	var responses []web.ConsultationHistoryResponse
+	for _, doctorTransaction := range doctorTransactions {
+
+		var doctor schema.Doctor
+		if err := configs.DB.Unscoped().First(&doctor, doctorTransaction.DoctorID).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor data"))
+		}
+
+		var prescriptions int64
+		if err := configs.DB.Model(&schema.Prescription{}).Where("doctor_transaction_id = ?", doctorTransaction.ID).Count(&prescriptions).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve prescription data"))
+		}
+
+		responses = append(responses, response.ConvertToConsultationHistoryResponse(doctorTransaction, doctor, prescriptions > 0))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"consultation history", responses, pagination))
+}
+
+// User Export Consultation Transcript
+func ExportConsultationTranscriptController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid transaction id"))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.Preload("Roomchat").First(&doctorTransaction, "user_id = ? AND id = ?", userID, transactionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction "+constanta.ErrNotFound))
+	}
+
+	if doctorTransaction.Roomchat.ID == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	if roomchatOpen(doctorTransaction.Roomchat) {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("consultation has not ended yet"))
+	}
+
+	var user schema.User
+	if err := configs.DB.First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve user data"))
+	}
+
+	var doctor schema.Doctor
+	if err := configs.DB.Unscoped().First(&doctor, doctorTransaction.DoctorID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor data"))
+	}
+
+	var messages []schema.Message
+	if err := configs.DB.Where("roomchat_id = ?", doctorTransaction.Roomchat.ID).Order("id ASC").Find(&messages).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve message data"))
+	}
+
+	var prescription *schema.Prescription
+	var found schema.Prescription
+	err = configs.DB.Preload("PrescriptionDetails.Medicine").First(&found, "doctor_transaction_id = ?", doctorTransaction.ID).Error
+	if err == nil {
+		prescription = &found
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve prescription data"))
+	}
+
+	document, err := transcript.RenderHTML(response.ConvertToConsultationTranscript(doctorTransaction, doctor, user, messages, prescription))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to generate consultation transcript"))
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"consultation-%d.html\"", doctorTransaction.ID))
+
+	return c.HTMLBlob(http.StatusOK, document)
+}
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var errReviewNotFound = errors.New("doctor review " + constanta.ErrNotFound)
+
+// consultationEnded reports whether the transaction reached a status the patient may review
+func consultationEnded(transaction schema.DoctorTransaction) bool {
+	return transaction.ConsultationStatus == lifecycle.StatusClosed || transaction.ConsultationStatus == lifecycle.StatusReferred
+}
+
+// GetDoctorRating averages the published reviews of a doctor, rounded to one decimal
+func GetDoctorRating(doctorID uint) (float64, int64, error) {
+
+	var result struct {
+		Average float64
+		Total   int64
+	}
+
+	err := configs.DB.Model(&schema.DoctorReview{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS total").
+		Where("doctor_id = ? AND status = ?", doctorID, "published").
+		Scan(&result).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return math.Round(result.Average*10) / 10, result.Total, nil
+}
+
+// findUserReviewTransaction loads the user's doctor transaction and its review, if any
+func findUserReviewTransaction(c echo.Context) (*schema.DoctorTransaction, *schema.DoctorReview, error) {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return nil, nil, c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return nil, nil, c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid transaction id"))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "user_id = ? AND id = ?", userID, transactionID).Error; err != nil {
+		return nil, nil, c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction "+constanta.ErrNotFound))
+	}
+
+	var review schema.DoctorReview
+	err = configs.DB.First(&review, "doctor_transaction_id = ?", doctorTransaction.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &doctorTransaction, nil, nil
+	}
+	if err != nil {
+		return nil, nil, c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"doctor review"))
+	}
+
+	return &doctorTransaction, &review, nil
+}
+
+// User Create Doctor Review
+func CreateDoctorReviewController(c echo.Context) error {
+
+	doctorTransaction, review, err := findUserReviewTransaction(c)
+	if doctorTransaction == nil {
+		return err
+	}
+
+	if review != nil {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse("doctor transaction already reviewed"))
+	}
+
+	if !consultationEnded(*doctorTransaction) {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("consultation has not ended yet"))
+	}
+
+	var reviewRequest web.DoctorReviewRequest
+
+	if err := c.Bind(&reviewRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(reviewRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	review = request.ConvertToDoctorReviewRequest(reviewRequest, doctorTransaction)
+
+	// the unique index on the transaction rejects a concurrent second review
+	if err := configs.DB.Create(review).Error; err != nil {
+		if helper.IsDuplicateKey(err) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse("doctor transaction already reviewed"))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"doctor review"))
+	}
+
+	response := response.ConvertToDoctorReviewResponse(review)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"doctor review", response))
+}
+
+// User Update Doctor Review
+func UpdateDoctorReviewController(c echo.Context) error {
+
+	doctorTransaction, review, err := findUserReviewTransaction(c)
+	if doctorTransaction == nil {
+		return err
+	}
+
+	if review == nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(errReviewNotFound.Error()))
+	}
+
+	var reviewRequest web.DoctorReviewRequest
+
+	if err := c.Bind(&reviewRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(reviewRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	// a hidden review stays hidden after an edit until an admin publishes it again
+	review.Rating = reviewRequest.Rating
+	review.Comment = reviewRequest.Comment
+
+	if err := configs.DB.Save(review).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"doctor review"))
+	}
+
+	response := response.ConvertToDoctorReviewResponse(review)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"doctor review", response))
+}
+
+// User Get Doctor Review
+func GetDoctorReviewController(c echo.Context) error {
+
+	doctorTransaction, review, err := findUserReviewTransaction(c)
+	if doctorTransaction == nil {
+		return err
+	}
+
+	if review == nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(errReviewNotFound.Error()))
+	}
+
+	response := response.ConvertToDoctorReviewResponse(review)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"doctor review", response))
+}
+
+// Get Published Reviews of Doctor
+func GetDoctorReviewsController(c echo.Context) error {
+
+	doctorID, err := strconv.Atoi(c.Param("doctor_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	var reviews []schema.DoctorReview
+	var total int64
+
+	query := configs.DB.Model(&schema.DoctorReview{}).Where("doctor_id = ? AND status = ?", doctorID, "published")
+
+	query.Count(&total)
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&reviews).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"doctor reviews"))
+	}
+
+	if len(reviews) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor reviews "+constanta.ErrNotFound))
+	}
+
+	var responses []web.DoctorPublicReviewResponse
+	for _, review := range reviews {
+
+		var user schema.User
+		if err := configs.DB.Unscoped().First(&user, review.UserID).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve user data"))
+		}
+
+		responses = append(responses, response.ConvertToDoctorPublicReviewResponse(review, user))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"doctor reviews", responses, pagination))
+}
+
+// Admin Get All Doctor Reviews
+func GetAllDoctorReviewsByAdminController(c echo.Context) error {
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	status := c.QueryParam("status")
+	if status != "" && status != "published" && status != "hidden" {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid input review status data ('published', 'hidden')"))
+	}
+
+	var reviews []schema.DoctorReview
+	var total int64
+
+	query := configs.DB.Model(&schema.DoctorReview{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if doctorID := c.QueryParam("doctor_id"); doctorID != "" {
+		query = query.Where("doctor_id = ?", doctorID)
+	}
+
+	query.Count(&total)
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&reviews).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"doctor reviews"))
+	}
+
+	if len(reviews) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor reviews "+constanta.ErrNotFound))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToDoctorReviewListResponse(reviews)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"doctor reviews", response, pagination))
+}
+
+// Admin Moderate Doctor Review
+func ModerateDoctorReviewByAdminController(c echo.Context) error {
+
+	adminID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid admin id"))
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("review_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid review id"))
+	}
+
+	var review schema.DoctorReview
+	if err := configs.DB.First(&review, reviewID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(errReviewNotFound.Error()))
+	}
+
+	var moderationRequest web.DoctorReviewModerationRequest
+
+	if err := c.Bind(&moderationRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(moderationRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	if moderationRequest.Status == "hidden" && moderationRequest.Reason == "" {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("reason is required to hide a review"))
+	}
+
+	now := time.Now()
+	review.Status = moderationRequest.Status
+	review.ModerationReason = moderationRequest.Reason
+	review.ModeratedBy = uint(adminID)
+	review.ModeratedAt = &now
+
+	if err := configs.DB.Save(&review).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"doctor review"))
+	}
+
+	response := response.ConvertToDoctorReviewResponse(&review)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"doctor review", response))
+}
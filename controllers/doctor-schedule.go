@@ -0,0 +1,528 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	clockLayout    = "15:04"
+	maxSlotsWindow = 31 // days
+)
+
+var (
+	errInvalidClock  = errors.New("invalid time format, use HH:MM")
+	errInvalidWindow = errors.New("end time must be after start time")
+	errSlotNotFound  = errors.New("schedule slot " + constanta.ErrNotFound)
+	errSlotBooked    = errors.New("schedule slot already booked")
+)
+
+// parseScheduleClock validates a start/end pair and returns both normalized to HH:MM
+func parseScheduleClock(start, end string) (string, string, error) {
+	startClock, err := time.Parse(clockLayout, start)
+	if err != nil {
+		return "", "", errInvalidClock
+	}
+
+	endClock, err := time.Parse(clockLayout, end)
+	if err != nil {
+		return "", "", errInvalidClock
+	}
+
+	if !endClock.After(startClock) {
+		return "", "", errInvalidWindow
+	}
+
+	return startClock.Format(clockLayout), endClock.Format(clockLayout), nil
+}
+
+var (
+	scheduleLocationOnce sync.Once
+	scheduleLocation     *time.Location
+)
+
+// ScheduleLocation is the time zone doctors write their schedules in, SCHEDULE_TIMEZONE or Asia/Jakarta when unset.
+// Slots are generated in it so they do not move with the time zone of the server.
+func ScheduleLocation() *time.Location {
+	scheduleLocationOnce.Do(func() {
+		name := os.Getenv("SCHEDULE_TIMEZONE")
+		if name == "" {
+			name = "Asia/Jakarta"
+		}
+
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("failed to load schedule time zone %s, using the server time zone: %v\n", name, err)
+			location = time.Local
+		}
+		scheduleLocation = location
+	})
+	return scheduleLocation
+}
+
+// clockOn returns the given HH:MM clock on the day of date, in the schedule time zone
+func clockOn(date time.Time, clock string) time.Time {
+	t, _ := time.Parse(clockLayout, clock)
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, ScheduleLocation())
+}
+
+// GetDoctorSlots generates the slots of a doctor between two dates (inclusive) from the weekly schedules,
+// leaving out past slots and exceptions. Booked slots carry the transaction id of the booking.
+func GetDoctorSlots(doctorID uint, from time.Time, to time.Time) ([]schema.DoctorSlot, error) {
+
+	var schedules []schema.DoctorSchedule
+	if err := configs.DB.Where("doctor_id = ?", doctorID).Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	var exceptions []schema.DoctorScheduleException
+	if err := configs.DB.Where("doctor_id = ? AND date BETWEEN ? AND ?", doctorID, from.Format(dateLayout), to.Format(dateLayout)).Find(&exceptions).Error; err != nil {
+		return nil, err
+	}
+
+	var booked []schema.DoctorSlot
+	if err := configs.DB.Where("doctor_id = ? AND start_time < ? AND end_time > ?", doctorID, to.AddDate(0, 0, 1), from).Find(&booked).Error; err != nil {
+		return nil, err
+	}
+
+	exceptionsByDate := make(map[string][]schema.DoctorScheduleException)
+	for _, exception := range exceptions {
+		date := exception.Date.Format(dateLayout)
+		exceptionsByDate[date] = append(exceptionsByDate[date], exception)
+	}
+
+	now := time.Now()
+	var slots []schema.DoctorSlot
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, schedule := range schedules {
+			if schedule.DayOfWeek != int(day.Weekday()) || schedule.SlotDuration <= 0 {
+				continue
+			}
+
+			duration := time.Duration(schedule.SlotDuration) * time.Minute
+			end := clockOn(day, schedule.EndTime)
+
+			for start := clockOn(day, schedule.StartTime); !start.Add(duration).After(end); start = start.Add(duration) {
+				slot := schema.DoctorSlot{DoctorID: doctorID, StartTime: start, EndTime: start.Add(duration)}
+
+				if !slot.StartTime.After(now) || slotBlocked(exceptionsByDate[day.Format(dateLayout)], day, slot) {
+					continue
+				}
+
+				for _, booking := range booked {
+					if booking.StartTime.Before(slot.EndTime) && booking.EndTime.After(slot.StartTime) {
+						slot.DoctorTransactionID = booking.DoctorTransactionID
+						break
+					}
+				}
+
+				slots = append(slots, slot)
+			}
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].StartTime.Before(slots[j].StartTime)
+	})
+
+	return slots, nil
+}
+
+// slotBlocked reports whether a slot falls into one of the exceptions of its day
+func slotBlocked(exceptions []schema.DoctorScheduleException, day time.Time, slot schema.DoctorSlot) bool {
+	for _, exception := range exceptions {
+		if exception.StartTime == "" || exception.EndTime == "" {
+			return true
+		}
+		if clockOn(day, exception.StartTime).Before(slot.EndTime) && clockOn(day, exception.EndTime).After(slot.StartTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// findDoctorSlot returns the generated slot of a doctor starting at the given time, if it is still free
+func findDoctorSlot(doctorID uint, start time.Time) (schema.DoctorSlot, error) {
+
+	local := start.In(ScheduleLocation())
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, ScheduleLocation())
+
+	slots, err := GetDoctorSlots(doctorID, day, day)
+	if err != nil {
+		return schema.DoctorSlot{}, err
+	}
+
+	for _, slot := range slots {
+		if !slot.StartTime.Equal(start) {
+			continue
+		}
+		if slot.DoctorTransactionID != 0 {
+			return schema.DoctorSlot{}, errSlotBooked
+		}
+		return slot, nil
+	}
+
+	return schema.DoctorSlot{}, errSlotNotFound
+}
+
+// bookDoctorSlot reserves a slot inside tx. The doctor row is locked so concurrent bookings
+// of the same doctor are serialized, and the overlap check runs after the lock is held.
+func bookDoctorSlot(tx *gorm.DB, slot *schema.DoctorSlot) error {
+
+	var doctor schema.Doctor
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&doctor, slot.DoctorID).Error; err != nil {
+		return err
+	}
+
+	var overlapping int64
+	if err := tx.Model(&schema.DoctorSlot{}).Where("doctor_id = ? AND start_time < ? AND end_time > ?", slot.DoctorID, slot.EndTime, slot.StartTime).Count(&overlapping).Error; err != nil {
+		return err
+	}
+
+	if overlapping > 0 {
+		return errSlotBooked
+	}
+
+	return tx.Create(slot).Error
+}
+
+// parseSlotsWindow reads the from/to query params, defaulting to the coming week
+func parseSlotsWindow(c echo.Context) (time.Time, time.Time, error) {
+
+	now := time.Now().In(ScheduleLocation())
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, ScheduleLocation())
+	to := from.AddDate(0, 0, 6)
+
+	if fromParam := c.QueryParam("from"); fromParam != "" {
+		parsed, err := time.ParseInLocation(dateLayout, fromParam, ScheduleLocation())
+		if err != nil {
+			return from, to, errors.New("invalid from date, use YYYY-MM-DD")
+		}
+		from = parsed
+		to = from.AddDate(0, 0, 6)
+	}
+
+	if toParam := c.QueryParam("to"); toParam != "" {
+		parsed, err := time.ParseInLocation(dateLayout, toParam, ScheduleLocation())
+		if err != nil {
+			return from, to, errors.New("invalid to date, use YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	if to.Before(from) || to.Sub(from) > maxSlotsWindow*24*time.Hour {
+		return from, to, errors.New("date range must be between 0 and 31 days")
+	}
+
+	return from, to, nil
+}
+
+// Doctor Get All Schedules
+func GetDoctorSchedulesController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	var schedules []schema.DoctorSchedule
+	if err := configs.DB.Where("doctor_id = ?", doctorID).Order("day_of_week, start_time").Find(&schedules).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"schedules"))
+	}
+
+	if len(schedules) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("schedules "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToDoctorScheduleListResponse(schedules)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"schedules", response))
+}
+
+// Doctor Create Schedule
+func CreateDoctorScheduleController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	var scheduleRequest web.DoctorScheduleRequest
+
+	if err := c.Bind(&scheduleRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(scheduleRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	schedule := request.ConvertToDoctorScheduleRequest(scheduleRequest, uint(doctorID))
+
+	if status, err := validateDoctorSchedule(schedule); err != nil {
+		return c.JSON(status, helper.ErrorResponse(err.Error()))
+	}
+
+	if err := configs.DB.Create(&schedule).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"schedule"))
+	}
+
+	response := response.ConvertToDoctorScheduleResponse(schedule)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"schedule", response))
+}
+
+// Doctor Update Schedule
+func UpdateDoctorScheduleController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	scheduleID, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid schedule id"))
+	}
+
+	var existingSchedule schema.DoctorSchedule
+	if err := configs.DB.First(&existingSchedule, "id = ? AND doctor_id = ?", scheduleID, doctorID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("schedule "+constanta.ErrNotFound))
+	}
+
+	var scheduleRequest web.DoctorScheduleRequest
+
+	if err := c.Bind(&scheduleRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(scheduleRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	schedule := request.ConvertToDoctorScheduleRequest(scheduleRequest, uint(doctorID))
+	schedule.ID = existingSchedule.ID
+	schedule.CreatedAt = existingSchedule.CreatedAt
+
+	if status, err := validateDoctorSchedule(schedule); err != nil {
+		return c.JSON(status, helper.ErrorResponse(err.Error()))
+	}
+
+	if err := configs.DB.Save(&schedule).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"schedule"))
+	}
+
+	response := response.ConvertToDoctorScheduleResponse(schedule)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"schedule", response))
+}
+
+// validateDoctorSchedule normalizes the schedule clocks and rejects schedules overlapping another one on the same day
+func validateDoctorSchedule(schedule *schema.DoctorSchedule) (int, error) {
+
+	startTime, endTime, err := parseScheduleClock(schedule.StartTime, schedule.EndTime)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	schedule.StartTime, schedule.EndTime = startTime, endTime
+
+	var overlapping int64
+	if err := configs.DB.Model(&schema.DoctorSchedule{}).
+		Where("doctor_id = ? AND day_of_week = ? AND id <> ?", schedule.DoctorID, schedule.DayOfWeek, schedule.ID).
+		Where("start_time < ? AND end_time > ?", schedule.EndTime, schedule.StartTime).
+		Count(&overlapping).Error; err != nil {
+		return http.StatusInternalServerError, errors.New(constanta.ErrActionGet + "doctor schedules")
+	}
+
+	if overlapping > 0 {
+		return http.StatusConflict, errors.New("schedule overlaps an existing schedule")
+	}
+
+	return http.StatusOK, nil
+}
+
+// Doctor Delete Schedule
+func DeleteDoctorScheduleController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	scheduleID, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid schedule id"))
+	}
+
+	var schedule schema.DoctorSchedule
+	if err := configs.DB.First(&schedule, "id = ? AND doctor_id = ?", scheduleID, doctorID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("schedule "+constanta.ErrNotFound))
+	}
+
+	if err := configs.DB.Delete(&schedule).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionDeleted+"schedule"))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionDeleted+"schedule", nil))
+}
+
+// Doctor Get All Schedule Exceptions
+func GetDoctorScheduleExceptionsController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	var exceptions []schema.DoctorScheduleException
+	if err := configs.DB.Where("doctor_id = ? AND date >= ?", doctorID, time.Now().In(ScheduleLocation()).Format(dateLayout)).Order("date, start_time").Find(&exceptions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"schedule exceptions"))
+	}
+
+	if len(exceptions) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("schedule exceptions "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToDoctorScheduleExceptionListResponse(exceptions)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"schedule exceptions", response))
+}
+
+// Doctor Create Schedule Exception
+func CreateDoctorScheduleExceptionController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	var exceptionRequest web.DoctorScheduleExceptionRequest
+
+	if err := c.Bind(&exceptionRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(exceptionRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	date, err := time.ParseInLocation(dateLayout, exceptionRequest.Date, ScheduleLocation())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid date, use YYYY-MM-DD"))
+	}
+
+	// the date column has no zone, the database connection writes times in the server zone so the
+	// calendar day of the schedule is handed over at midnight of that zone
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+
+	// a partial day block needs both clocks, a whole day block neither
+	if exceptionRequest.StartTime != "" || exceptionRequest.EndTime != "" {
+		startTime, endTime, err := parseScheduleClock(exceptionRequest.StartTime, exceptionRequest.EndTime)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+		}
+		exceptionRequest.StartTime, exceptionRequest.EndTime = startTime, endTime
+	}
+
+	exception := request.ConvertToDoctorScheduleExceptionRequest(exceptionRequest, uint(doctorID), date)
+
+	if err := configs.DB.Create(&exception).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"schedule exception"))
+	}
+
+	response := response.ConvertToDoctorScheduleExceptionResponse(exception)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"schedule exception", response))
+}
+
+// Doctor Delete Schedule Exception
+func DeleteDoctorScheduleExceptionController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	exceptionID, err := strconv.Atoi(c.Param("exception_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid schedule exception id"))
+	}
+
+	var exception schema.DoctorScheduleException
+	if err := configs.DB.First(&exception, "id = ? AND doctor_id = ?", exceptionID, doctorID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("schedule exception "+constanta.ErrNotFound))
+	}
+
+	if err := configs.DB.Delete(&exception).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionDeleted+"schedule exception"))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionDeleted+"schedule exception", nil))
+}
+
+// Doctor Get Own Slots
+func GetDoctorOwnSlotsController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	return getDoctorSlots(c, uint(doctorID))
+}
+
+// User Get Doctor Slots
+func GetDoctorSlotsController(c echo.Context) error {
+
+	doctorID, err := strconv.Atoi(c.Param("doctor_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	var doctor schema.Doctor
+	if err := configs.DB.First(&doctor, doctorID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor "+constanta.ErrNotFound))
+	}
+
+	return getDoctorSlots(c, doctor.ID)
+}
+
+func getDoctorSlots(c echo.Context, doctorID uint) error {
+
+	from, to, err := parseSlotsWindow(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	slots, err := GetDoctorSlots(doctorID, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"schedule slots"))
+	}
+
+	if len(slots) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("schedule slots "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToDoctorSlotListResponse(slots)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"schedule slots", response))
+}
@@ -1,19 +1,24 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"healthcare/configs"
 	"healthcare/models/schema"
 	"healthcare/models/web"
 	"healthcare/utils/helper"
 	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/helper/voucher"
 	"healthcare/utils/request"
 	"healthcare/utils/response"
 	"net/http"
-	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func GetAllDoctorTransactionPagination(userID int, offset int, limit int, paymentStatus string, queryInput []schema.DoctorTransaction) ([]schema.DoctorTransaction, int64, error) {
@@ -67,63 +72,94 @@ func CreateDoctorTransactionController(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid input doctor transaction data"))
 	}
 
-	err = c.Request().ParseMultipartForm(10 << 20) // 10 MB limit
+	provider, err := payment.ForMethod(doctorTransactionRequest.PaymentMethod)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(paymentMethodError()))
 	}
 
-	file, fileHeader, err := c.Request().FormFile("payment_confirmation")
+	paymentConfirmation, err := uploadPaymentConfirmation(c, provider.RequiresConfirmation())
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("payment confirmation file is required"))
+		return c.JSON(paymentConfirmationStatus(err), helper.ErrorResponse(err.Error()))
 	}
-	defer file.Close()
 
-	if fileHeader.Size > 10*1024*1024 { // 10 MB limit
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("image file size exceeds the limit (10 MB)"))
-	}
+	doctorTransactionRequest.PaymentConfirmation = paymentConfirmation
 
-	allowedExtensions := []string{".jpg", ".jpeg", ".png"}
-	ext := filepath.Ext(fileHeader.Filename)
-	allowed := false
-	for _, validExt := range allowedExtensions {
-		if ext == validExt {
-			allowed = true
-			break
-		}
-	}
-	if !allowed {
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid image file format. supported formats: jpg, jpeg, png"))
+	if err := helper.ValidateStruct(doctorTransactionRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
 	}
 
-	paymentConfirmations, err := helper.UploadFilesToGCS(c, fileHeader)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("error upload image to cloud storage"))
+	var doctor schema.Doctor
+
+	if err := configs.DB.First(&doctor, "id = ?", doctorID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor data"))
 	}
 
-	doctorTransactionRequest.PaymentConfirmation = paymentConfirmations
+	// without a schedule slot the consultation starts right away, so the doctor has to be online
+	var slot *schema.DoctorSlot
+	if doctorTransactionRequest.ScheduleStart == "" {
+		if !doctor.Status {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse("doctor is offline, book a schedule slot instead"))
+		}
+	} else {
+		scheduleStart, err := time.Parse(time.RFC3339, doctorTransactionRequest.ScheduleStart)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid schedule start, use RFC3339 format"))
+		}
 
-	if err := helper.ValidateStruct(doctorTransactionRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+		availableSlot, err := findDoctorSlot(doctor.ID, scheduleStart)
+		if err != nil {
+			if errors.Is(err, errSlotNotFound) {
+				return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+			}
+			if errors.Is(err, errSlotBooked) {
+				return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+			}
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"schedule slots"))
+		}
+		slot = &availableSlot
 	}
 
-	paymentMethod := doctorTransactionRequest.PaymentMethod
+	doctorTransaction := request.ConvertToCreateDoctorTransactionRequest(doctorTransactionRequest, uint(userID), uint(doctorID), doctor.Fullname, doctor.Specialist, doctor.Price)
 
-	if !helper.PaymentMethodIsValid(paymentMethod) {
-		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid input payment method data ('manual transfer bca', 'manual transfer bri', 'manual transfer bni')"))
+	if slot != nil {
+		doctorTransaction.ScheduleStart = &slot.StartTime
+		doctorTransaction.ScheduleEnd = &slot.EndTime
 	}
 
-	var doctor schema.Doctor
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applyDoctorVoucher(tx, doctorTransaction, doctor); err != nil {
+			return err
+		}
 
-	if err := configs.DB.First(&doctor, "id = ? AND status = true", doctorID).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor data"))
-	}
+		if err := tx.Create(&doctorTransaction).Error; err != nil {
+			return err
+		}
 
-	doctorTransaction := request.ConvertToCreateDoctorTransactionRequest(doctorTransactionRequest, uint(userID), uint(doctorID), doctor.Fullname, doctor.Specialist, doctor.Price)
+		if err := voucher.RedeemDoctorTransaction(tx, doctorTransaction); err != nil {
+			return err
+		}
 
-	if err := configs.DB.Create(&doctorTransaction).Error; err != nil {
+		if slot == nil {
+			return nil
+		}
+
+		slot.DoctorTransactionID = doctorTransaction.ID
+		return bookDoctorSlot(tx, slot)
+	})
+	if err != nil {
+		if errors.Is(err, errSlotBooked) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
+		if status := voucherErrorStatus(err); status != http.StatusInternalServerError {
+			return c.JSON(status, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to create doctor transaction"))
 	}
 
+	if err := chargeDoctorTransaction(provider, doctorTransaction); err != nil {
+		return c.JSON(http.StatusBadGateway, helper.ErrorResponse("failed to create payment charge"))
+	}
+
 	response := response.ConvertToCreateDoctorTransactionResponse(doctorTransaction, doctor)
 
 	return c.JSON(http.StatusCreated, helper.SuccessResponse("doctor transaction created successful", response))
@@ -248,3 +284,83 @@ func GetAllDoctorTransactionsController(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, helper.PaginationResponse("doctor transaction data successfully retrieved", responses, pagination))
 }
+
+// User Get Consultation History of Doctor Transaction
+func GetDoctorTransactionHistoryController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid transaction id"))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "user_id = ? AND id = ?", userID, transactionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction "+constanta.ErrNotFound))
+	}
+
+	return getConsultationHistory(c, doctorTransaction.ID)
+}
+
+func getConsultationHistory(c echo.Context, transactionID uint) error {
+
+	var transitions []schema.ConsultationTransition
+	if err := configs.DB.Where("doctor_transaction_id = ?", transactionID).Order("id ASC").Find(&transitions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"consultation history"))
+	}
+
+	if len(transitions) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("consultation history "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToConsultationTransitionListResponse(transitions)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"consultation history", response))
+}
+
+// User Check Doctor Transaction Payment Status
+func CheckDoctorTransactionPaymentController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid transaction id"))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "user_id = ? AND id = ?", userID, transactionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction "+constanta.ErrNotFound))
+	}
+
+	// providers without a status query, like manual transfers, keep the stored status
+	if provider, err := payment.ByName(doctorTransaction.PaymentProvider); err == nil && doctorTransaction.PaymentStatus == payment.StatusPending {
+		status, err := provider.QueryStatus(doctorTransaction.PaymentReference)
+		if err != nil && !errors.Is(err, payment.ErrNotSupported) {
+			return c.JSON(http.StatusBadGateway, helper.ErrorResponse("failed to query payment status"))
+		}
+
+		if err == nil {
+			note := "payment " + status + " reported by " + provider.Name()
+			if err := settleDoctorTransactionPayment(configs.DB, &doctorTransaction, status, lifecycle.ActorSystem, 0, note); err != nil {
+				return c.JSON(paymentSettleStatus(err), helper.ErrorResponse(err.Error()))
+			}
+		}
+	}
+
+	var doctor schema.Doctor
+	if err := configs.DB.Unscoped().First(&doctor, doctorTransaction.DoctorID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor data"))
+	}
+
+	response := response.ConvertToGetDoctorTransactionResponse(doctorTransaction, doctor)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"payment status", response))
+}
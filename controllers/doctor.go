@@ -9,6 +9,8 @@ import (
 	"healthcare/models/web"
 	"healthcare/utils/helper"
 	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/consultation"
+	"healthcare/utils/helper/lifecycle"
 	"healthcare/utils/request"
 	"healthcare/utils/response"
 	"net/http"
@@ -508,7 +510,12 @@ func GetDoctorByIDController(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, helper.ErrorResponse(constanta.ErrNotFound))
 	}
 
-	response := response.ConvertToGetIDDoctorResponse(&doctor)
+	rating, reviewCount, err := GetDoctorRating(doctor.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"doctor rating"))
+	}
+
+	response := response.ConvertToGetIDDoctorResponse(&doctor, rating, reviewCount)
 
 	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"doctor details", response))
 }
@@ -658,20 +665,38 @@ func UpdateManageUserController(c echo.Context) error {
 		return c.JSON(http.StatusForbidden, helper.ErrorResponse("payment status is not 'success'"))
 	}
 
-	// Memperbarui Update
-	if requestBody.HealthDetails != "" {
-		doctorTransaction.HealthDetails = requestBody.HealthDetails
-	}
+	// Memperbarui status konsultasi sesuai status pasien
+	from := doctorTransaction.ConsultationStatus
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if requestBody.PatientStatus != "" {
+			target := lifecycle.PatientStatusTarget(doctorTransaction.ConsultationStatus, requestBody.PatientStatus)
+			if err := consultation.Transition(tx, &doctorTransaction, target, lifecycle.ActorDoctor, uint(doctorID), "patient status set to "+requestBody.PatientStatus); err != nil {
+				return err
+			}
+			doctorTransaction.PatientStatus = requestBody.PatientStatus
+		}
 
-	if requestBody.PatientStatus != "" {
-		doctorTransaction.PatientStatus = requestBody.PatientStatus
-	}
+		if requestBody.HealthDetails != "" {
+			doctorTransaction.HealthDetails = requestBody.HealthDetails
+		}
 
-	// Menyimpan transaksi dokter yang diperbarui ke database
-	if err := configs.DB.Save(&doctorTransaction).Error; err != nil {
+		return tx.Model(&doctorTransaction).Updates(map[string]interface{}{
+			"health_details": doctorTransaction.HealthDetails,
+			"patient_status": doctorTransaction.PatientStatus,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, lifecycle.ErrInvalidTransition) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"health details and patient status"))
 	}
 
+	// a consultation closed or referred here ends its roomchat like closing it from the roomchat does
+	if doctorTransaction.ConsultationStatus != from {
+		consultation.DisconnectTransaction(configs.DB, &doctorTransaction, lifecycle.ActorDoctor, uint(doctorID))
+	}
+
 	// Mendapatkan data pengguna
 	var user schema.User
 	err = configs.DB.First(&user, "id=?", doctorTransaction.UserID).Error
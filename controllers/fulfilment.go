@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/fulfilment"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/response"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// preloadTimeline loads the tracking timeline of a checkout in the order it happened
+func preloadTimeline(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at ASC, id ASC")
+}
+
+// fulfilmentErrorStatus maps a fulfilment error to its http status
+func fulfilmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, fulfilment.ErrTrackingRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, fulfilment.ErrInvalidTransition), errors.Is(err, fulfilment.ErrNotPaid):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Admin Update Fulfilment of a Checkout
+func UpdateCheckoutFulfilmentByAdminController(c echo.Context) error {
+
+	adminID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid admin id"))
+	}
+
+	checkoutID, err := strconv.Atoi(c.Param("checkout_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid checkout id"))
+	}
+
+	var fulfilmentRequest web.FulfilmentRequest
+
+	if err := c.Bind(&fulfilmentRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(fulfilmentRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	shipment := fulfilment.Shipment{
+		Courier:        fulfilmentRequest.Courier,
+		TrackingNumber: fulfilmentRequest.TrackingNumber,
+		Note:           fulfilmentRequest.Note,
+	}
+
+	var checkout schema.Checkout
+	var changed bool
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&checkout, checkoutID).Error; err != nil {
+			return err
+		}
+
+		changed = checkout.FulfilmentStatus != fulfilmentRequest.FulfilmentStatus
+
+		return fulfilment.Transition(tx, &checkout, fulfilmentRequest.FulfilmentStatus, shipment, lifecycle.ActorAdmin, uint(adminID))
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse("checkout "+constanta.ErrNotFound))
+		}
+		if status := fulfilmentErrorStatus(err); status != http.StatusInternalServerError {
+			return c.JSON(status, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"checkout fulfilment"))
+	}
+
+	var updated schema.Checkout
+	if err := configs.DB.Preload("MedicineTransaction.MedicineDetails").Preload("FulfilmentEvents", preloadTimeline).First(&updated, checkout.ID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"updated checkout"))
+	}
+
+	if changed {
+		notifyFulfilment(&updated)
+	}
+
+	response := response.ConvertToGetCheckoutResponse(&updated)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"checkout fulfilment", response))
+}
+
+// notifyFulfilment tells the user by email that the medicines were shipped or delivered, other steps stay in the timeline
+func notifyFulfilment(checkout *schema.Checkout) {
+
+	// the name, courier and tracking number are typed in by users and admins
+	var subject, body string
+
+	switch checkout.FulfilmentStatus {
+	case fulfilment.StatusShipped:
+		subject = "Healthify Notification - Obat Telah Dikirim"
+		body = fmt.Sprintf("Hallo %s,\n<br><br>Obat pesanan kamu telah dikirimkan melalui %s dengan nomor resi <strong>%s</strong>.\n<br><br>Kamu dapat melacak pengiriman pada halaman pesanan di aplikasi Healthify.",
+			html.EscapeString(checkout.MedicineTransaction.Name), html.EscapeString(checkout.Courier), html.EscapeString(checkout.TrackingNumber))
+	case fulfilment.StatusDelivered:
+		subject = "Healthify Notification - Obat Telah Diterima"
+		body = fmt.Sprintf("Hallo %s,\n<br><br>Obat pesanan kamu dengan nomor resi <strong>%s</strong> telah sampai di alamat tujuan.\n<br><br>Semoga lekas sembuh!",
+			html.EscapeString(checkout.MedicineTransaction.Name), html.EscapeString(checkout.TrackingNumber))
+	default:
+		return
+	}
+
+	var user schema.User
+	if err := configs.DB.First(&user, checkout.MedicineTransaction.UserID).Error; err != nil {
+		log.Printf("failed to find user of checkout %d: %v\n", checkout.ID, err)
+		return
+	}
+
+	go func(email string) {
+		if err := helper.SendEmail(email, subject, body, "<p>"+body+"</p>"); err != nil {
+			log.Printf("failed to send fulfilment email to %s: %v\n", email, err)
+		}
+	}(user.Email)
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/stock"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultExpiringDays = 30
+	maxExpiringDays     = 365
+)
+
+// Admin Receive Medicine Batch
+func CreateMedicineBatchByAdminController(c echo.Context) error {
+
+	adminID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid admin id"))
+	}
+
+	medicineID, err := strconv.Atoi(c.Param("medicine_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var batchRequest web.MedicineBatchRequest
+
+	if err := c.Bind(&batchRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(batchRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	expiryDate, err := time.ParseInLocation(dateLayout, batchRequest.ExpiryDate, time.Local)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid expiry date, use YYYY-MM-DD"))
+	}
+
+	if expiryDate.Before(stock.Today()) {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("expiry date has already passed"))
+	}
+
+	var medicine schema.Medicine
+	if err := configs.DB.First(&medicine, medicineID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("medicine "+constanta.ErrNotFound))
+	}
+
+	var batch *schema.MedicineBatch
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		batch, err = stock.Receive(tx, medicine.ID, batchRequest.BatchNumber, expiryDate, batchRequest.Quantity, lifecycle.ActorAdmin, uint(adminID), "batch "+batchRequest.BatchNumber+" received")
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, stock.ErrBatchExpiryChanged) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine batch"))
+	}
+
+	batch.Medicine = medicine
+
+	response := response.ConvertToMedicineBatchResponse(batch)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"medicine batch", response))
+}
+
+// Admin Get Batches of a Medicine
+func GetMedicineBatchesByAdminController(c echo.Context) error {
+
+	medicineID, err := strconv.Atoi(c.Param("medicine_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var medicine schema.Medicine
+	if err := configs.DB.First(&medicine, medicineID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("medicine "+constanta.ErrNotFound))
+	}
+
+	var batches []schema.MedicineBatch
+	if err := configs.DB.Where("medicine_id = ?", medicine.ID).Order("expiry_date ASC, id ASC").Find(&batches).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"medicine batches"))
+	}
+
+	if len(batches) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("medicine batches "+constanta.ErrNotFound))
+	}
+
+	for i := range batches {
+		batches[i].Medicine = medicine
+	}
+
+	response := response.ConvertToMedicineBatchListResponse(batches)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"medicine batches", response))
+}
+
+// Admin Get Expiring Medicine Batches
+func GetExpiringMedicineBatchesByAdminController(c echo.Context) error {
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	days := defaultExpiringDays
+	if daysParam := c.QueryParam("days"); daysParam != "" {
+		days, err = strconv.Atoi(daysParam)
+		if err != nil || days < 0 || days > maxExpiringDays {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("days must be between 0 and "+strconv.Itoa(maxExpiringDays)))
+		}
+	}
+
+	// batches already expired but not fully written off are listed too
+	query := configs.DB.Model(&schema.MedicineBatch{}).
+		Where("quantity > 0 AND expiry_date <= ?", stock.Today().AddDate(0, 0, days))
+
+	if medicineID := c.QueryParam("medicine_id"); medicineID != "" {
+		query = query.Where("medicine_id = ?", medicineID)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var batches []schema.MedicineBatch
+	if err := query.Preload("Medicine").Order("expiry_date ASC, id ASC").Limit(limit).Offset(offset).Find(&batches).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"expiring medicine batches"))
+	}
+
+	if len(batches) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("expiring medicine batches "+constanta.ErrNotFound))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToMedicineBatchListResponse(batches)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"expiring medicine batches", response, pagination))
+}
@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/catalogue"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/stock"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxImportFileSize = 5 << 20 // 5 MB
+
+var errImportStockChanged = errors.New("stock changed since the import was checked, export the catalogue again")
+
+// Actions of an imported row
+const (
+	importCreate    = "create"
+	importUpdate    = "update"
+	importUnchanged = "unchanged"
+	importError     = "error"
+)
+
+// importedMedicine pairs a valid csv row with the medicine it creates or updates
+type importedMedicine struct {
+	row        web.MedicineImportRow
+	categoryID uint
+	typeID     uint
+	existing   *schema.Medicine
+	action     string
+}
+
+// Admin Import Medicines from CSV
+func ImportMedicinesByAdminController(c echo.Context) error {
+
+	adminID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid admin id"))
+	}
+
+	dryRun := c.QueryParam("dry_run") == "true"
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("csv file required"))
+	}
+
+	if strings.ToLower(filepath.Ext(fileHeader.Filename)) != ".csv" {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid file format. only csv allowed"))
+	}
+
+	if fileHeader.Size > maxImportFileSize {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("csv file must not be larger than 5 MB"))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("failed to read csv file"))
+	}
+	defer file.Close()
+
+	rows, err := catalogue.Read(file)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	if len(rows) == 0 {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("csv file has no medicines"))
+	}
+
+	report, imports, err := planMedicineImport(rows)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"medicines"))
+	}
+	report.DryRun = dryRun
+
+	// the catalogue is only changed when every row is valid
+	if report.Failed > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, helper.ErrorResponseWithData("medicine import has invalid rows", report))
+	}
+
+	if dryRun {
+		return c.JSON(http.StatusOK, helper.SuccessResponse("medicine import checked, nothing was saved", report))
+	}
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		for _, imported := range imports {
+			if err := applyMedicineImport(tx, imported, uint(adminID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errImportStockChanged) || errors.Is(err, stock.ErrBatchTracked) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to import medicines"))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse("successfully imported medicines", report))
+}
+
+// planMedicineImport validates the rows and matches them by code against the catalogue without changing it
+func planMedicineImport(rows []catalogue.Row) (*web.MedicineImportResponse, []importedMedicine, error) {
+
+	codes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.Medicine.Code != "" {
+			codes = append(codes, row.Medicine.Code)
+		}
+	}
+
+	// deleted medicines keep their code, a row using one of them is rejected instead of restoring it
+	var medicines []schema.Medicine
+	if len(codes) > 0 {
+		if err := configs.DB.Unscoped().Where("code IN ?", codes).Find(&medicines).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	byCode := map[string]schema.Medicine{}
+	for _, medicine := range medicines {
+		byCode[medicine.Code] = medicine
+	}
+
+	// the stock of a batch tracked medicine is only received through its batches
+	var batchTracked []uint
+	if len(medicines) > 0 {
+		if err := configs.DB.Model(&schema.MedicineBatch{}).Distinct().Where("medicine_id IN ?", medicineIDs(medicines)).Pluck("medicine_id", &batchTracked).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	tracked := map[uint]bool{}
+	for _, medicineID := range batchTracked {
+		tracked[medicineID] = true
+	}
+
+	var categories []schema.MedicineCategory
+	if err := configs.DB.Find(&categories).Error; err != nil {
+		return nil, nil, err
+	}
+	categoryIDs := map[string]uint{}
+	for _, category := range categories {
+		categoryIDs[category.Name] = category.ID
+	}
+
+	var medicineTypes []schema.MedicineType
+	if err := configs.DB.Find(&medicineTypes).Error; err != nil {
+		return nil, nil, err
+	}
+	typeIDs := map[string]uint{}
+	for _, medicineType := range medicineTypes {
+		typeIDs[medicineType.Name] = medicineType.ID
+	}
+
+	report := &web.MedicineImportResponse{Total: len(rows)}
+	var imports []importedMedicine
+	firstLine := map[string]int{}
+
+	for _, row := range rows {
+		errs := row.Errors
+
+		if err := helper.ValidateStruct(row.Medicine); err != nil {
+			errs = append(errs, strings.Split(err.Error(), "; ")...)
+		}
+
+		if line, seen := firstLine[row.Medicine.Code]; seen && row.Medicine.Code != "" {
+			errs = append(errs, "code is repeated from line "+strconv.Itoa(line))
+		} else {
+			firstLine[row.Medicine.Code] = row.Line
+		}
+
+		existing, found := byCode[row.Medicine.Code]
+		if found && existing.DeletedAt.Valid {
+			errs = append(errs, "code belongs to a deleted medicine")
+		}
+
+		if found && tracked[existing.ID] && row.Medicine.Stock != nil && *row.Medicine.Stock != existing.Stock {
+			errs = append(errs, stock.ErrBatchTracked.Error())
+		}
+
+		categoryID, categoryFound := categoryIDs[row.Medicine.Category]
+		if row.Medicine.Category != "" && !categoryFound {
+			errs = append(errs, "category '"+row.Medicine.Category+"' not found")
+		}
+
+		typeID, typeFound := typeIDs[row.Medicine.Type]
+		if row.Medicine.Type != "" && !typeFound {
+			errs = append(errs, "type '"+row.Medicine.Type+"' not found")
+		}
+
+		result := web.MedicineImportRowResponse{Line: row.Line, Code: row.Medicine.Code}
+
+		if len(errs) > 0 {
+			result.Action = importError
+			result.Errors = errs
+			report.Failed++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+
+		imported := importedMedicine{row: row.Medicine, categoryID: categoryID, typeID: typeID, action: importCreate}
+		if found {
+			imported.existing = &existing
+			imported.action = importUpdate
+			if !medicineImportChanges(existing, row.Medicine) && sameID(existing.CategoryID, categoryID) && sameID(existing.TypeID, typeID) {
+				imported.action = importUnchanged
+			}
+		}
+
+		switch imported.action {
+		case importCreate:
+			report.Created++
+		case importUpdate:
+			report.Updated++
+		case importUnchanged:
+			report.Unchanged++
+		}
+
+		result.Action = imported.action
+		report.Rows = append(report.Rows, result)
+		imports = append(imports, imported)
+	}
+
+	return report, imports, nil
+}
+
+// medicineImportChanges reports whether a row changes anything on an existing medicine, empty optional columns keep their value
+func medicineImportChanges(medicine schema.Medicine, row web.MedicineImportRow) bool {
+	return medicine.Name != row.Name ||
+		medicine.Merk != row.Merk ||
+		medicine.Category != row.Category ||
+		medicine.Type != row.Type ||
+		medicine.Price != row.Price ||
+		medicine.Details != row.Details ||
+		(row.Image != "" && medicine.Image != row.Image) ||
+		(row.Stock != nil && medicine.Stock != *row.Stock) ||
+		(row.ReorderThreshold != nil && medicine.ReorderThreshold != *row.ReorderThreshold) ||
+		(row.Weight != nil && medicine.Weight != *row.Weight)
+}
+
+// medicineIDs lists the ids of medicines
+func medicineIDs(medicines []schema.Medicine) []uint {
+	ids := make([]uint, 0, len(medicines))
+	for _, medicine := range medicines {
+		ids = append(ids, medicine.ID)
+	}
+	return ids
+}
+
+// sameID reports whether an optional reference already points at id
+func sameID(current *uint, id uint) bool {
+	return current != nil && *current == id
+}
+
+// applyMedicineImport writes one planned row, stock changes go through the ledger
+func applyMedicineImport(tx *gorm.DB, imported importedMedicine, adminID uint) error {
+
+	row := imported.row
+
+	switch imported.action {
+	case importCreate:
+		medicine := schema.Medicine{
+			Code:       row.Code,
+			Name:       row.Name,
+			Merk:       row.Merk,
+			CategoryID: &imported.categoryID,
+			Category:   row.Category,
+			TypeID:     &imported.typeID,
+			Type:       row.Type,
+			Price:      row.Price,
+			Details:    row.Details,
+			Image:      row.Image,
+		}
+		if row.ReorderThreshold != nil {
+			medicine.ReorderThreshold = *row.ReorderThreshold
+		}
+		if row.Weight != nil {
+			medicine.Weight = *row.Weight
+		}
+
+		if err := tx.Create(&medicine).Error; err != nil {
+			return err
+		}
+
+		if row.Stock != nil && *row.Stock > 0 {
+			if _, err := stock.Adjust(tx, medicine.ID, *row.Stock, stock.MovementRestock, lifecycle.ActorAdmin, adminID, "catalogue import"); err != nil {
+				return err
+			}
+		}
+
+	case importUpdate:
+		updates := map[string]interface{}{
+			"name":        row.Name,
+			"merk":        row.Merk,
+			"category_id": imported.categoryID,
+			"category":    row.Category,
+			"type_id":     imported.typeID,
+			"type":        row.Type,
+			"price":       row.Price,
+			"details":     row.Details,
+		}
+		if row.Image != "" {
+			updates["image"] = row.Image
+		}
+		if row.ReorderThreshold != nil {
+			updates["reorder_threshold"] = *row.ReorderThreshold
+		}
+		if row.Weight != nil {
+			updates["weight"] = *row.Weight
+		}
+
+		if err := tx.Model(imported.existing).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// the stock is imported as the change from the stock the row was checked against, orders placed since
+		// then would otherwise be written over by the file
+		if row.Stock != nil && *row.Stock != imported.existing.Stock {
+			var medicine schema.Medicine
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").First(&medicine, imported.existing.ID).Error; err != nil {
+				return err
+			}
+			if medicine.Stock != imported.existing.Stock {
+				return errImportStockChanged
+			}
+
+			tracked, err := stock.Tracked(tx, medicine.ID)
+			if err != nil {
+				return err
+			}
+			if tracked {
+				return stock.ErrBatchTracked
+			}
+
+			if _, err := stock.Adjust(tx, medicine.ID, *row.Stock-medicine.Stock, stock.MovementAdjustment, lifecycle.ActorAdmin, adminID, "catalogue import"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Admin Export Medicines to CSV
+func ExportMedicinesByAdminController(c echo.Context) error {
+
+	var medicines []schema.Medicine
+	if err := configs.DB.Order("code ASC, id ASC").Find(&medicines).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"medicines"))
+	}
+
+	var buffer bytes.Buffer
+	if err := catalogue.Write(&buffer, medicines); err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to export medicines"))
+	}
+
+	filename := "medicines-" + time.Now().Format("20060102") + ".csv"
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=\""+filename+"\"")
+
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buffer.Bytes())
+}
@@ -0,0 +1,363 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	errCategoryNotFound = errors.New("medicine category " + constanta.ErrNotFound)
+	errTypeNotFound     = errors.New("medicine type " + constanta.ErrNotFound)
+	errTaxonomyInUse    = errors.New("still used by medicines")
+	errCategoryTargeted = errors.New("still targeted by vouchers")
+	errTaxonomyExists   = errors.New("name already exists")
+	errCodeExists       = errors.New("medicine code already exists")
+)
+
+// resolveMedicineCategory finds a category by id, or by name when no id is given
+func resolveMedicineCategory(tx *gorm.DB, id uint, name string) (*schema.MedicineCategory, error) {
+	var category schema.MedicineCategory
+
+	query := tx.Where("id = ?", id)
+	if id == 0 {
+		query = tx.Where("name = ?", strings.TrimSpace(name))
+	}
+
+	if err := query.First(&category).Error; err != nil {
+		return nil, errCategoryNotFound
+	}
+	return &category, nil
+}
+
+// resolveMedicineType finds a type by id, or by name when no id is given
+func resolveMedicineType(tx *gorm.DB, id uint, name string) (*schema.MedicineType, error) {
+	var medicineType schema.MedicineType
+
+	query := tx.Where("id = ?", id)
+	if id == 0 {
+		query = tx.Where("name = ?", strings.TrimSpace(name))
+	}
+
+	if err := query.First(&medicineType).Error; err != nil {
+		return nil, errTypeNotFound
+	}
+	return &medicineType, nil
+}
+
+// medicineCodeTaken reports whether another medicine, deleted ones included, already uses code
+func medicineCodeTaken(code string, exceptID uint) (bool, error) {
+	var count int64
+	err := configs.DB.Unscoped().Model(&schema.Medicine{}).Where("code = ? AND id <> ?", code, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+// taxonomyNameTaken reports whether another row of the category or type table already has name
+func taxonomyNameTaken(tx *gorm.DB, model interface{}, name string, exceptID uint) (bool, error) {
+	var count int64
+	err := tx.Model(model).Where("name = ? AND id <> ?", name, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+// taxonomyErrorStatus maps a category or type error to its http status
+func taxonomyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errCategoryNotFound), errors.Is(err, errTypeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errTaxonomyInUse), errors.Is(err, errTaxonomyExists), errors.Is(err, errCategoryTargeted):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Get All Medicine Categories
+func GetMedicineCategoriesController(c echo.Context) error {
+
+	var categories []schema.MedicineCategory
+	if err := configs.DB.Order("name ASC").Find(&categories).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"medicine categories"))
+	}
+
+	if len(categories) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("medicine categories "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToMedicineCategoryListResponse(categories)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"medicine categories", response))
+}
+
+// Admin Create Medicine Category
+func CreateMedicineCategoryByAdminController(c echo.Context) error {
+
+	var categoryRequest web.MedicineCategoryRequest
+
+	if err := c.Bind(&categoryRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(categoryRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	category := request.ConvertToMedicineCategoryRequest(categoryRequest)
+
+	taken, err := taxonomyNameTaken(configs.DB, &schema.MedicineCategory{}, category.Name, 0)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine category"))
+	}
+	if taken {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse("medicine category "+errTaxonomyExists.Error()))
+	}
+
+	if err := configs.DB.Create(category).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine category"))
+	}
+
+	response := response.ConvertToMedicineCategoryResponse(category)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"medicine category", response))
+}
+
+// Admin Update Medicine Category, the new name is copied to its medicines
+func UpdateMedicineCategoryByAdminController(c echo.Context) error {
+
+	categoryID, err := strconv.Atoi(c.Param("category_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var categoryRequest web.MedicineCategoryRequest
+
+	if err := c.Bind(&categoryRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(categoryRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	name := request.ConvertToMedicineCategoryRequest(categoryRequest).Name
+
+	var category schema.MedicineCategory
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&category, categoryID).Error; err != nil {
+			return errCategoryNotFound
+		}
+
+		taken, err := taxonomyNameTaken(tx, &schema.MedicineCategory{}, name, category.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errTaxonomyExists
+		}
+
+		if err := tx.Model(&category).Update("name", name).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&schema.Medicine{}).Where("category_id = ?", category.ID).Update("category", name).Error
+	})
+	if err != nil {
+		return c.JSON(taxonomyErrorStatus(err), helper.ErrorResponse(taxonomyErrorMessage(err, "medicine category", constanta.ErrActionUpdated)))
+	}
+
+	response := response.ConvertToMedicineCategoryResponse(&category)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"medicine category", response))
+}
+
+// Admin Delete Medicine Category
+func DeleteMedicineCategoryByAdminController(c echo.Context) error {
+
+	categoryID, err := strconv.Atoi(c.Param("category_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		var category schema.MedicineCategory
+		if err := tx.First(&category, categoryID).Error; err != nil {
+			return errCategoryNotFound
+		}
+
+		// deleted medicines still point at their category
+		var used int64
+		if err := tx.Unscoped().Model(&schema.Medicine{}).Where("category_id = ?", category.ID).Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return errTaxonomyInUse
+		}
+
+		var targeted int64
+		if err := tx.Model(&schema.VoucherTarget{}).Where("medicine_category_id = ?", category.ID).Count(&targeted).Error; err != nil {
+			return err
+		}
+		if targeted > 0 {
+			return errCategoryTargeted
+		}
+
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		return c.JSON(taxonomyErrorStatus(err), helper.ErrorResponse(taxonomyErrorMessage(err, "medicine category", constanta.ErrActionDeleted)))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionDeleted+"medicine category", nil))
+}
+
+// Get All Medicine Types
+func GetMedicineTypesController(c echo.Context) error {
+
+	var medicineTypes []schema.MedicineType
+	if err := configs.DB.Order("name ASC").Find(&medicineTypes).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"medicine types"))
+	}
+
+	if len(medicineTypes) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("medicine types "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToMedicineTypeListResponse(medicineTypes)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"medicine types", response))
+}
+
+// Admin Create Medicine Type
+func CreateMedicineTypeByAdminController(c echo.Context) error {
+
+	var typeRequest web.MedicineTypeRequest
+
+	if err := c.Bind(&typeRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(typeRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	medicineType := request.ConvertToMedicineTypeRequest(typeRequest)
+
+	taken, err := taxonomyNameTaken(configs.DB, &schema.MedicineType{}, medicineType.Name, 0)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine type"))
+	}
+	if taken {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse("medicine type "+errTaxonomyExists.Error()))
+	}
+
+	if err := configs.DB.Create(medicineType).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine type"))
+	}
+
+	response := response.ConvertToMedicineTypeResponse(medicineType)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"medicine type", response))
+}
+
+// Admin Update Medicine Type, the new name is copied to its medicines
+func UpdateMedicineTypeByAdminController(c echo.Context) error {
+
+	typeID, err := strconv.Atoi(c.Param("type_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var typeRequest web.MedicineTypeRequest
+
+	if err := c.Bind(&typeRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(typeRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	name := request.ConvertToMedicineTypeRequest(typeRequest).Name
+
+	var medicineType schema.MedicineType
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&medicineType, typeID).Error; err != nil {
+			return errTypeNotFound
+		}
+
+		taken, err := taxonomyNameTaken(tx, &schema.MedicineType{}, name, medicineType.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errTaxonomyExists
+		}
+
+		if err := tx.Model(&medicineType).Update("name", name).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&schema.Medicine{}).Where("type_id = ?", medicineType.ID).Update("type", name).Error
+	})
+	if err != nil {
+		return c.JSON(taxonomyErrorStatus(err), helper.ErrorResponse(taxonomyErrorMessage(err, "medicine type", constanta.ErrActionUpdated)))
+	}
+
+	response := response.ConvertToMedicineTypeResponse(&medicineType)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"medicine type", response))
+}
+
+// Admin Delete Medicine Type
+func DeleteMedicineTypeByAdminController(c echo.Context) error {
+
+	typeID, err := strconv.Atoi(c.Param("type_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		var medicineType schema.MedicineType
+		if err := tx.First(&medicineType, typeID).Error; err != nil {
+			return errTypeNotFound
+		}
+
+		// deleted medicines still point at their type
+		var used int64
+		if err := tx.Unscoped().Model(&schema.Medicine{}).Where("type_id = ?", medicineType.ID).Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return errTaxonomyInUse
+		}
+
+		return tx.Delete(&medicineType).Error
+	})
+	if err != nil {
+		return c.JSON(taxonomyErrorStatus(err), helper.ErrorResponse(taxonomyErrorMessage(err, "medicine type", constanta.ErrActionDeleted)))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionDeleted+"medicine type", nil))
+}
+
+// taxonomyErrorMessage names the category or type in conflicts and hides internal errors
+func taxonomyErrorMessage(err error, subject string, action string) string {
+	switch {
+	case errors.Is(err, errTaxonomyInUse), errors.Is(err, errTaxonomyExists), errors.Is(err, errCategoryTargeted):
+		return subject + " " + err.Error()
+	case errors.Is(err, errCategoryNotFound), errors.Is(err, errTypeNotFound):
+		return err.Error()
+	default:
+		return action + subject
+	}
+}
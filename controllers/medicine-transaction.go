@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"healthcare/configs"
@@ -8,13 +9,90 @@ import (
 	"healthcare/models/web"
 	"healthcare/utils/helper"
 	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/helper/stock"
+	"healthcare/utils/helper/voucher"
 	"healthcare/utils/request"
 	"healthcare/utils/response"
 	"net/http"
 	"strconv"
 	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errMedicineNotFound  = stock.ErrMedicineNotFound
+	errInsufficientStock = stock.ErrInsufficientStock
+	errExpiredStock      = stock.ErrExpiredStock
+	errActiveCheckout    = errors.New("medicine transaction has an active checkout")
+	errPrescriptionOrder = errors.New("medicine transaction for this prescription already exists")
 )
 
+// lockPrescriptionOrder locks a prescription and checks it has no order holding or sold its medicines, so concurrent orders
+// of it wait for each other. An order whose reservation was released does not keep the prescription from being ordered again.
+func lockPrescriptionOrder(tx *gorm.DB, prescriptionID uint, userID uint) error {
+	var prescription schema.Prescription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&prescription, prescriptionID).Error; err != nil {
+		return err
+	}
+
+	var ordered int64
+	if err := tx.Model(&schema.MedicineTransaction{}).
+		Where("prescription_id = ? AND user_id = ?", prescriptionID, userID).
+		Where("reservation_status IN ?", []string{stock.StatusReserved, stock.StatusCommitted}).
+		Count(&ordered).Error; err != nil {
+		return err
+	}
+	if ordered > 0 {
+		return errPrescriptionOrder
+	}
+	return nil
+}
+
+// createMedicineTransaction copies the delivery address, prices the delivery, applies the voucher, reserves the ordered medicines
+// and saves the transaction in one database transaction, so an order is either stored with its stock taken or not stored at all
+func createMedicineTransaction(medicineTransaction *schema.MedicineTransaction, addressID uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if medicineTransaction.PrescriptionID != nil {
+			if err := lockPrescriptionOrder(tx, *medicineTransaction.PrescriptionID, medicineTransaction.UserID); err != nil {
+				return err
+			}
+		}
+		if err := applyDeliveryAddress(tx, medicineTransaction, addressID); err != nil {
+			return err
+		}
+		if err := applyShipping(tx, medicineTransaction); err != nil {
+			return err
+		}
+		if err := applyMedicineVoucher(tx, medicineTransaction); err != nil {
+			return err
+		}
+		if err := stock.Reserve(tx, medicineTransaction, lifecycle.ActorUser, medicineTransaction.UserID); err != nil {
+			return err
+		}
+		return voucher.RedeemMedicineTransaction(tx, medicineTransaction)
+	})
+}
+
+// medicineTransactionErrorStatus maps an order error to its http status
+func medicineTransactionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errMedicineNotFound), errors.Is(err, errAddressNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errAddressRequired), errors.Is(err, errUnknownShippingService):
+		return http.StatusBadRequest
+	case errors.Is(err, errNoShippingRates):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, errInsufficientStock), errors.Is(err, errExpiredStock), errors.Is(err, errPrescriptionOrder):
+		return http.StatusConflict
+	default:
+		return voucherErrorStatus(err)
+	}
+}
+
 func CreateMedicineTransaction(c echo.Context) error {
 	userID, ok := c.Get("userID").(int)
 	if !ok {
@@ -40,27 +118,16 @@ func CreateMedicineTransaction(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
 	}
 
-	medicineTransaction := request.ConvertToMedicineTransactionRequest(medicineTransactionRequest, uint(userID))
-
-	totalPrice := 0
-	for i, md := range medicineTransaction.MedicineDetails {
-		medicine := schema.Medicine{}
-		if err := configs.DB.First(&medicine, md.MedicineID).Error; err != nil {
-			return c.JSON(http.StatusNotFound, helper.ErrorResponse("medicine id "+constanta.ErrNotFound))
-		}
-
-		if medicine.Stock < md.Quantity {
-			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("insufficient stock"))
-		}
-
-		medicineTransaction.MedicineDetails[i].TotalPriceMedicine = md.Quantity * medicine.Price
-
-		totalPrice += medicineTransaction.MedicineDetails[i].TotalPriceMedicine
+	if _, err := payment.ForMethod(medicineTransactionRequest.PaymentMethod); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(paymentMethodError()))
 	}
 
-	medicineTransaction.TotalPrice = totalPrice
+	medicineTransaction := request.ConvertToMedicineTransactionRequest(medicineTransactionRequest, uint(userID))
 
-	if err := configs.DB.Create(&medicineTransaction).Error; err != nil {
+	if err := createMedicineTransaction(medicineTransaction, medicineTransactionRequest.AddressID); err != nil {
+		if status := medicineTransactionErrorStatus(err); status != http.StatusInternalServerError {
+			return c.JSON(status, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine transaction"))
 	}
 
@@ -109,7 +176,37 @@ func DeleteMedicineTransactionController(c echo.Context) error {
 		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
 	}
 
-	if err := configs.DB.Delete(&medicineTransaction).Error; err != nil {
+	// the medicines stay with an order that is being paid or was paid
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&medicineTransaction, medicineTransaction.ID).Error; err != nil {
+			return err
+		}
+
+		var active int64
+		if err := tx.Model(&schema.Checkout{}).
+			Where("medicine_transaction_id = ? AND payment_status IN ?", medicineTransaction.ID, []string{payment.StatusPending, payment.StatusSuccess}).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return errActiveCheckout
+		}
+
+		if _, err := stock.Release(tx, medicineTransaction.ID, stock.MovementRelease, lifecycle.ActorUser, uint(userID), "order deleted"); err != nil {
+			return err
+		}
+
+		// orders released before vouchers were given back with their stock still hold their voucher use
+		if err := voucher.ReleaseMedicineTransaction(tx, medicineTransaction.ID); err != nil {
+			return err
+		}
+
+		return tx.Delete(&medicineTransaction).Error
+	})
+	if err != nil {
+		if errors.Is(err, errActiveCheckout) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionDeleted+"medicine transaction"))
 	}
 
@@ -186,3 +283,48 @@ func GetMedicineTransactionPagination(userID int, offset int, limit int, status
 
 	return medicineTransactions, total, nil
 }
+
+// User Create Medicine Transaction from Prescription
+func CreatePrescriptionMedicineTransactionController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	prescriptionID, err := strconv.Atoi(c.Param("prescription_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid prescription id"))
+	}
+
+	var prescriptionOrderRequest web.PrescriptionOrderRequest
+
+	if err := c.Bind(&prescriptionOrderRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(prescriptionOrderRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	if _, err := payment.ForMethod(prescriptionOrderRequest.PaymentMethod); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(paymentMethodError()))
+	}
+
+	var prescription schema.Prescription
+	if err := configs.DB.Preload("PrescriptionDetails").Where("user_id = ?", userID).First(&prescription, prescriptionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("prescription "+constanta.ErrNotFound))
+	}
+
+	medicineTransaction := request.ConvertToPrescriptionMedicineTransactionRequest(prescriptionOrderRequest, prescription, uint(userID))
+
+	if err := createMedicineTransaction(medicineTransaction, prescriptionOrderRequest.AddressID); err != nil {
+		if status := medicineTransactionErrorStatus(err); status != http.StatusInternalServerError {
+			return c.JSON(status, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine transaction"))
+	}
+
+	response := response.ConvertToMedicineTransactionResponse(medicineTransaction)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"medicine transaction", response))
+}
@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"healthcare/configs"
 	"healthcare/models/schema"
 	"healthcare/models/web"
 	"healthcare/utils/helper"
 	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/stock"
 	"healthcare/utils/request"
 	"healthcare/utils/response"
 	"net/http"
@@ -16,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 // Create Medicine
@@ -31,8 +35,26 @@ func CreateMedicineController(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
 	}
 
+	taken, err := medicineCodeTaken(medicine.Code, 0)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine"))
+	}
+	if taken {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse(errCodeExists.Error()))
+	}
+
+	category, err := resolveMedicineCategory(configs.DB, medicine.CategoryID, medicine.Category)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	medicineType, err := resolveMedicineType(configs.DB, medicine.TypeID, medicine.Type)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
 	// Upload files
-	err := c.Request().ParseMultipartForm(10 << 20) // 10 MB limit
+	err = c.Request().ParseMultipartForm(10 << 20) // 10 MB limit
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
 	}
@@ -63,12 +85,34 @@ func CreateMedicineController(c echo.Context) error {
 
 	medicine.Image = imageURL
 
-	medicineRequest := request.ConvertToMedicineRequest(medicine)
+	adminID, _ := c.Get("userID").(int)
 
-	if err := configs.DB.Create(&medicineRequest).Error; err != nil {
+	medicineRequest := request.ConvertToMedicineRequest(medicine)
+	medicineRequest.CategoryID = &category.ID
+	medicineRequest.Category = category.Name
+	medicineRequest.TypeID = &medicineType.ID
+	medicineRequest.Type = medicineType.Name
+
+	// the initial stock enters through the ledger like every later change
+	initialStock := medicineRequest.Stock
+	medicineRequest.Stock = 0
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&medicineRequest).Error; err != nil {
+			return err
+		}
+		if initialStock == 0 {
+			return nil
+		}
+		_, err := stock.Adjust(tx, medicineRequest.ID, initialStock, stock.MovementRestock, lifecycle.ActorAdmin, uint(adminID), "initial stock")
+		return err
+	})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"medicine"))
 	}
 
+	medicineRequest.Stock = initialStock
+
 	response := response.ConvertToAdminMedicineResponse(medicineRequest)
 
 	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"medicine", response))
@@ -98,8 +142,63 @@ func UpdateMedicineController(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
 	}
 
-	result = configs.DB.Model(&existingMedicine).Updates(updatedMedicineRequest)
-	if result.Error != nil {
+	if updatedMedicineRequest.Code != "" {
+		taken, err := medicineCodeTaken(updatedMedicineRequest.Code, existingMedicine.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"medicine"))
+		}
+		if taken {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(errCodeExists.Error()))
+		}
+	}
+
+	if updatedMedicineRequest.CategoryID != 0 || updatedMedicineRequest.Category != "" {
+		category, err := resolveMedicineCategory(configs.DB, updatedMedicineRequest.CategoryID, updatedMedicineRequest.Category)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+		}
+		updatedMedicineRequest.CategoryID = category.ID
+		updatedMedicineRequest.Category = category.Name
+	}
+
+	if updatedMedicineRequest.TypeID != 0 || updatedMedicineRequest.Type != "" {
+		medicineType, err := resolveMedicineType(configs.DB, updatedMedicineRequest.TypeID, updatedMedicineRequest.Type)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+		}
+		updatedMedicineRequest.TypeID = medicineType.ID
+		updatedMedicineRequest.Type = medicineType.Name
+	}
+
+	adminID, _ := c.Get("userID").(int)
+
+	// a new stock is recorded as an adjustment instead of overwriting the counter
+	newStock := updatedMedicineRequest.Stock
+	updatedMedicineRequest.Stock = 0
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&existingMedicine).Updates(updatedMedicineRequest).Error; err != nil {
+			return err
+		}
+		if newStock == 0 || newStock == existingMedicine.Stock {
+			return nil
+		}
+		tracked, err := stock.Tracked(tx, existingMedicine.ID)
+		if err != nil {
+			return err
+		}
+		if tracked {
+			return stock.ErrBatchTracked
+		}
+		if _, err := stock.Set(tx, existingMedicine.ID, newStock, lifecycle.ActorAdmin, uint(adminID), "stock set on medicine update"); err != nil {
+			return err
+		}
+		return tx.First(&existingMedicine, existingMedicine.ID).Error
+	})
+	if err != nil {
+		if errors.Is(err, stock.ErrBatchTracked) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"medicine"))
 	}
 
@@ -234,31 +333,54 @@ func GetImageMedicineController(c echo.Context) error {
 	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"image medicine", response))
 }
 
-func GetAll(offset, limit int, price, category, keyword string, queryInput []schema.Medicine) ([]schema.Medicine, int64, error) {
+// MedicineFilter holds the catalogue filters shared by the admin and user medicine lists
+type MedicineFilter struct {
+	Keyword     string
+	Price       string
+	Category    string
+	CategoryIDs []uint
+	TypeIDs     []uint
+}
 
-	if offset < 0 || limit < 0 {
-		return nil, 0, nil
+// filtered applies the filters to a medicines query, the category or type id filter can be skipped for its facet counts
+func (filter MedicineFilter) filtered(query *gorm.DB, withCategories bool, withTypes bool) *gorm.DB {
+
+	if filter.Keyword != "" {
+		query = query.Where("(medicines.name LIKE ? OR medicines.merk LIKE ? OR medicines.code LIKE ?)", "%"+filter.Keyword+"%", "%"+filter.Keyword+"%", "%"+filter.Keyword+"%")
 	}
 
-	queryAll := queryInput
-	var total int64
+	if filter.Category != "" {
+		query = query.Where("medicines.category LIKE ?", "%"+filter.Category+"%")
+	}
 
-	query := configs.DB.Model(&queryAll)
+	if withCategories && len(filter.CategoryIDs) > 0 {
+		query = query.Where("medicines.category_id IN ?", filter.CategoryIDs)
+	}
 
-	if keyword != "" {
-		query = query.Where("name LIKE ? OR merk LIKE ? OR code LIKE ?", "%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
+	if withTypes && len(filter.TypeIDs) > 0 {
+		query = query.Where("medicines.type_id IN ?", filter.TypeIDs)
 	}
 
-	if price == "low" {
-		query = query.Order("price ASC")
+	return query
+}
+
+func GetAll(offset, limit int, filter MedicineFilter, queryInput []schema.Medicine) ([]schema.Medicine, int64, error) {
+
+	if offset < 0 || limit < 0 {
+		return nil, 0, nil
 	}
 
-	if price == "high" {
-		query = query.Order("price DESC")
+	queryAll := queryInput
+	var total int64
+
+	query := filter.filtered(configs.DB.Model(&queryAll), true, true)
+
+	if filter.Price == "low" {
+		query = query.Order("medicines.price ASC")
 	}
 
-	if category != "" {
-		query = query.Where("category LIKE ?", "%"+category+"%")
+	if filter.Price == "high" {
+		query = query.Order("medicines.price DESC")
 	}
 
 	query.Find(&queryAll).Count(&total)
@@ -278,6 +400,77 @@ func GetAll(offset, limit int, price, category, keyword string, queryInput []sch
 	return queryAll, total, nil
 }
 
+// medicineFacets counts the filtered medicines per category and per type. Each count ignores its own
+// id filter so the other choices of that facet keep showing how many medicines they would add.
+func medicineFacets(filter MedicineFilter) (*web.MedicineFacetsResponse, error) {
+
+	facets := web.MedicineFacetsResponse{
+		Categories: []web.MedicineFacetResponse{},
+		Types:      []web.MedicineFacetResponse{},
+	}
+
+	categories := filter.filtered(configs.DB.Model(&schema.Medicine{}), false, true).
+		Select("medicine_categories.id, medicine_categories.name, COUNT(medicines.id) AS count").
+		Joins("JOIN medicine_categories ON medicine_categories.id = medicines.category_id").
+		Group("medicine_categories.id, medicine_categories.name").
+		Order("medicine_categories.name ASC")
+	if err := categories.Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	types := filter.filtered(configs.DB.Model(&schema.Medicine{}), true, false).
+		Select("medicine_types.id, medicine_types.name, COUNT(medicines.id) AS count").
+		Joins("JOIN medicine_types ON medicine_types.id = medicines.type_id").
+		Group("medicine_types.id, medicine_types.name").
+		Order("medicine_types.name ASC")
+	if err := types.Scan(&facets.Types).Error; err != nil {
+		return nil, err
+	}
+
+	return &facets, nil
+}
+
+// parseIDList reads a comma separated list of ids like "1,4,7"
+func parseIDList(raw string) ([]uint, error) {
+	var ids []uint
+	if raw == "" {
+		return ids, nil
+	}
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid id '%s'", part)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// medicineFilterParams reads the medicine list filters from the query params
+func medicineFilterParams(c echo.Context) (MedicineFilter, error) {
+	params := c.QueryParams()
+
+	filter := MedicineFilter{
+		Keyword:  params.Get("keyword"),
+		Price:    params.Get("price"),
+		Category: params.Get("category"),
+	}
+
+	categoryIDs, err := parseIDList(params.Get("category_id"))
+	if err != nil {
+		return filter, fmt.Errorf("category_id: %w", err)
+	}
+	filter.CategoryIDs = categoryIDs
+
+	typeIDs, err := parseIDList(params.Get("type_id"))
+	if err != nil {
+		return filter, fmt.Errorf("type_id: %w", err)
+	}
+	filter.TypeIDs = typeIDs
+
+	return filter, nil
+}
+
 // Admin Get All Medicines Pagination
 func GetMedicineAdminController(c echo.Context) error {
 	params := c.QueryParams()
@@ -293,13 +486,14 @@ func GetMedicineAdminController(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
 	}
 
-	keyword := params.Get("keyword")
-	price := params.Get("price")
-	category := params.Get("category")
+	filter, err := medicineFilterParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
 
 	var medicines []schema.Medicine
 
-	medicine, total, err := GetAll(offset, limit, price, category, keyword, medicines)
+	medicine, total, err := GetAll(offset, limit, filter, medicines)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
@@ -346,13 +540,14 @@ func GetMedicineUserController(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
 	}
 
-	keyword := params.Get("keyword")
-	price := params.Get("price")
-	category := params.Get("category")
+	filter, err := medicineFilterParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
 
 	var medicines []schema.Medicine
 
-	medicine, total, err := GetAll(offset, limit, price, category, keyword, medicines)
+	medicine, total, err := GetAll(offset, limit, filter, medicines)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
@@ -363,9 +558,14 @@ func GetMedicineUserController(c echo.Context) error {
 
 	pagination := helper.Pagination(offset, limit, total)
 
+	facets, err := medicineFacets(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"medicines"))
+	}
+
 	response := response.ConvertToUserGetAllMedicinesResponse(medicine)
 
-	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"medicines", response, pagination))
+	return c.JSON(http.StatusOK, helper.FacetedPaginationResponse(constanta.SuccessActionGet+"medicines", response, pagination, facets))
 }
 
 // User Get Medicine by ID
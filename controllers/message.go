@@ -5,12 +5,13 @@ import (
 	"healthcare/models/schema"
 	"healthcare/models/web"
 	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/hub"
 	"healthcare/utils/request"
 	"healthcare/utils/response"
 	"net/http"
 	"path/filepath"
 	"strconv"
-	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -33,7 +34,7 @@ func CreateComplaintMessageController(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve roomchat data"))
 	}
 
-	if existingRoomchat.ExpirationTime != nil && time.Now().After(*existingRoomchat.ExpirationTime) {
+	if !roomchatOpen(existingRoomchat) {
 		return c.JSON(http.StatusForbidden, helper.ErrorResponse("roomchat expired"))
 	}
 
@@ -139,6 +140,8 @@ func CreateComplaintMessageController(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to send complaint message"))
 	}
 
+	hub.Default.Publish(uint(roomchatID), response.ConvertToRoomchatMessageEventResponse(complaint))
+
 	response := response.ConvertToCreateMessageResponse(complaint)
 
 	return c.JSON(http.StatusCreated, helper.SuccessResponse("complaint message successful send", response))
@@ -162,7 +165,7 @@ func CreateAdviceMessageController(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve roomchat data"))
 	}
 
-	if existingRoomchat.ExpirationTime != nil && time.Now().After(*existingRoomchat.ExpirationTime) {
+	if !roomchatOpen(existingRoomchat) {
 		return c.JSON(http.StatusForbidden, helper.ErrorResponse("roomchat expired"))
 	}
 
@@ -266,7 +269,175 @@ func CreateAdviceMessageController(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to send advice message"))
 	}
 
+	hub.Default.Publish(uint(roomchatID), response.ConvertToRoomchatMessageEventResponse(advice))
+
 	response := response.ConvertToCreateMessageResponse(advice)
 
 	return c.JSON(http.StatusCreated, helper.SuccessResponse("advice message successful send", response))
 }
+
+const (
+	defaultMessageLimit = 20
+	maxMessageLimit     = 100
+)
+
+// GetMessageCursorPagination returns messages of a roomchat in ascending order, older than before or newer than after
+func GetMessageCursorPagination(roomchatID uint, before int, after int, limit int) ([]schema.Message, bool, error) {
+
+	var messages []schema.Message
+
+	query := configs.DB.Where("roomchat_id = ?", roomchatID)
+
+	if after > 0 {
+		query = query.Where("id > ?", after).Order("id ASC")
+	} else {
+		if before > 0 {
+			query = query.Where("id < ?", before)
+		}
+		query = query.Order("id DESC")
+	}
+
+	if err := query.Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
+	if after <= 0 {
+		reverseMessages(messages)
+	}
+
+	return messages, hasMore, nil
+}
+
+func reverseMessages(messages []schema.Message) {
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+}
+
+// GetLastMessages returns the latest message of every given roomchat without loading the full histories
+func GetLastMessages(roomchatIDs []uint) (map[uint]schema.Message, error) {
+
+	lastMessages := make(map[uint]schema.Message)
+	if len(roomchatIDs) == 0 {
+		return lastMessages, nil
+	}
+
+	var messages []schema.Message
+
+	latestIDs := configs.DB.Model(&schema.Message{}).Select("MAX(id)").Where("roomchat_id IN ?", roomchatIDs).Group("roomchat_id")
+
+	if err := configs.DB.Where("id IN (?)", latestIDs).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	for _, message := range messages {
+		lastMessages[message.RoomchatID] = message
+	}
+
+	return lastMessages, nil
+}
+
+// User Get Roomchat Messages
+func GetUserRoomchatMessagesController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var existingRoomchat schema.Roomchat
+	if err := configs.DB.First(&existingRoomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve roomchat data"))
+	}
+
+	var doctortransaction schema.DoctorTransaction
+	if err := configs.DB.Where("user_id = ? AND id = ?", userID, existingRoomchat.TransactionID).First(&doctortransaction).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor transaction data"))
+	}
+
+	return getRoomchatMessages(c, existingRoomchat.ID)
+}
+
+// Doctor Get Roomchat Messages
+func GetDoctorRoomchatMessagesController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var existingRoomchat schema.Roomchat
+	if err := configs.DB.First(&existingRoomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve roomchat data"))
+	}
+
+	var doctortransaction schema.DoctorTransaction
+	if err := configs.DB.Where("doctor_id = ? AND id = ?", doctorID, existingRoomchat.TransactionID).First(&doctortransaction).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor transaction data"))
+	}
+
+	return getRoomchatMessages(c, existingRoomchat.ID)
+}
+
+func getRoomchatMessages(c echo.Context, roomchatID uint) error {
+
+	limit := defaultMessageLimit
+	if c.QueryParam("limit") != "" {
+		parsedLimit, err := strconv.Atoi(c.QueryParam("limit"))
+		if err != nil || parsedLimit < 1 || parsedLimit > maxMessageLimit {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrInvalidParam))
+		}
+		limit = parsedLimit
+	}
+
+	var before, after int
+	var err error
+
+	if c.QueryParam("before") != "" {
+		if before, err = strconv.Atoi(c.QueryParam("before")); err != nil || before < 1 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("before"+constanta.ErrInvalidParam))
+		}
+	}
+
+	if c.QueryParam("after") != "" {
+		if after, err = strconv.Atoi(c.QueryParam("after")); err != nil || after < 1 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("after"+constanta.ErrInvalidParam))
+		}
+	}
+
+	if before > 0 && after > 0 {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("use either before or after, not both"))
+	}
+
+	messages, hasMore, err := GetMessageCursorPagination(roomchatID, before, after, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"messages"))
+	}
+
+	var firstID, lastID uint
+	if len(messages) > 0 {
+		firstID = messages[0].ID
+		lastID = messages[len(messages)-1].ID
+	}
+
+	pagination := helper.CursorPagination(limit, firstID, lastID, hasMore)
+
+	response := response.ConvertToMessageListResponse(messages)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"messages", response, pagination))
+}
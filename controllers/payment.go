@@ -0,0 +1,449 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/consultation"
+	"healthcare/utils/helper/fulfilment"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/helper/stock"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errConfirmationFileSize   = errors.New("image file size exceeds the limit (10 MB)")
+	errConfirmationFileFormat = errors.New("invalid image file format. supported formats: jpg, jpeg, png")
+	errConfirmationUpload     = errors.New("error upload image to cloud storage")
+	errPaymentSettled         = errors.New("payment status was changed by another request")
+	errPaymentEventID         = errors.New("payment event id is required")
+	errCheckoutShipped        = errors.New("checkout has already been shipped")
+)
+
+// paymentMethodError lists the accepted payment methods in the error message
+func paymentMethodError() string {
+	return "invalid input payment method data ('" + strings.Join(payment.Methods(), "', '") + "')"
+}
+
+// uploadPaymentConfirmation stores the proof of payment of the request, the file is only mandatory when required is set
+func uploadPaymentConfirmation(c echo.Context, required bool) (string, error) {
+
+	file, fileHeader, err := c.Request().FormFile("payment_confirmation")
+	if err != nil {
+		if required {
+			return "", payment.ErrConfirmationRequired
+		}
+		return "", nil
+	}
+	defer file.Close()
+
+	if fileHeader.Size > 10*1024*1024 { // 10 MB limit
+		return "", errConfirmationFileSize
+	}
+
+	allowedExtensions := []string{".jpg", ".jpeg", ".png"}
+	ext := filepath.Ext(fileHeader.Filename)
+	allowed := false
+	for _, validExt := range allowedExtensions {
+		if ext == validExt {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", errConfirmationFileFormat
+	}
+
+	paymentConfirmation, err := helper.UploadFilesToGCS(c, fileHeader)
+	if err != nil {
+		return "", errConfirmationUpload
+	}
+
+	return paymentConfirmation, nil
+}
+
+// paymentConfirmationStatus maps an upload error to its http status
+func paymentConfirmationStatus(err error) int {
+	if errors.Is(err, errConfirmationUpload) {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// chargeDoctorTransaction opens the provider charge of a new doctor transaction. The transaction is
+// cancelled, releasing its slot, when the provider refuses the charge.
+func chargeDoctorTransaction(provider payment.PaymentProvider, doctorTransaction *schema.DoctorTransaction) error {
+
+	charge, err := provider.CreateCharge(payment.Charge{
+		Reference:    fmt.Sprintf("doctor-transaction-%d", doctorTransaction.ID),
+		Amount:       doctorTransaction.Price,
+		Method:       doctorTransaction.PaymentMethod,
+		Confirmation: doctorTransaction.PaymentConfirmation,
+	})
+	if err != nil {
+		cancelErr := configs.DB.Transaction(func(tx *gorm.DB) error {
+			return consultation.Transition(tx, doctorTransaction, lifecycle.StatusCancelled, lifecycle.ActorSystem, 0, "payment charge failed")
+		})
+		return errors.Join(err, cancelErr)
+	}
+
+	doctorTransaction.PaymentProvider = provider.Name()
+	doctorTransaction.PaymentReference = charge.ProviderReference
+
+	if err := configs.DB.Model(doctorTransaction).Updates(map[string]interface{}{
+		"payment_provider":  doctorTransaction.PaymentProvider,
+		"payment_reference": doctorTransaction.PaymentReference,
+	}).Error; err != nil {
+		return err
+	}
+
+	return settleDoctorTransactionPayment(configs.DB, doctorTransaction, charge.Status, lifecycle.ActorSystem, 0, "payment "+charge.Status+" on charge")
+}
+
+// chargeCheckout opens the provider charge of a new checkout, a refused charge cancels the checkout
+func chargeCheckout(provider payment.PaymentProvider, checkout *schema.Checkout, medicineTransaction schema.MedicineTransaction) error {
+
+	checkout.MedicineTransaction = medicineTransaction
+
+	charge, err := provider.CreateCharge(payment.Charge{
+		Reference:    fmt.Sprintf("checkout-%d", checkout.ID),
+		Amount:       medicineTransaction.TotalPrice,
+		Method:       medicineTransaction.PaymentMethod,
+		Confirmation: checkout.PaymentConfirmation,
+	})
+	if err != nil {
+		return errors.Join(err, settleCheckoutPayment(configs.DB, checkout, payment.StatusCancelled, lifecycle.ActorSystem, 0))
+	}
+
+	checkout.PaymentProvider = provider.Name()
+	checkout.PaymentReference = charge.ProviderReference
+
+	if err := configs.DB.Model(checkout).Updates(map[string]interface{}{
+		"payment_provider":  checkout.PaymentProvider,
+		"payment_reference": checkout.PaymentReference,
+	}).Error; err != nil {
+		return err
+	}
+
+	if charge.Status == payment.StatusPending {
+		return nil
+	}
+
+	return settleCheckoutPayment(configs.DB, checkout, charge.Status, lifecycle.ActorSystem, 0)
+}
+
+// settleDoctorTransactionPayment applies a payment status to a doctor transaction through the lifecycle.
+// Admins, provider callbacks and status queries all settle payments here, inside tx so a callback
+// settles the payment together with its event.
+func settleDoctorTransactionPayment(tx *gorm.DB, doctorTransaction *schema.DoctorTransaction, status string, actorRole string, actorID uint, note string) error {
+
+	if status == doctorTransaction.PaymentStatus {
+		return nil
+	}
+
+	paid := doctorTransaction.PaymentStatus == payment.StatusSuccess
+
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := consultation.Transition(tx, doctorTransaction, lifecycle.PaymentStatusTarget(status), actorRole, actorID, note); err != nil {
+			return err
+		}
+
+		// cancelling a paid consultation owes the user their money back
+		if paid && status == payment.StatusCancelled {
+			refund := request.ConvertToDoctorTransactionRefundRequest(doctorTransaction, 0, note, "", lifecycle.ActorSystem)
+			return openRefund(tx, refund)
+		}
+
+		return nil
+	})
+}
+
+// settleCheckoutPayment applies a payment status to a checkout, the stock reservation of the order is committed once the payment succeeds.
+// The checkout has to be loaded with its medicine transaction details, it is settled inside tx.
+func settleCheckoutPayment(tx *gorm.DB, checkout *schema.Checkout, status string, actorRole string, actorID uint) error {
+
+	from := checkout.PaymentStatus
+	if from == "" {
+		from = payment.StatusPending
+	}
+
+	if status == from {
+		return nil
+	}
+
+	if from == "refunded" {
+		return errPaymentRefunded
+	}
+
+	// medicines on their way to or with the user cannot be put back on the shelf by a payment change
+	if checkout.FulfilmentStatus == fulfilment.StatusShipped || checkout.FulfilmentStatus == fulfilment.StatusDelivered {
+		return errCheckoutShipped
+	}
+
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		// the status guard makes concurrent settlements fail instead of settling the stock twice
+		result := tx.Model(&schema.Checkout{}).
+			Where("id = ? AND payment_status = ?", checkout.ID, from).
+			Update("payment_status", status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errPaymentSettled
+		}
+
+		// the order keeps its reservation while the payment is pending, a cancelled payment gives the medicines back
+		switch status {
+		case payment.StatusSuccess:
+			if err := stock.Commit(tx, checkout.MedicineTransactionID, actorRole, actorID); err != nil {
+				return err
+			}
+		case payment.StatusPending:
+			if err := stock.Renew(tx, checkout.MedicineTransactionID, actorRole, actorID); err != nil {
+				return err
+			}
+		case payment.StatusCancelled:
+			if _, err := stock.Release(tx, checkout.MedicineTransactionID, stock.MovementRelease, actorRole, actorID, "payment cancelled"); err != nil {
+				return err
+			}
+
+			if from == payment.StatusSuccess {
+				refund := request.ConvertToCheckoutRefundRequest(checkout, 0, "payment cancelled after it succeeded", "", lifecycle.ActorSystem)
+				if err := openRefund(tx, refund); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Table("medicine_transactions").
+				Where("id = ?", checkout.MedicineTransactionID).
+				Update("status_transaction", "belum dibayar").Error; err != nil {
+				return err
+			}
+
+			// a packed order is unpacked again, its medicines were just released
+			if err := tx.Model(&schema.Checkout{}).Where("id = ?", checkout.ID).Update("fulfilment_status", nil).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	checkout.PaymentStatus = status
+
+	return nil
+}
+
+// applyProviderStatus settles the doctor transaction or checkout charged under a provider reference inside tx
+func applyProviderStatus(tx *gorm.DB, providerName string, providerReference string, status string) error {
+
+	if !payment.StatusIsValid(status) {
+		return payment.ErrInvalidStatus
+	}
+
+	note := "payment " + status + " reported by " + providerName
+
+	var doctorTransaction schema.DoctorTransaction
+	err := tx.First(&doctorTransaction, "payment_provider = ? AND payment_reference = ?", providerName, providerReference).Error
+	if err == nil {
+		return settleDoctorTransactionPayment(tx, &doctorTransaction, status, lifecycle.ActorSystem, 0, note)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	var checkout schema.Checkout
+	err = tx.Preload("MedicineTransaction.MedicineDetails").First(&checkout, "payment_provider = ? AND payment_reference = ?", providerName, providerReference).Error
+	if err == nil {
+		return settleCheckoutPayment(tx, &checkout, status, lifecycle.ActorSystem, 0)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	var extension schema.RoomchatExtension
+	err = tx.First(&extension, "payment_provider = ? AND payment_reference = ?", providerName, providerReference).Error
+	if err == nil {
+		var roomchat schema.Roomchat
+		_, err = settleRoomchatExtensionPayment(tx, &extension, &roomchat, status)
+		return err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return payment.ErrChargeNotFound
+}
+
+// paymentSettleStatus maps a settlement error to its http status
+func paymentSettleStatus(err error) int {
+	switch {
+	case errors.Is(err, payment.ErrChargeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, payment.ErrInvalidStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, lifecycle.ErrInvalidTransition), errors.Is(err, errPaymentSettled), errors.Is(err, errPaymentRefunded),
+		errors.Is(err, errInsufficientStock), errors.Is(err, errMedicineNotFound), errors.Is(err, errExpiredStock),
+		errors.Is(err, errCheckoutShipped):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// recordPaymentEvent stores a provider event, a redelivered event only bumps its delivery count
+func recordPaymentEvent(providerName string, event payment.CallbackEvent, body []byte) error {
+
+	paymentEvent := schema.PaymentEvent{
+		Provider:          providerName,
+		EventID:           event.EventID,
+		ProviderReference: event.ProviderReference,
+		Status:            event.Status,
+		Payload:           string(body),
+	}
+
+	return configs.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"deliveries": gorm.Expr("deliveries + 1")}),
+	}).Create(&paymentEvent).Error
+}
+
+// processPaymentEvent settles the payment of a stored event once. The event row stays locked while
+// it is processed so a concurrent redelivery waits and then sees it as processed. The payment is settled in
+// the same transaction, an event is only marked processed together with its settlement.
+func processPaymentEvent(providerName string, event payment.CallbackEvent) (bool, error) {
+
+	var processErr error
+	duplicate := false
+
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var paymentEvent schema.PaymentEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&paymentEvent, "provider = ? AND event_id = ?", providerName, event.EventID).Error; err != nil {
+			return err
+		}
+
+		if paymentEvent.ProcessedAt != nil {
+			duplicate = true
+			return nil
+		}
+
+		processErr = applyProviderStatus(tx, providerName, event.ProviderReference, event.Status)
+
+		updates := map[string]interface{}{"error": ""}
+		if processErr != nil {
+			updates["error"] = processErr.Error()
+		} else {
+			updates["processed_at"] = time.Now()
+		}
+
+		return tx.Model(&paymentEvent).Updates(updates).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return duplicate, processErr
+}
+
+// Payment Provider Callback
+func PaymentCallbackController(c echo.Context) error {
+
+	provider, err := payment.ByName(c.Param("provider"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	event, err := provider.HandleCallback(c.Request().Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrNotSupported):
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+		case errors.Is(err, payment.ErrInvalidSignature):
+			return c.JSON(http.StatusUnauthorized, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid payment callback"))
+	}
+
+	if event.EventID == "" {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(errPaymentEventID.Error()))
+	}
+
+	if err := recordPaymentEvent(provider.Name(), event, body); err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"payment event"))
+	}
+
+	duplicate, err := processPaymentEvent(provider.Name(), event)
+	if err != nil {
+		return c.JSON(paymentSettleStatus(err), helper.ErrorResponse(err.Error()))
+	}
+
+	if duplicate {
+		return c.JSON(http.StatusOK, helper.SuccessResponse("payment event already processed", nil))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse("payment callback processed", nil))
+}
+
+// Admin Get All Payment Events
+func GetAllPaymentEventsByAdminController(c echo.Context) error {
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	var paymentEvents []schema.PaymentEvent
+	var total int64
+
+	query := configs.DB.Model(&schema.PaymentEvent{})
+	if provider := c.QueryParam("provider"); provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	if reference := c.QueryParam("reference"); reference != "" {
+		query = query.Where("provider_reference = ?", reference)
+	}
+
+	query.Count(&total)
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&paymentEvents).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"payment events"))
+	}
+
+	if len(paymentEvents) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("payment events "+constanta.ErrNotFound))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToPaymentEventListResponse(paymentEvents)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"payment events", response, pagination))
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/utils/helper/payment"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testPaymentSecret = "callback-test-secret"
+
+// openTestDB points configs.DB at the MySQL database of TEST_DB_DSN, tests that need a database skip without it
+func openTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set")
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect test database: %v", err)
+	}
+
+	configs.DB = db
+	configs.InitialMigration()
+}
+
+// createSandboxDoctorTransaction stores a pending doctor transaction charged by the sandbox provider
+func createSandboxDoctorTransaction(t *testing.T) schema.DoctorTransaction {
+	t.Helper()
+
+	suffix := time.Now().UnixNano()
+
+	user := schema.User{Fullname: "callback test", Email: fmt.Sprintf("callback-%d@test.local", suffix), Password: "-", OTP: "-"}
+	if err := configs.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	doctor := schema.Doctor{
+		ProfilePicture: "-", Fullname: "callback test", Email: fmt.Sprintf("callback-doctor-%d@test.local", suffix), Password: "-",
+		Price: 50000, Specialist: "-", Experience: "-", Alumnus: "-", AboutDoctor: "-", LocationPractice: "-", OTP: "-",
+	}
+	if err := configs.DB.Create(&doctor).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	doctorTransaction := schema.DoctorTransaction{
+		DoctorID:         doctor.ID,
+		UserID:           user.ID,
+		HealthDetails:    "-",
+		Price:            50000,
+		PaymentMethod:    "sandbox",
+		PaymentProvider:  "sandbox",
+		PaymentReference: fmt.Sprintf("sbx-test-%d", suffix),
+		PaymentStatus:    payment.StatusPending,
+	}
+	if err := configs.DB.Create(&doctorTransaction).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return doctorTransaction
+}
+
+// postPaymentCallback sends a sandbox callback through the callback route, signed with secret
+func postPaymentCallback(t *testing.T, secret string, eventID string, reference string, status string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]string{"event_id": eventID, "reference": reference, "status": status})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/payments/sandbox/callback", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(payment.SignatureHeader, payment.Sign(secret, body))
+	rec := httptest.NewRecorder()
+
+	e := echo.New()
+	e.POST("/api/v1/payments/:provider/callback", PaymentCallbackController)
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestPaymentCallback(t *testing.T) {
+	openTestDB(t)
+	payment.Register(payment.NewSandbox(testPaymentSecret))
+
+	doctorTransaction := createSandboxDoctorTransaction(t)
+	eventID := fmt.Sprintf("evt-%d", time.Now().UnixNano())
+
+	// a callback signed with another secret is refused before anything is stored
+	rec := postPaymentCallback(t, "other-secret", eventID, doctorTransaction.PaymentReference, payment.StatusSuccess)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	var events int64
+	configs.DB.Model(&schema.PaymentEvent{}).Where("provider = ? AND event_id = ?", "sandbox", eventID).Count(&events)
+	if events != 0 {
+		t.Fatalf("bad signature: got %d stored events, want none", events)
+	}
+
+	rec = postPaymentCallback(t, testPaymentSecret, eventID, doctorTransaction.PaymentReference, payment.StatusSuccess)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("valid signature: got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var settled schema.DoctorTransaction
+	if err := configs.DB.First(&settled, doctorTransaction.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if settled.PaymentStatus != payment.StatusSuccess || settled.ConsultationStatus != "paid" {
+		t.Errorf("valid signature: got payment %q and consultation %q, want success and paid", settled.PaymentStatus, settled.ConsultationStatus)
+	}
+
+	// the redelivered event is acknowledged without settling the payment again
+	rec = postPaymentCallback(t, testPaymentSecret, eventID, doctorTransaction.PaymentReference, payment.StatusSuccess)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("duplicate event: got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var paymentEvent schema.PaymentEvent
+	if err := configs.DB.First(&paymentEvent, "provider = ? AND event_id = ?", "sandbox", eventID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if paymentEvent.ProcessedAt == nil || paymentEvent.Deliveries != 2 {
+		t.Errorf("duplicate event: got processed at %v after %d deliveries, want processed after 2", paymentEvent.ProcessedAt, paymentEvent.Deliveries)
+	}
+
+	var transitions int64
+	configs.DB.Model(&schema.ConsultationTransition{}).Where("doctor_transaction_id = ?", doctorTransaction.ID).Count(&transitions)
+	if transitions != 1 {
+		t.Errorf("duplicate event: got %d consultation transitions, want 1", transitions)
+	}
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"fmt"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetAllPrescriptionPagination(offset int, limit int, column string, ownerID int) ([]schema.Prescription, int64, error) {
+
+	if offset < 0 || limit < 0 {
+		return nil, 0, nil
+	}
+
+	var prescriptions []schema.Prescription
+	var total int64
+
+	query := configs.DB.Model(&prescriptions).Where(column+" = ?", ownerID)
+
+	query.Count(&total)
+
+	result := query.Preload("PrescriptionDetails.Medicine").
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&prescriptions)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	if offset >= int(total) {
+		return nil, 0, fmt.Errorf("not found")
+	}
+
+	return prescriptions, total, nil
+}
+
+// Doctor Create Prescription from Roomchat
+func CreatePrescriptionController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	if !roomchatOpen(roomchat) {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("roomchat expired"))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "doctor_id = ? AND id = ? AND payment_status = 'success'", doctorID, roomchat.TransactionID).Error; err != nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
+	}
+
+	var existingPrescription schema.Prescription
+	if err := configs.DB.First(&existingPrescription, "doctor_transaction_id = ?", doctorTransaction.ID).Error; err == nil {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse("prescription for this transaction id already exists"))
+	}
+
+	var prescriptionRequest web.PrescriptionRequest
+
+	if err := c.Bind(&prescriptionRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(prescriptionRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	for _, pd := range prescriptionRequest.PrescriptionDetails {
+		var medicine schema.Medicine
+		if err := configs.DB.First(&medicine, pd.MedicineID).Error; err != nil {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse("medicine id "+constanta.ErrNotFound))
+		}
+	}
+
+	prescription := request.ConvertToPrescriptionRequest(prescriptionRequest, doctorTransaction)
+
+	if err := configs.DB.Create(&prescription).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"prescription"))
+	}
+
+	var created schema.Prescription
+	if err := configs.DB.Preload("PrescriptionDetails.Medicine").First(&created, prescription.ID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"created prescription"))
+	}
+
+	response := response.ConvertToPrescriptionResponse(&created)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"prescription", response))
+}
+
+// Doctor Get All Prescriptions
+func GetDoctorPrescriptionsController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	prescriptions, total, err := GetAllPrescriptionPagination(offset, limit, "doctor_id", doctorID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse("prescriptions "+constanta.ErrNotFound))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"prescriptions"))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToPrescriptionListResponse(prescriptions)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"prescriptions", response, pagination))
+}
+
+// Doctor Get Prescription by ID
+func GetDoctorPrescriptionByIDController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	prescriptionID, err := strconv.Atoi(c.Param("prescription_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid prescription id"))
+	}
+
+	var prescription schema.Prescription
+	if err := configs.DB.Preload("PrescriptionDetails.Medicine").Where("doctor_id = ?", doctorID).First(&prescription, prescriptionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("prescription "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToPrescriptionResponse(&prescription)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"prescription", response))
+}
+
+// User Get All Prescriptions
+func GetUserPrescriptionsController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	prescriptions, total, err := GetAllPrescriptionPagination(offset, limit, "user_id", userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse("prescriptions "+constanta.ErrNotFound))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"prescriptions"))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToPrescriptionListResponse(prescriptions)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"prescriptions", response, pagination))
+}
+
+// User Get Prescription by ID
+func GetUserPrescriptionByIDController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	prescriptionID, err := strconv.Atoi(c.Param("prescription_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid prescription id"))
+	}
+
+	var prescription schema.Prescription
+	if err := configs.DB.Preload("PrescriptionDetails.Medicine").Where("user_id = ?", userID).First(&prescription, prescriptionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("prescription "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToPrescriptionResponse(&prescription)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"prescription", response))
+}
+
+// User Get Prescription by Transaction ID
+func GetUserPrescriptionByTransactionController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid transaction id"))
+	}
+
+	var prescription schema.Prescription
+	if err := configs.DB.Preload("PrescriptionDetails.Medicine").Where("user_id = ? AND doctor_transaction_id = ?", userID, transactionID).First(&prescription).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("prescription "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToPrescriptionResponse(&prescription)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"prescription", response))
+}
@@ -0,0 +1,511 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/consultation"
+	"healthcare/utils/helper/fulfilment"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/helper/stock"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errRefundNotAllowed = errors.New("payment cannot be refunded")
+	errRefundPending    = errors.New("a refund is already pending")
+	errRefundAmount     = errors.New("refund amount exceeds the paid amount")
+	errRefundProcessed  = errors.New("refund already processed")
+	errPaymentRefunded  = errors.New("payment already refunded")
+)
+
+// openRefund stores a pending refund and flags its doctor transaction or checkout
+func openRefund(tx *gorm.DB, refund *schema.Refund) error {
+
+	if err := tx.Create(refund).Error; err != nil {
+		return err
+	}
+
+	if refund.DoctorTransactionID != nil {
+		return tx.Model(&schema.DoctorTransaction{}).Where("id = ?", *refund.DoctorTransactionID).Update("refund_status", "pending").Error
+	}
+	if refund.RoomchatExtensionID != nil {
+		return nil
+	}
+	return tx.Model(&schema.Checkout{}).Where("id = ?", *refund.CheckoutID).Update("refund_status", "pending").Error
+}
+
+// checkRefundable validates a new refund against the paid amount and the refunds already made
+func checkRefundable(paymentStatus, refundStatus string, refund *schema.Refund, paidAmount int) error {
+
+	switch {
+	case refundStatus == "pending":
+		return errRefundPending
+	case refundStatus == "completed", paymentStatus == "refunded":
+		return errPaymentRefunded
+	case paymentStatus != payment.StatusSuccess:
+		return errRefundNotAllowed
+	case refund.Amount > paidAmount:
+		return errRefundAmount
+	}
+
+	if _, err := payment.ForMethod(refund.Method); err != nil {
+		return payment.ErrUnknownMethod
+	}
+
+	return nil
+}
+
+// requestDoctorTransactionRefund opens a refund of a paid doctor transaction
+func requestDoctorTransactionRefund(doctorTransaction *schema.DoctorTransaction, refundRequest web.AdminRefundRequest, requestedBy string) (*schema.Refund, error) {
+
+	refund := request.ConvertToDoctorTransactionRefundRequest(doctorTransaction, refundRequest.Amount, refundRequest.Reason, refundRequest.Method, requestedBy)
+
+	// the checks run on the locked row, so two requests cannot both open a refund of the same payment
+	if err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(doctorTransaction, doctorTransaction.ID).Error; err != nil {
+			return err
+		}
+
+		if err := checkRefundable(doctorTransaction.PaymentStatus, doctorTransaction.RefundStatus, refund, doctorTransaction.Price); err != nil {
+			return err
+		}
+
+		if !lifecycle.CanTransition(doctorTransaction.ConsultationStatus, lifecycle.StatusRefunded) {
+			return errRefundNotAllowed
+		}
+
+		return openRefund(tx, refund)
+	}); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// requestCheckoutRefund opens a refund of a paid checkout
+func requestCheckoutRefund(checkout *schema.Checkout, refundRequest web.AdminRefundRequest, requestedBy string) (*schema.Refund, error) {
+
+	refund := request.ConvertToCheckoutRefundRequest(checkout, refundRequest.Amount, refundRequest.Reason, refundRequest.Method, requestedBy)
+
+	// the checks run on the locked row, so two requests cannot both open a refund of the same payment
+	if err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var locked schema.Checkout
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, checkout.ID).Error; err != nil {
+			return err
+		}
+		checkout.PaymentStatus = locked.PaymentStatus
+		checkout.RefundStatus = locked.RefundStatus
+
+		if err := checkRefundable(checkout.PaymentStatus, checkout.RefundStatus, refund, checkout.MedicineTransaction.TotalPrice); err != nil {
+			return err
+		}
+
+		return openRefund(tx, refund)
+	}); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// processRefund completes or rejects a pending refund. A completed refund of the whole payment moves the
+// consultation to refunded, or restocks the medicines of a checkout that still held them. A partial refund
+// only gives part of the money back, the consultation or order stays as it is.
+func processRefund(refund *schema.Refund, status string, adminID uint, note string) error {
+
+	var refunded *schema.DoctorTransaction
+
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(refund, refund.ID).Error; err != nil {
+			return err
+		}
+
+		if refund.Status != "pending" {
+			return errRefundProcessed
+		}
+
+		now := time.Now()
+		refund.Status = status
+		refund.Note = note
+		refund.ProcessedBy = adminID
+		refund.ProcessedAt = &now
+
+		if err := tx.Save(refund).Error; err != nil {
+			return err
+		}
+
+		// the extension was already cancelled when its refund was opened
+		if refund.RoomchatExtensionID != nil {
+			return nil
+		}
+
+		if refund.DoctorTransactionID != nil {
+			var doctorTransaction schema.DoctorTransaction
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&doctorTransaction, *refund.DoctorTransactionID).Error; err != nil {
+				return err
+			}
+
+			if status == "completed" && refund.Amount >= doctorTransaction.Price {
+				if err := consultation.Transition(tx, &doctorTransaction, lifecycle.StatusRefunded, lifecycle.ActorAdmin, adminID, "refund completed"); err != nil {
+					return err
+				}
+				refunded = &doctorTransaction
+			}
+
+			return tx.Model(&doctorTransaction).Update("refund_status", status).Error
+		}
+
+		var checkout schema.Checkout
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("MedicineTransaction.MedicineDetails").First(&checkout, *refund.CheckoutID).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"refund_status": status}
+
+		if status == "completed" && refund.Amount >= checkout.MedicineTransaction.TotalPrice {
+			// a cancelled checkout already gave its medicines back, shipped medicines only come back with a return
+			shipped := checkout.FulfilmentStatus == fulfilment.StatusShipped || checkout.FulfilmentStatus == fulfilment.StatusDelivered
+			if !shipped {
+				if _, err := stock.Release(tx, checkout.MedicineTransactionID, stock.MovementRefund, lifecycle.ActorAdmin, adminID, "refund completed"); err != nil {
+					return err
+				}
+			}
+			updates["payment_status"] = "refunded"
+		}
+
+		return tx.Model(&checkout).Updates(updates).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// a refunded consultation ends its roomchat
+	if refunded != nil {
+		consultation.DisconnectTransaction(configs.DB, refunded, lifecycle.ActorAdmin, adminID)
+	}
+
+	return nil
+}
+
+// refundErrorStatus maps a refund error to its http status
+func refundErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errRefundPending), errors.Is(err, errPaymentRefunded), errors.Is(err, errRefundProcessed),
+		errors.Is(err, lifecycle.ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, errRefundNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, errRefundAmount):
+		return http.StatusBadRequest
+	case errors.Is(err, payment.ErrUnknownMethod):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// refundErrorMessage hides internal errors behind a generic message
+func refundErrorMessage(err error, action string) string {
+	if errors.Is(err, payment.ErrUnknownMethod) {
+		return paymentMethodError()
+	}
+	if refundErrorStatus(err) == http.StatusInternalServerError {
+		return action + "refund"
+	}
+	return err.Error()
+}
+
+// User Request Doctor Transaction Refund
+func RequestDoctorTransactionRefundController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid transaction id"))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "user_id = ? AND id = ?", userID, transactionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction "+constanta.ErrNotFound))
+	}
+
+	// once the consultation started only an admin can decide on a refund
+	if doctorTransaction.ConsultationStatus != lifecycle.StatusPaid {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("refund can only be requested before the consultation starts"))
+	}
+
+	var refundRequest web.RefundRequest
+
+	if err := c.Bind(&refundRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(refundRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	refund, err := requestDoctorTransactionRefund(&doctorTransaction, web.AdminRefundRequest{Reason: refundRequest.Reason, Method: refundRequest.Method}, lifecycle.ActorUser)
+	if err != nil {
+		return c.JSON(refundErrorStatus(err), helper.ErrorResponse(refundErrorMessage(err, constanta.ErrActionCreated)))
+	}
+
+	response := response.ConvertToRefundResponse(refund)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"refund", response))
+}
+
+// User Request Checkout Refund
+func RequestCheckoutRefundController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	checkoutID, err := strconv.Atoi(c.Param("checkout_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid checkout id"))
+	}
+
+	var checkout schema.Checkout
+	result := configs.DB.
+		Joins("JOIN medicine_transactions ON checkouts.medicine_transaction_id = medicine_transactions.id").
+		Preload("MedicineTransaction.MedicineDetails").
+		Where("medicine_transactions.user_id = ? AND checkouts.id = ?", userID, checkoutID).
+		First(&checkout)
+
+	if result.Error != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(constanta.ErrNotFound+" checkout"))
+	}
+
+	var refundRequest web.RefundRequest
+
+	if err := c.Bind(&refundRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(refundRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	refund, err := requestCheckoutRefund(&checkout, web.AdminRefundRequest{Reason: refundRequest.Reason, Method: refundRequest.Method}, lifecycle.ActorUser)
+	if err != nil {
+		return c.JSON(refundErrorStatus(err), helper.ErrorResponse(refundErrorMessage(err, constanta.ErrActionCreated)))
+	}
+
+	response := response.ConvertToRefundResponse(refund)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"refund", response))
+}
+
+// User Get Refunds
+func GetUserRefundsController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	var refunds []schema.Refund
+	var total int64
+
+	query := configs.DB.Model(&schema.Refund{}).Where("user_id = ?", userID)
+
+	query.Count(&total)
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&refunds).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"refunds"))
+	}
+
+	if len(refunds) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("refunds "+constanta.ErrNotFound))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToRefundListResponse(refunds)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"refunds", response, pagination))
+}
+
+// Admin Refund Doctor Transaction
+func CreateDoctorTransactionRefundByAdminController(c echo.Context) error {
+
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid transaction id"))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, transactionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction "+constanta.ErrNotFound))
+	}
+
+	var refundRequest web.AdminRefundRequest
+
+	if err := c.Bind(&refundRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(refundRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	refund, err := requestDoctorTransactionRefund(&doctorTransaction, refundRequest, lifecycle.ActorAdmin)
+	if err != nil {
+		return c.JSON(refundErrorStatus(err), helper.ErrorResponse(refundErrorMessage(err, constanta.ErrActionCreated)))
+	}
+
+	response := response.ConvertToRefundResponse(refund)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"refund", response))
+}
+
+// Admin Refund Checkout
+func CreateCheckoutRefundByAdminController(c echo.Context) error {
+
+	checkoutID, err := strconv.Atoi(c.Param("checkout_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid checkout id"))
+	}
+
+	var checkout schema.Checkout
+	if err := configs.DB.Preload("MedicineTransaction.MedicineDetails").First(&checkout, checkoutID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(constanta.ErrNotFound+" checkout"))
+	}
+
+	var refundRequest web.AdminRefundRequest
+
+	if err := c.Bind(&refundRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(refundRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	refund, err := requestCheckoutRefund(&checkout, refundRequest, lifecycle.ActorAdmin)
+	if err != nil {
+		return c.JSON(refundErrorStatus(err), helper.ErrorResponse(refundErrorMessage(err, constanta.ErrActionCreated)))
+	}
+
+	response := response.ConvertToRefundResponse(refund)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"refund", response))
+}
+
+// Admin Get All Refunds
+func GetAllRefundsByAdminController(c echo.Context) error {
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	status := c.QueryParam("status")
+	if status != "" && status != "pending" && status != "completed" && status != "rejected" {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid input refund status data ('pending', 'completed', 'rejected')"))
+	}
+
+	var refunds []schema.Refund
+	var total int64
+
+	query := configs.DB.Model(&schema.Refund{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	switch c.QueryParam("type") {
+	case "consultation":
+		query = query.Where("doctor_transaction_id IS NOT NULL")
+	case "checkout":
+		query = query.Where("checkout_id IS NOT NULL")
+	case "extension":
+		query = query.Where("roomchat_extension_id IS NOT NULL")
+	}
+
+	query.Count(&total)
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&refunds).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"refunds"))
+	}
+
+	if len(refunds) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("refunds "+constanta.ErrNotFound))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToRefundListResponse(refunds)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"refunds", response, pagination))
+}
+
+// Admin Process Refund
+func ProcessRefundByAdminController(c echo.Context) error {
+
+	adminID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid admin id"))
+	}
+
+	refundID, err := strconv.Atoi(c.Param("refund_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid refund id"))
+	}
+
+	var refund schema.Refund
+	if err := configs.DB.First(&refund, refundID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("refund "+constanta.ErrNotFound))
+	}
+
+	var processRequest web.ProcessRefundRequest
+
+	if err := c.Bind(&processRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(processRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	if err := processRefund(&refund, processRequest.Status, uint(adminID), processRequest.Note); err != nil {
+		return c.JSON(refundErrorStatus(err), helper.ErrorResponse(refundErrorMessage(err, constanta.ErrActionUpdated)))
+	}
+
+	response := response.ConvertToRefundResponse(&refund)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"refund", response))
+}
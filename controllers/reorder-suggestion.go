@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"healthcare/configs"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/stock"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxReorderDays = 365
+
+// reorderDaysParam reads an optional day count query param
+func reorderDaysParam(c echo.Context, name string, fallback int) (int, bool) {
+	param := c.QueryParam(name)
+	if param == "" {
+		return fallback, true
+	}
+
+	days, err := strconv.Atoi(param)
+	if err != nil || days < 1 || days > maxReorderDays {
+		return 0, false
+	}
+	return days, true
+}
+
+// Admin Get Reorder Suggestions
+func GetReorderSuggestionsByAdminController(c echo.Context) error {
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	salesWindow, ok := reorderDaysParam(c, "days", stock.DefaultSalesWindow)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("days must be between 1 and "+strconv.Itoa(maxReorderDays)))
+	}
+
+	coverDays, ok := reorderDaysParam(c, "cover_days", stock.DefaultCoverDays)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("cover_days must be between 1 and "+strconv.Itoa(maxReorderDays)))
+	}
+
+	if offset < 0 || limit < 0 {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit and offset must not be negative"))
+	}
+
+	suggestions, err := stock.Suggest(configs.DB, salesWindow, coverDays)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"reorder suggestions"))
+	}
+
+	if offset >= len(suggestions) {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("reorder suggestions "+constanta.ErrNotFound))
+	}
+
+	total := int64(len(suggestions))
+	end := offset + limit
+	if end > len(suggestions) {
+		end = len(suggestions)
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToReorderSuggestionListResponse(suggestions[offset:end])
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"reorder suggestions", response, pagination))
+}
@@ -0,0 +1,586 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/consultation"
+	"healthcare/utils/helper/hub"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errRoomchatClosed   = errors.New("roomchat already closed")
+	errExtensionLimit   = errors.New("roomchat can be extended by at most 60 minutes")
+	errExtensionOverlap = errors.New("extension overlaps another booked consultation")
+)
+
+// applyRoomchatExtension pushes the expiration time of the locked roomchat and marks the extension as applied
+func applyRoomchatExtension(tx *gorm.DB, extension *schema.RoomchatExtension, roomchat *schema.Roomchat) error {
+
+	if !roomchatOpen(*roomchat) {
+		return errRoomchatClosed
+	}
+
+	expirationTime := roomchat.ExpirationTime.Add(time.Duration(extension.Minutes) * time.Minute)
+	roomchat.ExpirationTime = &expirationTime
+	roomchat.ExtendedMinutes += extension.Minutes
+
+	if err := tx.Model(roomchat).Updates(map[string]interface{}{
+		"expiration_time":  roomchat.ExpirationTime,
+		"extended_minutes": roomchat.ExtendedMinutes,
+	}).Error; err != nil {
+		return err
+	}
+
+	// the status guard keeps an extension from being applied twice by concurrent settlements
+	now := time.Now()
+	result := tx.Model(&schema.RoomchatExtension{}).
+		Where("id = ? AND payment_status = ?", extension.ID, "pending").
+		Updates(map[string]interface{}{"payment_status": "success", "applied_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errPaymentSettled
+	}
+
+	extension.AppliedAt = &now
+	extension.PaymentStatus = "success"
+
+	return nil
+}
+
+// settleRoomchatExtensionPayment applies a payment status to a paid extension inside tx, with its roomchat and the
+// extension locked. A successful payment extends the roomchat while it is open, an extension paid after its roomchat
+// closed is refunded instead and reported as lapsed.
+func settleRoomchatExtensionPayment(tx *gorm.DB, extension *schema.RoomchatExtension, roomchat *schema.Roomchat, status string) (bool, error) {
+
+	lapsed := false
+
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		// the roomchat is locked before the extension, in the order closing a roomchat locks them
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(roomchat, extension.RoomchatID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(extension, extension.ID).Error; err != nil {
+			return err
+		}
+
+		if status == extension.PaymentStatus {
+			return nil
+		}
+
+		var doctorTransaction schema.DoctorTransaction
+		if err := tx.First(&doctorTransaction, roomchat.TransactionID).Error; err != nil {
+			return err
+		}
+
+		// a provider collected the payment of an extension that lapsed while its charge was pending
+		if extension.PaymentStatus == "cancelled" && status == payment.StatusSuccess && extension.AppliedAt == nil {
+			lapsed = true
+			return consultation.RefundExtension(tx, extension, doctorTransaction.UserID)
+		}
+
+		if extension.PaymentStatus != "pending" {
+			return errPaymentSettled
+		}
+
+		switch status {
+		case payment.StatusSuccess:
+			if roomchatOpen(*roomchat) {
+				return applyRoomchatExtension(tx, extension, roomchat)
+			}
+			// the patient paid but the roomchat closed first, the payment goes back through a refund
+			lapsed = true
+			return consultation.LapseExtension(tx, extension, doctorTransaction.UserID, true)
+		case payment.StatusCancelled:
+			extension.PaymentStatus = payment.StatusCancelled
+			return tx.Model(extension).Update("payment_status", extension.PaymentStatus).Error
+		}
+
+		return nil
+	})
+
+	return lapsed, err
+}
+
+// chargeRoomchatExtension opens the provider charge of a paid extension, a refused charge leaves the extension unpaid
+// so the patient can pay it again
+func chargeRoomchatExtension(provider payment.PaymentProvider, extension *schema.RoomchatExtension, roomchat *schema.Roomchat) error {
+
+	charge, err := provider.CreateCharge(payment.Charge{
+		Reference:    fmt.Sprintf("roomchat-extension-%d", extension.ID),
+		Amount:       extension.Price,
+		Method:       extension.PaymentMethod,
+		Confirmation: extension.PaymentConfirmation,
+	})
+	if err != nil {
+		return errors.Join(err, configs.DB.Model(extension).Updates(map[string]interface{}{
+			"payment_method":       nil,
+			"payment_provider":     nil,
+			"payment_confirmation": nil,
+		}).Error)
+	}
+
+	extension.PaymentReference = charge.ProviderReference
+
+	if err := configs.DB.Model(extension).Update("payment_reference", extension.PaymentReference).Error; err != nil {
+		return err
+	}
+
+	if charge.Status == payment.StatusPending {
+		return nil
+	}
+
+	_, err = settleRoomchatExtensionPayment(configs.DB, extension, roomchat, charge.Status)
+	return err
+}
+
+// consultationMinutes is the booked length of a consultation, the length of its slot when it was scheduled
+func consultationMinutes(doctorTransaction schema.DoctorTransaction) int {
+	if doctorTransaction.ScheduleStart != nil && doctorTransaction.ScheduleEnd != nil {
+		if minutes := int(doctorTransaction.ScheduleEnd.Sub(*doctorTransaction.ScheduleStart) / time.Minute); minutes > 0 {
+			return minutes
+		}
+	}
+	return int(roomchatDuration / time.Minute)
+}
+
+// Doctor Extend Roomchat
+func CreateRoomchatExtensionController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "doctor_id = ? AND id = ?", doctorID, roomchat.TransactionID).Error; err != nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
+	}
+
+	if !roomchatOpen(roomchat) || roomchat.ExpirationTime == nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("roomchat expired"))
+	}
+
+	var extensionRequest web.RoomchatExtensionRequest
+
+	if err := c.Bind(&extensionRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(extensionRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	// paid extensions cost the same per minute as the consultation itself at its list price, the voucher of the
+	// booking only discounted the booked consultation and its discount is added back
+	price := 0
+	if extensionRequest.Paid {
+		price = (doctorTransaction.Price + doctorTransaction.Discount) * extensionRequest.Minutes / consultationMinutes(doctorTransaction)
+	}
+
+	extension := request.ConvertToRoomchatExtensionRequest(extensionRequest, roomchat.ID, uint(doctorID), price)
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&roomchat, roomchat.ID).Error; err != nil {
+			return err
+		}
+
+		if !roomchatOpen(roomchat) {
+			return errRoomchatClosed
+		}
+
+		var pendingMinutes int64
+		if err := tx.Model(&schema.RoomchatExtension{}).
+			Where("roomchat_id = ? AND payment_status = ?", roomchat.ID, "pending").
+			Select("COALESCE(SUM(minutes), 0)").
+			Scan(&pendingMinutes).Error; err != nil {
+			return err
+		}
+
+		totalMinutes := roomchat.ExtendedMinutes + int(pendingMinutes) + extension.Minutes
+		if totalMinutes > maxRoomchatExtension {
+			return errExtensionLimit
+		}
+
+		extendedUntil := roomchat.ExpirationTime.Add(time.Duration(int(pendingMinutes)+extension.Minutes) * time.Minute)
+
+		var overlapping int64
+		if err := tx.Model(&schema.DoctorSlot{}).
+			Where("doctor_id = ? AND doctor_transaction_id <> ?", doctorID, doctorTransaction.ID).
+			Where("start_time < ? AND end_time > ?", extendedUntil, roomchat.ExpirationTime).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+
+		if overlapping > 0 {
+			return errExtensionOverlap
+		}
+
+		if err := tx.Create(&extension).Error; err != nil {
+			return err
+		}
+
+		if extension.Price > 0 {
+			return nil
+		}
+
+		return applyRoomchatExtension(tx, extension, &roomchat)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errRoomchatClosed):
+			return c.JSON(http.StatusForbidden, helper.ErrorResponse("roomchat expired"))
+		case errors.Is(err, errExtensionLimit), errors.Is(err, errExtensionOverlap):
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"roomchat extension"))
+	}
+
+	eventType := "extended"
+	if extension.AppliedAt == nil {
+		eventType = "extension_offered"
+	}
+	hub.Default.Publish(roomchat.ID, response.ConvertToRoomchatExtensionEventResponse(eventType, extension, &roomchat))
+
+	response := response.ConvertToRoomchatExtensionResponse(extension)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"roomchat extension", response))
+}
+
+// Doctor Close Roomchat Early
+func CloseRoomchatController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "doctor_id = ? AND id = ?", doctorID, roomchat.TransactionID).Error; err != nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
+	}
+
+	if !roomchatOpen(roomchat) {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("roomchat expired"))
+	}
+
+	var closeRequest web.RoomchatCloseRequest
+
+	if err := c.Bind(&closeRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(closeRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		closed, err := consultation.CloseRoomchat(tx, &doctorTransaction, roomchat.ID, lifecycle.ActorDoctor, uint(doctorID), closeRequest.Summary)
+		if err != nil {
+			return err
+		}
+		if !closed {
+			return errRoomchatClosed
+		}
+
+		now := time.Now()
+		roomchat.Status = false
+		roomchat.ExpirationTime = &now
+		roomchat.ClosingSummary = closeRequest.Summary
+
+		return tx.Model(&roomchat).Updates(map[string]interface{}{
+			"expiration_time": roomchat.ExpirationTime,
+			"closing_summary": roomchat.ClosingSummary,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errRoomchatClosed) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"roomchat"))
+	}
+
+	consultation.Disconnect(roomchat.ID, lifecycle.ActorDoctor, uint(doctorID))
+
+	var user schema.User
+	if err := configs.DB.First(&user, doctorTransaction.UserID).Error; err == nil {
+		helper.SendNotificationEmail(user.Email, user.Fullname, "closed", "", "", "", false, int(roomchat.ID))
+	}
+
+	response := response.ConvertToCreateRoomchatResponse(&roomchat)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse("roomchat closed successful", response))
+}
+
+// User Get Roomchat Extensions
+func GetUserRoomchatExtensionsController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "user_id = ? AND id = ?", userID, roomchat.TransactionID).Error; err != nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
+	}
+
+	var extensions []schema.RoomchatExtension
+	if err := configs.DB.Where("roomchat_id = ?", roomchat.ID).Order("created_at DESC").Find(&extensions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"roomchat extensions"))
+	}
+
+	if len(extensions) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat extensions "+constanta.ErrNotFound))
+	}
+
+	response := response.ConvertToRoomchatExtensionListResponse(extensions)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"roomchat extensions", response))
+}
+
+// User Pay Roomchat Extension
+func PayRoomchatExtensionController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	extensionID, err := strconv.Atoi(c.Param("extension_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid extension id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	var doctorTransaction schema.DoctorTransaction
+	if err := configs.DB.First(&doctorTransaction, "user_id = ? AND id = ?", userID, roomchat.TransactionID).Error; err != nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
+	}
+
+	var extension schema.RoomchatExtension
+	if err := configs.DB.First(&extension, "id = ? AND roomchat_id = ?", extensionID, roomchat.ID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat extension "+constanta.ErrNotFound))
+	}
+
+	if extension.PaymentStatus != "pending" {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse("roomchat extension payment already settled"))
+	}
+
+	if !roomchatOpen(roomchat) {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("roomchat expired"))
+	}
+
+	var paymentRequest web.RoomchatExtensionPaymentRequest
+
+	if err := c.Bind(&paymentRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	provider, err := payment.ForMethod(paymentRequest.PaymentMethod)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(paymentMethodError()))
+	}
+
+	paymentConfirmation, err := uploadPaymentConfirmation(c, provider.RequiresConfirmation())
+	if err != nil {
+		return c.JSON(paymentConfirmationStatus(err), helper.ErrorResponse(err.Error()))
+	}
+
+	paymentRequest.PaymentConfirmation = paymentConfirmation
+
+	if err := helper.ValidateStruct(paymentRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	// the payment is claimed under the row lock, so a second payment of the same extension is refused
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&extension, extension.ID).Error; err != nil {
+			return err
+		}
+		if extension.PaymentStatus != "pending" || extension.PaymentReference != "" {
+			return errPaymentSettled
+		}
+
+		extension.PaymentMethod = paymentRequest.PaymentMethod
+		extension.PaymentProvider = provider.Name()
+		extension.PaymentConfirmation = paymentRequest.PaymentConfirmation
+
+		return tx.Model(&extension).Updates(map[string]interface{}{
+			"payment_method":       extension.PaymentMethod,
+			"payment_provider":     extension.PaymentProvider,
+			"payment_confirmation": extension.PaymentConfirmation,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errPaymentSettled) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse("roomchat extension payment already submitted"))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"roomchat extension"))
+	}
+
+	if err := chargeRoomchatExtension(provider, &extension, &roomchat); err != nil {
+		return c.JSON(http.StatusBadGateway, helper.ErrorResponse("failed to create payment charge"))
+	}
+
+	if extension.AppliedAt != nil {
+		hub.Default.Publish(roomchat.ID, response.ConvertToRoomchatExtensionEventResponse("extended", &extension, &roomchat))
+	}
+
+	response := response.ConvertToRoomchatExtensionResponse(&extension)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"roomchat extension", response))
+}
+
+// Admin Get All Roomchat Extensions
+func GetAllRoomchatExtensionsByAdminController(c echo.Context) error {
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	paymentStatus := c.QueryParam("payment_status")
+
+	if !helper.PaymentStatusIsValid(paymentStatus) {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid input payment status data ('pending', 'success', 'cancelled')"))
+	}
+
+	var extensions []schema.RoomchatExtension
+	var total int64
+
+	// free extensions are applied on creation, only paid ones need an admin
+	query := configs.DB.Model(&schema.RoomchatExtension{}).Where("price > 0")
+	if paymentStatus != "" {
+		query = query.Where("payment_status = ?", paymentStatus)
+	}
+
+	query.Count(&total)
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&extensions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"roomchat extensions"))
+	}
+
+	if len(extensions) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat extensions "+constanta.ErrNotFound))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToRoomchatExtensionListResponse(extensions)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"roomchat extensions", response, pagination))
+}
+
+// Admin Update Roomchat Extension Payment Status
+func UpdateRoomchatExtensionPaymentByAdminController(c echo.Context) error {
+
+	extensionID, err := strconv.Atoi(c.Param("extension_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid extension id"))
+	}
+
+	var extension schema.RoomchatExtension
+	if err := configs.DB.First(&extension, extensionID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat extension "+constanta.ErrNotFound))
+	}
+
+	var updateRequest web.UpdatePaymentRequest
+	if err := c.Bind(&updateRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(updateRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	if updateRequest.PaymentStatus != payment.StatusSuccess && updateRequest.PaymentStatus != payment.StatusCancelled {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("roomchat extension payment is already pending"))
+	}
+
+	if extension.PaymentStatus != "pending" {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse("roomchat extension payment already settled"))
+	}
+
+	var roomchat schema.Roomchat
+	lapsed, err := settleRoomchatExtensionPayment(configs.DB, &extension, &roomchat, updateRequest.PaymentStatus)
+	if err != nil {
+		if errors.Is(err, errPaymentSettled) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse("roomchat extension payment already settled"))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"payment status"))
+	}
+
+	if lapsed {
+		return c.JSON(http.StatusConflict, helper.ErrorResponse(errRoomchatClosed.Error()+", a refund of the extension payment was opened"))
+	}
+
+	if extension.AppliedAt != nil {
+		hub.Default.Publish(roomchat.ID, response.ConvertToRoomchatExtensionEventResponse("extended", &extension, &roomchat))
+	}
+
+	response := response.ConvertToRoomchatExtensionResponse(&extension)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"payment status", response))
+}
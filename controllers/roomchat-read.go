@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/hub"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errMessageNotFound = errors.New("message " + constanta.ErrNotFound)
+
+// unreadSenderFilter selects the messages sent by the other side of the roomchat
+func unreadSenderFilter(role string) string {
+	if role == "doctor" {
+		return "messages.doctor_id = 0"
+	}
+	return "messages.doctor_id <> 0"
+}
+
+// GetUnreadCounts counts, per roomchat, the messages from the other side newer than the reader's marker
+func GetUnreadCounts(roomchatIDs []uint, role string, readerID uint) (map[uint]int64, error) {
+
+	unreadCounts := make(map[uint]int64, len(roomchatIDs))
+	if len(roomchatIDs) == 0 {
+		return unreadCounts, nil
+	}
+
+	var rows []struct {
+		RoomchatID uint
+		Total      int64
+	}
+
+	err := configs.DB.Table("messages").
+		Select("messages.roomchat_id, COUNT(*) AS total").
+		Joins("LEFT JOIN roomchat_reads ON roomchat_reads.roomchat_id = messages.roomchat_id AND roomchat_reads.role = ? AND roomchat_reads.reader_id = ?", role, readerID).
+		Where("messages.roomchat_id IN ?", roomchatIDs).
+		Where("messages.id > COALESCE(roomchat_reads.last_read_message_id, 0)").
+		Where(unreadSenderFilter(role)).
+		Group("messages.roomchat_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		unreadCounts[row.RoomchatID] = row.Total
+	}
+
+	return unreadCounts, nil
+}
+
+// markRoomchatRead moves the reader's marker forward, it never moves back to an older message
+func markRoomchatRead(roomchatID uint, role string, readerID uint, messageID uint) (*schema.RoomchatRead, error) {
+
+	var message schema.Message
+	if err := configs.DB.First(&message, "id = ? AND roomchat_id = ?", messageID, roomchatID).Error; err != nil {
+		return nil, errMessageNotFound
+	}
+
+	// the upsert keeps concurrent marks of the same reader from colliding on the unique index
+	read := schema.RoomchatRead{
+		RoomchatID:        roomchatID,
+		Role:              role,
+		ReaderID:          readerID,
+		LastReadMessageID: messageID,
+	}
+	if err := configs.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_message_id": gorm.Expr("GREATEST(last_read_message_id, VALUES(last_read_message_id))"),
+			"updated_at":           gorm.Expr("VALUES(updated_at)"),
+		}),
+	}).Create(&read).Error; err != nil {
+		return nil, err
+	}
+
+	if err := configs.DB.Where("roomchat_id = ? AND role = ? AND reader_id = ?", roomchatID, role, readerID).First(&read).Error; err != nil {
+		return nil, err
+	}
+
+	return &read, nil
+}
+
+// User Get All Roomchats
+func GetAllUserRoomchatController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	var roomchats []schema.Roomchat
+	var total int64
+
+	query := configs.DB.Model(&schema.Roomchat{}).
+		Joins("JOIN doctor_transactions ON doctor_transactions.id = roomchats.transaction_id").
+		Where("doctor_transactions.user_id = ? AND doctor_transactions.payment_status = ?", userID, "success")
+
+	query.Count(&total)
+
+	if err := query.Order("roomchats.created_at DESC").Limit(limit).Offset(offset).Find(&roomchats).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve roomchat data"))
+	}
+
+	if len(roomchats) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	roomchatIDs := make([]uint, 0, len(roomchats))
+	for _, roomchat := range roomchats {
+		roomchatIDs = append(roomchatIDs, roomchat.ID)
+	}
+
+	lastMessages, err := GetLastMessages(roomchatIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve last message data"))
+	}
+
+	unreadCounts, err := GetUnreadCounts(roomchatIDs, "user", uint(userID))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve unread message data"))
+	}
+
+	var responses []web.RoomchatListResponse
+	for _, roomchat := range roomchats {
+
+		var doctorTransaction schema.DoctorTransaction
+		if err := configs.DB.First(&doctorTransaction, "id = ?", roomchat.TransactionID).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor transaction data"))
+		}
+
+		var doctor schema.Doctor
+		if err := configs.DB.First(&doctor, "id = ?", doctorTransaction.DoctorID).Error; err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor data"))
+		}
+
+		responses = append(responses, response.ConvertToGetAllUserRoomchats(doctor, roomchat, lastMessages[roomchat.ID], unreadCounts[roomchat.ID]))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse("roomchat data successfully retrieved", responses, pagination))
+}
+
+// User Mark Roomchat as Read
+func UserReadRoomchatController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	var doctortransaction schema.DoctorTransaction
+	if err := configs.DB.Where("user_id = ? AND id = ?", userID, roomchat.TransactionID).First(&doctortransaction).Error; err != nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
+	}
+
+	return readRoomchat(c, roomchat, "user", uint(userID))
+}
+
+// Doctor Mark Roomchat as Read
+func DoctorReadRoomchatController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("roomchat "+constanta.ErrNotFound))
+	}
+
+	var doctortransaction schema.DoctorTransaction
+	if err := configs.DB.Where("doctor_id = ? AND id = ?", doctorID, roomchat.TransactionID).First(&doctortransaction).Error; err != nil {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("permission denied"))
+	}
+
+	return readRoomchat(c, roomchat, "doctor", uint(doctorID))
+}
+
+func readRoomchat(c echo.Context, roomchat schema.Roomchat, role string, readerID uint) error {
+
+	var readRequest web.RoomchatReadRequest
+
+	if err := c.Bind(&readRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(readRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	read, err := markRoomchatRead(roomchat.ID, role, readerID, readRequest.MessageID)
+	if err != nil {
+		if errors.Is(err, errMessageNotFound) {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"read marker"))
+	}
+
+	unreadCounts, err := GetUnreadCounts([]uint{roomchat.ID}, role, readerID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve unread message data"))
+	}
+
+	event := response.ConvertToRoomchatEventResponse("read", roomchat.ID, role, readerID)
+	event.MessageID = read.LastReadMessageID
+	hub.Default.Publish(roomchat.ID, event)
+
+	response := response.ConvertToRoomchatReadResponse(read, unreadCounts[roomchat.ID])
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"read marker", response))
+}
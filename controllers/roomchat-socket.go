@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/hub"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// User Connect to Roomchat WebSocket
+func UserRoomchatSocketController(c echo.Context) error {
+
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid user id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve roomchat data"))
+	}
+
+	var doctortransaction schema.DoctorTransaction
+	if err := configs.DB.Where("user_id = ? AND id = ?", userID, roomchat.TransactionID).First(&doctortransaction).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor transaction data"))
+	}
+
+	return serveRoomchatSocket(c, roomchat, "user", uint(userID))
+}
+
+// Doctor Connect to Roomchat WebSocket
+func DoctorRoomchatSocketController(c echo.Context) error {
+
+	doctorID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("invalid doctor id"))
+	}
+
+	roomchatID, err := strconv.Atoi(c.Param("roomchat_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid roomchat id"))
+	}
+
+	var roomchat schema.Roomchat
+	if err := configs.DB.First(&roomchat, "id = ?", roomchatID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve roomchat data"))
+	}
+
+	var doctortransaction schema.DoctorTransaction
+	if err := configs.DB.Where("doctor_id = ? AND id = ?", doctorID, roomchat.TransactionID).First(&doctortransaction).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor transaction data"))
+	}
+
+	return serveRoomchatSocket(c, roomchat, "doctor", uint(doctorID))
+}
+
+// serveRoomchatSocket upgrades the request and relays hub events of the roomchat until either side disconnects
+func serveRoomchatSocket(c echo.Context, roomchat schema.Roomchat, role string, id uint) error {
+
+	if !c.IsWebSocket() {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("websocket upgrade required"))
+	}
+
+	if !roomchatOpen(roomchat) {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("roomchat expired"))
+	}
+
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			client := hub.Default.Subscribe(roomchat.ID, role, id)
+			hub.Default.Publish(roomchat.ID, response.ConvertToRoomchatEventResponse("online", roomchat.ID, role, id))
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for {
+					var eventRequest web.RoomchatEventRequest
+					if err := websocket.JSON.Receive(ws, &eventRequest); err != nil {
+						return
+					}
+
+					if err := helper.ValidateStruct(eventRequest); err != nil {
+						continue
+					}
+
+					hub.Default.Publish(roomchat.ID, response.ConvertToRoomchatEventResponse(eventRequest.Type, roomchat.ID, role, id))
+				}
+			}()
+
+		relay:
+			for {
+				select {
+				case event, ok := <-client.Send:
+					if !ok {
+						break relay
+					}
+					if err := websocket.JSON.Send(ws, event); err != nil {
+						break relay
+					}
+				case <-done:
+					break relay
+				}
+			}
+
+			hub.Default.Unsubscribe(client)
+			hub.Default.Publish(roomchat.ID, response.ConvertToRoomchatEventResponse("offline", roomchat.ID, role, id))
+		},
+	}
+
+	server.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
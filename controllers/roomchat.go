@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"healthcare/configs"
 	"healthcare/models/schema"
 	"healthcare/models/web"
 	"healthcare/utils/helper"
 	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/consultation"
+	"healthcare/utils/helper/lifecycle"
 	"healthcare/utils/request"
 	"healthcare/utils/response"
 	"net/http"
@@ -16,6 +19,7 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func GetAllRoomchatPagination(doctorID int, offset int, limit int, fullname string, queryInput []schema.DoctorTransaction) ([]schema.DoctorTransaction, int64, error) {
@@ -33,11 +37,11 @@ func GetAllRoomchatPagination(doctorID int, offset int, limit int, fullname stri
 		query = query.Joins("JOIN users ON doctor_transactions.user_id = users.id").Where("users.fullname LIKE ?", "%"+fullname+"%")
 	}
 
-	query.Preload("Roomchat.Message").Where("doctor_id = ? AND payment_status = ?", doctorID, "success").Find(&queryAll).Count(&total)
+	query.Preload("Roomchat").Where("doctor_id = ? AND payment_status = ?", doctorID, "success").Find(&queryAll).Count(&total)
 
 	query = query.Limit(limit).Offset(offset)
 
-	result := query.Preload("Roomchat.Message").Where("doctor_id = ? AND payment_status = ?", doctorID, "success").Find(&queryAll)
+	result := query.Preload("Roomchat").Where("doctor_id = ? AND payment_status = ?", doctorID, "success").Find(&queryAll)
 
 	if result.Error != nil {
 		return nil, 0, result.Error
@@ -50,6 +54,24 @@ func GetAllRoomchatPagination(doctorID int, offset int, limit int, fullname stri
 	return queryAll, total, nil
 }
 
+// roomchat details only embed the latest messages, older ones are served by the message history endpoint
+const roomchatMessageLimit = 50
+
+// roomchatDuration is the length of an unscheduled consultation, doctors can extend it up to maxRoomchatExtension
+const (
+	roomchatDuration     = 30 * time.Minute
+	maxRoomchatExtension = 60 // minutes
+)
+
+// roomchatOpen reports whether messages can still be exchanged in the roomchat
+func roomchatOpen(roomchat schema.Roomchat) bool {
+	return roomchat.Status && (roomchat.ExpirationTime == nil || time.Now().Before(*roomchat.ExpirationTime))
+}
+
+func latestMessages(db *gorm.DB) *gorm.DB {
+	return db.Order("id DESC").Limit(roomchatMessageLimit)
+}
+
 // User Create Roomchat and Send Notification to Doctor
 func CreateRoomchatController(c echo.Context) error {
 
@@ -75,14 +97,29 @@ func CreateRoomchatController(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve doctor transaction data"))
 	}
 
+	if doctorTransaction.ScheduleStart != nil && time.Now().Before(*doctorTransaction.ScheduleStart) {
+		return c.JSON(http.StatusForbidden, helper.ErrorResponse("consultation schedule has not started yet"))
+	}
+
 	roomchat := request.CreateRoomchatRequest(uint(transactionID))
 
-	timeoutDuration := 30 * time.Minute
-	expirationTime := time.Now().Add(timeoutDuration)
+	expirationTime := time.Now().Add(roomchatDuration)
+	if doctorTransaction.ScheduleEnd != nil {
+		expirationTime = *doctorTransaction.ScheduleEnd
+	}
 	roomchat.ExpirationTime = &expirationTime
 	roomchat.Status = time.Now().Before(*roomchat.ExpirationTime) 
 
-	if err := configs.DB.Create(&roomchat).Error; err != nil {
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := consultation.Transition(tx, &doctorTransaction, lifecycle.StatusInConsultation, lifecycle.ActorUser, uint(userID), "roomchat opened"); err != nil {
+			return err
+		}
+		return tx.Create(&roomchat).Error
+	})
+	if err != nil {
+		if errors.Is(err, lifecycle.ErrInvalidTransition) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to create roomchat"))
 	}
 
@@ -131,16 +168,10 @@ func GetUserRoomchatController(c echo.Context) error {
 	}
 
 	var roomchat schema.Roomchat
-	if err := configs.DB.Where("id = ?", roomchatID).Preload("Message").First(&roomchat).Error; err != nil {
+	if err := configs.DB.Where("id = ?", roomchatID).Preload("Message", latestMessages).First(&roomchat).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve message data"))
 	}
-
-	if roomchat.ExpirationTime != nil && time.Now().After(*roomchat.ExpirationTime) {
-		roomchat.Status = false
-		if err := configs.DB.Save(&roomchat).Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to update roomchat status"))
-		}
-	}
+	reverseMessages(roomchat.Message)
 
 	var doctor schema.Doctor
 	if err := configs.DB.Where("id = ?", doctortransaction.DoctorID).First(&doctor).Error; err != nil {
@@ -176,16 +207,10 @@ func GetDoctorRoomchatController(c echo.Context) error {
 	}
 
 	var roomchat schema.Roomchat
-	if err := configs.DB.Where("id = ?", roomchatID).Preload("Message").First(&roomchat).Error; err != nil {
+	if err := configs.DB.Where("id = ?", roomchatID).Preload("Message", latestMessages).First(&roomchat).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve message data"))
 	}
-
-	if roomchat.ExpirationTime != nil && time.Now().After(*roomchat.ExpirationTime) {
-		roomchat.Status = false
-		if err := configs.DB.Save(&roomchat).Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to update roomchat status"))
-		}
-	}
+	reverseMessages(roomchat.Message)
 
 	var user schema.User
 	if err := configs.DB.Where("id = ?", doctortransaction.UserID).First(&user).Error; err != nil {
@@ -226,30 +251,28 @@ func GetAllDoctorRoomchatController(c echo.Context) error {
 			return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction data not found"))
 		}
 
-		sort.Slice(existingDoctorTransactions, func(i, j int) bool {
+		roomchatIDs := make([]uint, 0, len(existingDoctorTransactions))
+		for _, doctorTransaction := range existingDoctorTransactions {
+			roomchatIDs = append(roomchatIDs, doctorTransaction.Roomchat.ID)
+		}
 
-			getLastMessageTimestamp := func(transaction schema.DoctorTransaction) time.Time {
-				if len(transaction.Roomchat.Message) > 0 {
-					return transaction.Roomchat.Message[len(transaction.Roomchat.Message)-1].CreatedAt
-				}
+		lastMessages, err := GetLastMessages(roomchatIDs)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve last message data"))
+		}
 
-				return time.Time{}
-			}
+		unreadCounts, err := GetUnreadCounts(roomchatIDs, "doctor", uint(doctorID))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve unread message data"))
+		}
 
-			return getLastMessageTimestamp(existingDoctorTransactions[i]).After(getLastMessageTimestamp(existingDoctorTransactions[j]))
+		sort.Slice(existingDoctorTransactions, func(i, j int) bool {
+			return lastMessages[existingDoctorTransactions[i].Roomchat.ID].CreatedAt.After(lastMessages[existingDoctorTransactions[j].Roomchat.ID].CreatedAt)
 		})
 
 		var responses []web.RoomchatListResponse
 		for _, doctorTransaction := range existingDoctorTransactions {
 
-			roomchat := doctorTransaction.Roomchat
-			if roomchat.ExpirationTime != nil && time.Now().After(*roomchat.ExpirationTime) && roomchat.Status {
-				roomchat.Status = false
-				if err := configs.DB.Save(&roomchat).Error; err != nil {
-					return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to update roomchat status"))
-				}
-			}
-
 			if doctorTransaction.DoctorID != uint(doctorID) {
 				continue
 			}
@@ -267,12 +290,9 @@ func GetAllDoctorRoomchatController(c echo.Context) error {
 				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve user data"))
 			}
 
-			var lastMessage schema.Message
-			if len(doctorTransaction.Roomchat.Message) > 0 {
-				lastMessage = doctorTransaction.Roomchat.Message[len(doctorTransaction.Roomchat.Message)-1]
-			}
+			lastMessage := lastMessages[doctorTransaction.Roomchat.ID]
 
-			response := response.ConvertToGetAllRoomchats(user, doctorTransaction.Roomchat, lastMessage)
+			response := response.ConvertToGetAllRoomchats(user, doctorTransaction.Roomchat, lastMessage, unreadCounts[doctorTransaction.Roomchat.ID])
 			responses = append(responses, response)
 		}
 
@@ -289,30 +309,28 @@ func GetAllDoctorRoomchatController(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, helper.ErrorResponse("doctor transaction data not found"))
 	}
 
-	sort.Slice(existingDoctorTransactions, func(i, j int) bool {
+	roomchatIDs := make([]uint, 0, len(existingDoctorTransactions))
+	for _, doctorTransaction := range existingDoctorTransactions {
+		roomchatIDs = append(roomchatIDs, doctorTransaction.Roomchat.ID)
+	}
 
-		getLastMessageTimestamp := func(transaction schema.DoctorTransaction) time.Time {
-			if len(transaction.Roomchat.Message) > 0 {
-				return transaction.Roomchat.Message[len(transaction.Roomchat.Message)-1].CreatedAt
-			}
+	lastMessages, err := GetLastMessages(roomchatIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve last message data"))
+	}
 
-			return time.Time{}
-		}
+	unreadCounts, err := GetUnreadCounts(roomchatIDs, "doctor", uint(doctorID))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve unread message data"))
+	}
 
-		return getLastMessageTimestamp(existingDoctorTransactions[i]).After(getLastMessageTimestamp(existingDoctorTransactions[j]))
+	sort.Slice(existingDoctorTransactions, func(i, j int) bool {
+		return lastMessages[existingDoctorTransactions[i].Roomchat.ID].CreatedAt.After(lastMessages[existingDoctorTransactions[j].Roomchat.ID].CreatedAt)
 	})
 
 	var responses []web.RoomchatListResponse
 	for _, doctorTransaction := range existingDoctorTransactions {
 
-		roomchat := doctorTransaction.Roomchat
-		if roomchat.ExpirationTime != nil && time.Now().After(*roomchat.ExpirationTime) && roomchat.Status {
-			roomchat.Status = false
-			if err := configs.DB.Save(&roomchat).Error; err != nil {
-				return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to update roomchat status"))
-			}
-		}
-
 		if doctorTransaction.DoctorID != uint(doctorID) {
 			continue
 		}
@@ -330,12 +348,9 @@ func GetAllDoctorRoomchatController(c echo.Context) error {
 			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse("failed to retrieve user data"))
 		}
 
-		var lastMessage schema.Message
-		if len(doctorTransaction.Roomchat.Message) > 0 {
-			lastMessage = doctorTransaction.Roomchat.Message[len(doctorTransaction.Roomchat.Message)-1]
-		}
+		lastMessage := lastMessages[doctorTransaction.Roomchat.ID]
 
-		response := response.ConvertToGetAllRoomchats(user, doctorTransaction.Roomchat, lastMessage)
+		response := response.ConvertToGetAllRoomchats(user, doctorTransaction.Roomchat, lastMessage, unreadCounts[doctorTransaction.Roomchat.ID])
 		responses = append(responses, response)
 	}
 
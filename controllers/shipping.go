@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/shipping"
+	"healthcare/utils/response"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	errUnknownShippingService = shipping.ErrUnknownService
+	errNoShippingRates        = shipping.ErrNoRates
+)
+
+// parcelWeight adds up the weight in grams of the ordered medicines
+func parcelWeight(tx *gorm.DB, medicineDetails []schema.MedicineDetails) (int, error) {
+
+	medicineIDs := make([]uint, len(medicineDetails))
+	for i, md := range medicineDetails {
+		medicineIDs[i] = md.MedicineID
+	}
+
+	var medicines []schema.Medicine
+	if err := tx.Where("id IN ?", medicineIDs).Find(&medicines).Error; err != nil {
+		return 0, err
+	}
+
+	weights := make(map[uint]int, len(medicines))
+	for _, medicine := range medicines {
+		weights[medicine.ID] = medicine.Weight
+	}
+
+	weight := 0
+	for _, md := range medicineDetails {
+		itemWeight, ok := weights[md.MedicineID]
+		if !ok {
+			return 0, errMedicineNotFound
+		}
+		if itemWeight <= 0 {
+			itemWeight = shipping.DefaultItemWeight
+		}
+		weight += md.Quantity * itemWeight
+	}
+
+	return weight, nil
+}
+
+// applyShipping prices the delivery of an order to its address with the chosen service, the cheapest one when
+// none was chosen. It runs after the delivery address is copied and before the order is reserved.
+func applyShipping(tx *gorm.DB, medicineTransaction *schema.MedicineTransaction) error {
+
+	weight, err := parcelWeight(tx, medicineTransaction.MedicineDetails)
+	if err != nil {
+		return err
+	}
+
+	option, err := shipping.Choose(shipping.Parcel{
+		Province:   medicineTransaction.Province,
+		City:       medicineTransaction.City,
+		PostalCode: medicineTransaction.PostalCode,
+		Weight:     weight,
+	}, medicineTransaction.ShippingService)
+	if err != nil {
+		return err
+	}
+
+	medicineTransaction.ShippingProvider = option.Provider
+	medicineTransaction.ShippingService = option.Service
+	medicineTransaction.ShippingWeight = weight
+	medicineTransaction.ShippingFee = option.Fee
+
+	return nil
+}
+
+// User Get Delivery Options and Fees, for the given medicines or else the cart
+func GetShippingRatesController(c echo.Context) error {
+	userID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid user id"))
+	}
+
+	var shippingRateRequest web.ShippingRateRequest
+
+	if err := c.Bind(&shippingRateRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(shippingRateRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	medicineDetails := make([]schema.MedicineDetails, len(shippingRateRequest.MedicineDetails))
+	for i, md := range shippingRateRequest.MedicineDetails {
+		medicineDetails[i] = schema.MedicineDetails{MedicineID: md.MedicineID, Quantity: md.Quantity}
+	}
+
+	if len(medicineDetails) == 0 {
+		items, err := cartItems(configs.DB, uint(userID))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"cart"))
+		}
+		if len(items) == 0 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("medicine details are required when the cart is empty"))
+		}
+		for _, item := range items {
+			medicineDetails = append(medicineDetails, schema.MedicineDetails{MedicineID: item.MedicineID, Quantity: item.Quantity})
+		}
+	}
+
+	// a chosen address comes first, then a typed in region, then the default address
+	destination := schema.MedicineTransaction{
+		UserID:     uint(userID),
+		Province:   shippingRateRequest.Province,
+		City:       shippingRateRequest.City,
+		PostalCode: shippingRateRequest.PostalCode,
+	}
+	typedIn := destination.Province != "" || destination.City != "" || destination.PostalCode != ""
+
+	if shippingRateRequest.AddressID != 0 || !typedIn {
+		err := applyDeliveryAddress(configs.DB, &destination, shippingRateRequest.AddressID)
+		if errors.Is(err, errAddressNotFound) {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+		}
+		if err != nil && !errors.Is(err, errAddressRequired) {
+			return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"address"))
+		}
+	}
+
+	weight, err := parcelWeight(configs.DB, medicineDetails)
+	if err != nil {
+		if errors.Is(err, errMedicineNotFound) {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"shipping rates"))
+	}
+
+	parcel := shipping.Parcel{
+		Province:   destination.Province,
+		City:       destination.City,
+		PostalCode: destination.PostalCode,
+		Weight:     weight,
+	}
+
+	options, err := shipping.Quote(parcel)
+	if err != nil {
+		if errors.Is(err, errNoShippingRates) {
+			return c.JSON(http.StatusUnprocessableEntity, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"shipping rates"))
+	}
+
+	response := response.ConvertToShippingRatesResponse(parcel, options)
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"shipping rates", response))
+}
@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/stock"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// parseDateRange reads the optional from/to query params, to includes its whole day
+func parseDateRange(c echo.Context) (*time.Time, *time.Time, error) {
+
+	var from, to *time.Time
+
+	if fromParam := c.QueryParam("from"); fromParam != "" {
+		parsed, err := time.ParseInLocation(dateLayout, fromParam, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("invalid from date, use YYYY-MM-DD")
+		}
+		from = &parsed
+	}
+
+	if toParam := c.QueryParam("to"); toParam != "" {
+		parsed, err := time.ParseInLocation(dateLayout, toParam, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("invalid to date, use YYYY-MM-DD")
+		}
+		end := parsed.AddDate(0, 0, 1)
+		to = &end
+	}
+
+	if from != nil && to != nil && !to.After(*from) {
+		return nil, nil, errors.New("to date must not be before from date")
+	}
+
+	return from, to, nil
+}
+
+// Admin Create Stock Movement
+func CreateStockMovementByAdminController(c echo.Context) error {
+
+	adminID, ok := c.Get("userID").(int)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid admin id"))
+	}
+
+	medicineID, err := strconv.Atoi(c.Param("medicine_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var movementRequest web.StockMovementRequest
+
+	if err := c.Bind(&movementRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(movementRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	// restocks and expiry write-offs are given as positive amounts, adjustments carry their sign
+	quantity := movementRequest.Quantity
+	switch movementRequest.Type {
+	case stock.MovementRestock, stock.MovementExpiry:
+		if quantity < 0 {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("quantity of a "+movementRequest.Type+" must be positive"))
+		}
+		if movementRequest.Type == stock.MovementExpiry {
+			quantity = -quantity
+		}
+	}
+
+	var movement *schema.StockMovement
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		// batches are received and written off through their own endpoints, a movement here would leave them behind
+		tracked, err := stock.Tracked(tx, uint(medicineID))
+		if err != nil {
+			return err
+		}
+		if tracked {
+			return stock.ErrBatchTracked
+		}
+
+		movement, err = stock.Adjust(tx, uint(medicineID), quantity, movementRequest.Type, lifecycle.ActorAdmin, uint(adminID), movementRequest.Reason)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, stock.ErrMedicineNotFound) {
+			return c.JSON(http.StatusNotFound, helper.ErrorResponse(err.Error()))
+		}
+		if errors.Is(err, stock.ErrInsufficientStock) || errors.Is(err, stock.ErrBatchTracked) {
+			return c.JSON(http.StatusConflict, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"stock movement"))
+	}
+
+	response := response.ConvertToStockMovementResponse(movement)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"stock movement", response))
+}
+
+// Admin Get Stock Movements of a Medicine
+func GetStockMovementsByAdminController(c echo.Context) error {
+
+	medicineID, err := strconv.Atoi(c.Param("medicine_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	if err := configs.DB.Unscoped().First(&schema.Medicine{}, medicineID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("medicine "+constanta.ErrNotFound))
+	}
+
+	var movements []schema.StockMovement
+	var total int64
+
+	query := configs.DB.Model(&schema.StockMovement{}).Where("medicine_id = ?", medicineID)
+	if movementType := c.QueryParam("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at < ?", *to)
+	}
+
+	query.Count(&total)
+
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&movements).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"stock movements"))
+	}
+
+	if len(movements) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("stock movements "+constanta.ErrNotFound))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToStockMovementListResponse(movements)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"stock movements", response, pagination))
+}
+
+// Admin Get Stock Reconciliation
+func GetStockReconciliationByAdminController(c echo.Context) error {
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	// the ledger of a medicine always adds up to its stock, any difference is a change that bypassed it
+	ledger := configs.DB.Table("stock_movements").
+		Select("medicine_id, SUM(quantity) AS ledger_stock, COUNT(*) AS movements, MAX(created_at) AS last_movement_at").
+		Group("medicine_id")
+
+	query := configs.DB.Table("medicines").
+		Joins("LEFT JOIN (?) AS ledger ON ledger.medicine_id = medicines.id", ledger).
+		Where("medicines.deleted_at IS NULL")
+
+	if c.QueryParam("mismatch") == "true" {
+		query = query.Where("medicines.stock <> COALESCE(ledger.ledger_stock, 0)")
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var reconciliations []web.StockReconciliationResponse
+	if err := query.
+		Select("medicines.id AS medicine_id, medicines.code, medicines.name, medicines.stock, " +
+			"COALESCE(ledger.ledger_stock, 0) AS ledger_stock, COALESCE(ledger.movements, 0) AS movements, ledger.last_movement_at").
+		Order("medicines.id ASC").Limit(limit).Offset(offset).
+		Scan(&reconciliations).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"stock reconciliation"))
+	}
+
+	if len(reconciliations) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("stock reconciliation "+constanta.ErrNotFound))
+	}
+
+	medicineIDs := make([]uint, len(reconciliations))
+	for i, reconciliation := range reconciliations {
+		medicineIDs[i] = reconciliation.MedicineID
+	}
+
+	// the totals per movement type cover the requested period only
+	var totals []struct {
+		MedicineID uint
+		Type       string
+		Total      int
+	}
+	totalsQuery := configs.DB.Table("stock_movements").
+		Select("medicine_id, type, SUM(quantity) AS total").
+		Where("medicine_id IN ?", medicineIDs).
+		Group("medicine_id, type")
+	if from != nil {
+		totalsQuery = totalsQuery.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		totalsQuery = totalsQuery.Where("created_at < ?", *to)
+	}
+	if err := totalsQuery.Scan(&totals).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"stock reconciliation"))
+	}
+
+	positions := make(map[uint]int, len(reconciliations))
+	for i := range reconciliations {
+		reconciliations[i].Difference = reconciliations[i].Stock - reconciliations[i].LedgerStock
+		positions[reconciliations[i].MedicineID] = i
+	}
+
+	for _, typeTotal := range totals {
+		reconciliation := &reconciliations[positions[typeTotal.MedicineID]]
+		switch typeTotal.Type {
+		case stock.MovementRestock:
+			reconciliation.Restocked = typeTotal.Total
+		case stock.MovementSale:
+			reconciliation.Sold = -typeTotal.Total
+		case stock.MovementRelease:
+			reconciliation.Released = typeTotal.Total
+		case stock.MovementRefund:
+			reconciliation.Refunded = typeTotal.Total
+		case stock.MovementAdjustment:
+			reconciliation.Adjusted = typeTotal.Total
+		case stock.MovementExpiry:
+			reconciliation.Expired = -typeTotal.Total
+		}
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"stock reconciliation", reconciliations, pagination))
+}
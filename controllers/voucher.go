@@ -0,0 +1,448 @@
+package controllers
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/voucher"
+	"healthcare/utils/request"
+	"healthcare/utils/response"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	errVoucherNotFound   = voucher.ErrNotFound
+	errVoucherCodeExists = errors.New("voucher code already exists")
+	errVoucherTarget     = errors.New("voucher targets a doctor or medicine category that does not exist")
+)
+
+// voucherErrorStatus maps a voucher error to its http status
+func voucherErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errVoucherNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errVoucherTarget):
+		return http.StatusBadRequest
+	case errors.Is(err, errVoucherCodeExists), errors.Is(err, voucher.ErrUsageLimit), errors.Is(err, voucher.ErrUserLimit):
+		return http.StatusConflict
+	case errors.Is(err, voucher.ErrInactive), errors.Is(err, voucher.ErrNotStarted), errors.Is(err, voucher.ErrExpired),
+		errors.Is(err, voucher.ErrWrongScope), errors.Is(err, voucher.ErrNotApplicable), errors.Is(err, voucher.ErrMinSpend):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// applyDoctorVoucher takes the discount of the voucher code of a new doctor transaction off its price. The price
+// is what the patient is charged from then on, the list price stays known as the price plus the discount.
+func applyDoctorVoucher(tx *gorm.DB, doctorTransaction *schema.DoctorTransaction, doctor schema.Doctor) error {
+
+	if strings.TrimSpace(doctorTransaction.VoucherCode) == "" {
+		doctorTransaction.VoucherCode = ""
+		return nil
+	}
+
+	promo, discount, err := voucher.Claim(tx, doctorTransaction.VoucherCode, voucher.ScopeConsultation, doctorTransaction.UserID, []voucher.Line{
+		{DoctorID: doctor.ID, Specialist: doctor.Specialist, Amount: doctorTransaction.Price},
+	})
+	if err != nil {
+		return err
+	}
+
+	doctorTransaction.VoucherID = &promo.ID
+	doctorTransaction.VoucherCode = promo.Code
+	doctorTransaction.Discount = discount
+	doctorTransaction.Price -= discount
+
+	return nil
+}
+
+// applyMedicineVoucher works out the discount of the voucher code of a new order on the medicines it applies to.
+// It runs before the order is reserved, which takes the discount off the total, shipping is never discounted.
+func applyMedicineVoucher(tx *gorm.DB, medicineTransaction *schema.MedicineTransaction) error {
+
+	if strings.TrimSpace(medicineTransaction.VoucherCode) == "" {
+		medicineTransaction.VoucherCode = ""
+		return nil
+	}
+
+	medicineIDs := make([]uint, len(medicineTransaction.MedicineDetails))
+	for i, md := range medicineTransaction.MedicineDetails {
+		medicineIDs[i] = md.MedicineID
+	}
+
+	var medicines []schema.Medicine
+	if err := tx.Where("id IN ?", medicineIDs).Find(&medicines).Error; err != nil {
+		return err
+	}
+
+	byID := make(map[uint]schema.Medicine, len(medicines))
+	for _, medicine := range medicines {
+		byID[medicine.ID] = medicine
+	}
+
+	lines := make([]voucher.Line, len(medicineTransaction.MedicineDetails))
+	for i, md := range medicineTransaction.MedicineDetails {
+		medicine, ok := byID[md.MedicineID]
+		if !ok {
+			return errMedicineNotFound
+		}
+		lines[i] = voucher.Line{CategoryID: medicine.CategoryID, Amount: md.Quantity * medicine.Price}
+	}
+
+	promo, discount, err := voucher.Claim(tx, medicineTransaction.VoucherCode, voucher.ScopeMedicine, medicineTransaction.UserID, lines)
+	if err != nil {
+		return err
+	}
+
+	medicineTransaction.VoucherID = &promo.ID
+	medicineTransaction.VoucherCode = promo.Code
+	medicineTransaction.Discount = discount
+
+	return nil
+}
+
+// voucherUsage counts the redemptions of vouchers that were not given back
+func voucherUsage(voucherIDs []uint) (map[uint]int, error) {
+
+	var rows []struct {
+		VoucherID uint
+		Used      int
+	}
+
+	err := configs.DB.Model(&schema.VoucherRedemption{}).
+		Select("voucher_id, COUNT(*) AS used").
+		Where("voucher_id IN ? AND released_at IS NULL", voucherIDs).
+		Group("voucher_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		used[row.VoucherID] = row.Used
+	}
+	return used, nil
+}
+
+// voucherFromRequest checks a voucher request and converts it, the error is the message to answer with
+func voucherFromRequest(voucherRequest web.VoucherRequest) (*schema.Voucher, error) {
+
+	startsAt, err := time.Parse(time.RFC3339, voucherRequest.StartsAt)
+	if err != nil {
+		return nil, errors.New("invalid starts at, use RFC3339 format")
+	}
+
+	endsAt, err := time.Parse(time.RFC3339, voucherRequest.EndsAt)
+	if err != nil {
+		return nil, errors.New("invalid ends at, use RFC3339 format")
+	}
+
+	if !endsAt.After(startsAt) {
+		return nil, errors.New("ends at must be after starts at")
+	}
+
+	if voucherRequest.DiscountType == voucher.TypePercentage && voucherRequest.Value > 100 {
+		return nil, errors.New("percentage value cannot be more than 100")
+	}
+
+	if voucherRequest.Scope == voucher.ScopeConsultation && len(voucherRequest.CategoryIDs) > 0 {
+		return nil, errors.New("consultation vouchers can only target doctors and specialists")
+	}
+
+	if voucherRequest.Scope == voucher.ScopeMedicine && (len(voucherRequest.DoctorIDs) > 0 || len(voucherRequest.Specialists) > 0) {
+		return nil, errors.New("medicine vouchers can only target medicine categories")
+	}
+
+	return request.ConvertToVoucherRequest(voucherRequest, startsAt, endsAt), nil
+}
+
+// checkVoucher makes sure the code of a voucher is free, deleted vouchers included, and that its targets exist
+func checkVoucher(tx *gorm.DB, promo *schema.Voucher, exceptID uint) error {
+
+	var count int64
+	if err := tx.Unscoped().Model(&schema.Voucher{}).Where("code = ? AND id <> ?", promo.Code, exceptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errVoucherCodeExists
+	}
+
+	var doctorIDs, categoryIDs []uint
+	for _, target := range promo.Targets {
+		if target.DoctorID != nil {
+			doctorIDs = append(doctorIDs, *target.DoctorID)
+		}
+		if target.MedicineCategoryID != nil {
+			categoryIDs = append(categoryIDs, *target.MedicineCategoryID)
+		}
+	}
+
+	if len(doctorIDs) > 0 {
+		if err := tx.Model(&schema.Doctor{}).Where("id IN ?", doctorIDs).Distinct("id").Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(uniqueIDs(doctorIDs)) {
+			return errVoucherTarget
+		}
+	}
+
+	if len(categoryIDs) > 0 {
+		if err := tx.Model(&schema.MedicineCategory{}).Where("id IN ?", categoryIDs).Distinct("id").Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(uniqueIDs(categoryIDs)) {
+			return errVoucherTarget
+		}
+	}
+
+	return nil
+}
+
+// uniqueIDs drops the repeated ids of a list
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	var unique []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// Admin Get All Vouchers, filtered by scope and active flag
+func GetVouchersByAdminController(c echo.Context) error {
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("limit"+constanta.ErrQueryParamRequired))
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("offset"+constanta.ErrQueryParamRequired))
+	}
+
+	scope := c.QueryParam("scope")
+	if scope != "" && scope != voucher.ScopeConsultation && scope != voucher.ScopeMedicine {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid input voucher scope data ('consultation', 'medicine')"))
+	}
+
+	query := configs.DB.Model(&schema.Voucher{})
+	if scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+
+	if active := c.QueryParam("is_active"); active != "" {
+		isActive, err := strconv.ParseBool(active)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, helper.ErrorResponse("invalid is_active query param"))
+		}
+		query = query.Where("is_active = ?", isActive)
+	}
+
+	if keyword := c.QueryParam("keyword"); keyword != "" {
+		query = query.Where("code LIKE ?", "%"+strings.ToUpper(keyword)+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var vouchers []schema.Voucher
+	if err := query.Preload("Targets").Order("created_at DESC").Limit(limit).Offset(offset).Find(&vouchers).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"vouchers"))
+	}
+
+	if len(vouchers) == 0 {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse("vouchers "+constanta.ErrNotFound))
+	}
+
+	voucherIDs := make([]uint, len(vouchers))
+	for i := range vouchers {
+		voucherIDs[i] = vouchers[i].ID
+	}
+
+	used, err := voucherUsage(voucherIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"vouchers"))
+	}
+
+	pagination := helper.Pagination(offset, limit, total)
+
+	response := response.ConvertToVoucherListResponse(vouchers, used)
+
+	return c.JSON(http.StatusOK, helper.PaginationResponse(constanta.SuccessActionGet+"vouchers", response, pagination))
+}
+
+// Admin Get Voucher by ID
+func GetVoucherByAdminController(c echo.Context) error {
+
+	voucherID, err := strconv.Atoi(c.Param("voucher_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var promo schema.Voucher
+	if err := configs.DB.Preload("Targets").First(&promo, voucherID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(errVoucherNotFound.Error()))
+	}
+
+	used, err := voucherUsage([]uint{promo.ID})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"voucher"))
+	}
+
+	response := response.ConvertToVoucherResponse(&promo, used[promo.ID])
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionGet+"voucher", response))
+}
+
+// Admin Create Voucher
+func CreateVoucherByAdminController(c echo.Context) error {
+
+	var voucherRequest web.VoucherRequest
+
+	if err := c.Bind(&voucherRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(voucherRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	promo, err := voucherFromRequest(voucherRequest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkVoucher(tx, promo, 0); err != nil {
+			return err
+		}
+		return tx.Create(promo).Error
+	})
+	if err != nil {
+		if status := voucherErrorStatus(err); status != http.StatusInternalServerError {
+			return c.JSON(status, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionCreated+"voucher"))
+	}
+
+	response := response.ConvertToVoucherResponse(promo, 0)
+
+	return c.JSON(http.StatusCreated, helper.SuccessResponse(constanta.SuccessActionCreated+"voucher", response))
+}
+
+// Admin Update Voucher, its targets are replaced and transactions that used it keep their discount
+func UpdateVoucherByAdminController(c echo.Context) error {
+
+	voucherID, err := strconv.Atoi(c.Param("voucher_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var voucherRequest web.VoucherRequest
+
+	if err := c.Bind(&voucherRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidBody))
+	}
+
+	if err := helper.ValidateStruct(voucherRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	updated, err := voucherFromRequest(voucherRequest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(err.Error()))
+	}
+
+	var promo schema.Voucher
+	err = configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&promo, voucherID).Error; err != nil {
+			return errVoucherNotFound
+		}
+
+		if err := checkVoucher(tx, updated, promo.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&promo).Updates(map[string]interface{}{
+			"code":           updated.Code,
+			"description":    updated.Description,
+			"scope":          updated.Scope,
+			"discount_type":  updated.DiscountType,
+			"value":          updated.Value,
+			"min_spend":      updated.MinSpend,
+			"max_discount":   updated.MaxDiscount,
+			"starts_at":      updated.StartsAt,
+			"ends_at":        updated.EndsAt,
+			"usage_limit":    updated.UsageLimit,
+			"per_user_limit": updated.PerUserLimit,
+			"is_active":      updated.IsActive,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("voucher_id = ?", promo.ID).Delete(&schema.VoucherTarget{}).Error; err != nil {
+			return err
+		}
+
+		for i := range updated.Targets {
+			updated.Targets[i].VoucherID = promo.ID
+		}
+		if len(updated.Targets) > 0 {
+			if err := tx.Create(&updated.Targets).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Preload("Targets").First(&promo, promo.ID).Error
+	})
+	if err != nil {
+		if status := voucherErrorStatus(err); status != http.StatusInternalServerError {
+			return c.JSON(status, helper.ErrorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionUpdated+"voucher"))
+	}
+
+	used, err := voucherUsage([]uint{promo.ID})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionGet+"updated voucher"))
+	}
+
+	response := response.ConvertToVoucherResponse(&promo, used[promo.ID])
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionUpdated+"voucher", response))
+}
+
+// Admin Delete Voucher, its code stays taken so past transactions keep pointing at it
+func DeleteVoucherByAdminController(c echo.Context) error {
+
+	voucherID, err := strconv.Atoi(c.Param("voucher_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, helper.ErrorResponse(constanta.ErrInvalidIDParam))
+	}
+
+	var promo schema.Voucher
+	if err := configs.DB.First(&promo, voucherID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, helper.ErrorResponse(errVoucherNotFound.Error()))
+	}
+
+	if err := configs.DB.Delete(&promo).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, helper.ErrorResponse(constanta.ErrActionDeleted+"voucher"))
+	}
+
+	return c.JSON(http.StatusOK, helper.SuccessResponse(constanta.SuccessActionDeleted+"voucher", nil))
+}
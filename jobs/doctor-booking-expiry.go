@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"errors"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/utils/helper/consultation"
+	"healthcare/utils/helper/lifecycle"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExpireUnpaidBookings cancels the scheduled consultations that were not paid before their payment
+// deadline or their slot started, which gives their slots back to other patients
+func ExpireUnpaidBookings() error {
+
+	now := time.Now()
+
+	var doctorTransactions []schema.DoctorTransaction
+	if err := configs.DB.
+		Where("consultation_status = ? AND schedule_start IS NOT NULL", lifecycle.StatusBooked).
+		Where("created_at <= ? OR schedule_start <= ?", now.Add(-lifecycle.BookingPaymentTTL), now).
+		Find(&doctorTransactions).Error; err != nil {
+		return err
+	}
+
+	for _, doctorTransaction := range doctorTransactions {
+		if err := expireBooking(doctorTransaction.ID); err != nil {
+			log.Printf("failed to expire booking of doctor transaction %d: %v\n", doctorTransaction.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// expireBooking cancels one unpaid booking under the row lock, a payment settled meanwhile keeps it
+func expireBooking(doctorTransactionID uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var locked schema.DoctorTransaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, doctorTransactionID).Error; err != nil {
+			return err
+		}
+
+		if locked.ConsultationStatus != lifecycle.StatusBooked {
+			return nil
+		}
+
+		err := consultation.Transition(tx, &locked, lifecycle.StatusCancelled, lifecycle.ActorSystem, 0, "payment deadline passed")
+		if errors.Is(err, lifecycle.ErrInvalidTransition) {
+			return nil
+		}
+		return err
+	})
+}
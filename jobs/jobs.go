@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// Start runs the background jobs of the service until the process exits
+func Start() {
+	every(time.Minute, "roomchat expiry", ExpireRoomchats)
+	every(time.Minute, "stock reservation expiry", ReleaseExpiredReservations)
+	every(time.Minute, "pending checkout expiry", ExpirePendingCheckouts)
+	every(time.Minute, "unpaid booking expiry", ExpireUnpaidBookings)
+	every(time.Hour, "medicine batch expiry", WriteOffExpiredBatches)
+	every(time.Hour, "low stock alert", AlertLowStock)
+}
+
+// every runs job right away and then on each interval, logging failures instead of stopping
+func every(interval time.Duration, name string, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := job(); err != nil {
+				log.Printf("%s job failed: %v\n", name, err)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/stock"
+	"html"
+	"log"
+	"strings"
+	"time"
+)
+
+// AlertLowStock emails the admins once about every medicine that fell below its reorder threshold,
+// a medicine is alerted again only after its stock recovered
+func AlertLowStock() error {
+
+	if err := configs.DB.Model(&schema.Medicine{}).
+		Where("low_stock_alerted_at IS NOT NULL AND stock >= reorder_threshold").
+		Update("low_stock_alerted_at", nil).Error; err != nil {
+		return err
+	}
+
+	var medicines []schema.Medicine
+	if err := configs.DB.Where("reorder_threshold > 0 AND stock < reorder_threshold AND low_stock_alerted_at IS NULL").
+		Order("stock ASC").Find(&medicines).Error; err != nil {
+		return err
+	}
+
+	if len(medicines) == 0 {
+		return nil
+	}
+
+	suggested := map[uint]int{}
+	if suggestions, err := stock.Suggest(configs.DB, stock.DefaultSalesWindow, stock.DefaultCoverDays); err == nil {
+		for _, suggestion := range suggestions {
+			suggested[suggestion.Medicine.ID] = suggestion.SuggestedQuantity
+		}
+	}
+
+	var admins []schema.Admin
+	if err := configs.DB.Find(&admins).Error; err != nil {
+		return err
+	}
+
+	body, htmlBody := lowStockEmail(medicines, suggested)
+
+	sent := 0
+	for _, admin := range admins {
+		if err := helper.SendEmail(admin.Email, "Healthify Notification - Stok Obat Menipis", body, htmlBody); err != nil {
+			log.Printf("failed to send low stock alert to %s: %v\n", admin.Email, err)
+			continue
+		}
+		sent++
+	}
+
+	// nobody was told, the next run tries again
+	if sent == 0 {
+		return errors.New("low stock alert was not sent to any admin")
+	}
+
+	medicineIDs := make([]uint, len(medicines))
+	for i, medicine := range medicines {
+		medicineIDs[i] = medicine.ID
+	}
+
+	return configs.DB.Model(&schema.Medicine{}).Where("id IN ?", medicineIDs).Update("low_stock_alerted_at", time.Now()).Error
+}
+
+func lowStockEmail(medicines []schema.Medicine, suggested map[uint]int) (string, string) {
+
+	var text, rows strings.Builder
+
+	text.WriteString("Hallo Admin,\n\nStok obat berikut berada di bawah batas pemesanan ulang:\n\n")
+
+	for _, medicine := range medicines {
+		fmt.Fprintf(&text, "- %s (%s): stok %d, batas %d, saran pesan %d\n",
+			medicine.Name, medicine.Code, medicine.Stock, medicine.ReorderThreshold, suggested[medicine.ID])
+
+		fmt.Fprintf(&rows, "<tr><td>%s</td><td>%s</td><td>%d</td><td>%d</td><td>%d</td></tr>",
+			html.EscapeString(medicine.Code), html.EscapeString(medicine.Name), medicine.Stock, medicine.ReorderThreshold, suggested[medicine.ID])
+	}
+
+	text.WriteString("\nSegera lakukan pemesanan ulang agar obat tetap tersedia bagi pasien.")
+
+	htmlBody := "<p>Hallo Admin,</p><p>Stok obat berikut berada di bawah batas pemesanan ulang:</p>" +
+		"<table border=\"1\" cellpadding=\"6\" cellspacing=\"0\">" +
+		"<tr><th>Kode</th><th>Nama</th><th>Stok</th><th>Batas</th><th>Saran Pesan</th></tr>" +
+		rows.String() + "</table>" +
+		"<p>Segera lakukan pemesanan ulang agar obat tetap tersedia bagi pasien.</p>"
+
+	return text.String(), htmlBody
+}
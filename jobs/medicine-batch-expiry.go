@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/stock"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// WriteOffExpiredBatches takes the remaining quantity of every expired batch off the stock, so expired
+// units can no longer be ordered
+func WriteOffExpiredBatches() error {
+
+	var batches []schema.MedicineBatch
+	if err := configs.DB.Where("quantity > 0 AND expiry_date < ?", stock.Today()).Find(&batches).Error; err != nil {
+		return err
+	}
+
+	for _, batch := range batches {
+		err := configs.DB.Transaction(func(tx *gorm.DB) error {
+			_, err := stock.WriteOff(tx, batch.ID, lifecycle.ActorSystem, 0)
+			return err
+		})
+		if err != nil {
+			log.Printf("failed to write off medicine batch %d: %v\n", batch.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/utils/helper"
+	"healthcare/utils/helper/consultation"
+	"healthcare/utils/helper/lifecycle"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExpireRoomchats closes every open roomchat past its expiration time and notifies both participants
+func ExpireRoomchats() error {
+
+	var roomchats []schema.Roomchat
+	if err := configs.DB.Where("status = ? AND expiration_time IS NOT NULL AND expiration_time <= ?", true, time.Now()).Find(&roomchats).Error; err != nil {
+		return err
+	}
+
+	for _, roomchat := range roomchats {
+		var doctorTransaction schema.DoctorTransaction
+		if err := configs.DB.First(&doctorTransaction, roomchat.TransactionID).Error; err != nil {
+			log.Printf("failed to expire roomchat %d: %v\n", roomchat.ID, err)
+			continue
+		}
+
+		closed, err := expireRoomchat(roomchat, &doctorTransaction)
+		if err != nil {
+			log.Printf("failed to expire roomchat %d: %v\n", roomchat.ID, err)
+			continue
+		}
+		if closed {
+			notifyRoomchatExpired(roomchat, doctorTransaction)
+		}
+	}
+
+	return nil
+}
+
+// expireRoomchat closes one roomchat, it reports false when another worker or request closed it first
+func expireRoomchat(roomchat schema.Roomchat, doctorTransaction *schema.DoctorTransaction) (bool, error) {
+
+	closed := false
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		closed, err = consultation.CloseRoomchat(tx, doctorTransaction, roomchat.ID, lifecycle.ActorSystem, 0, "roomchat expired")
+		return err
+	})
+
+	return closed, err
+}
+
+func notifyRoomchatExpired(roomchat schema.Roomchat, doctorTransaction schema.DoctorTransaction) {
+
+	consultation.Disconnect(roomchat.ID, lifecycle.ActorSystem, 0)
+
+	var user schema.User
+	if err := configs.DB.First(&user, doctorTransaction.UserID).Error; err == nil {
+		helper.SendNotificationEmail(user.Email, user.Fullname, "expired", "", "", "", false, int(roomchat.ID))
+	}
+
+	var doctor schema.Doctor
+	if err := configs.DB.First(&doctor, doctorTransaction.DoctorID).Error; err == nil {
+		helper.SendNotificationEmail(doctor.Email, doctor.Fullname, "expired", "", "", "", false, int(roomchat.ID))
+	}
+}
@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/helper/stock"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReleaseExpiredReservations gives back the medicines of every order whose reservation expired
+// without a pending or successful checkout
+func ReleaseExpiredReservations() error {
+
+	active := configs.DB.Model(&schema.Checkout{}).
+		Select("medicine_transaction_id").
+		Where("payment_status IN ?", []string{payment.StatusPending, payment.StatusSuccess})
+
+	var medicineTransactions []schema.MedicineTransaction
+	if err := configs.DB.
+		Where("reservation_status = ? AND reserved_until <= ?", stock.StatusReserved, time.Now()).
+		Where("id NOT IN (?)", active).
+		Find(&medicineTransactions).Error; err != nil {
+		return err
+	}
+
+	for _, medicineTransaction := range medicineTransactions {
+		if err := releaseReservation(medicineTransaction.ID); err != nil {
+			log.Printf("failed to release reservation of medicine transaction %d: %v\n", medicineTransaction.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseReservation rechecks the reservation under the row lock, a checkout created meanwhile keeps it
+func releaseReservation(medicineTransactionID uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var locked schema.MedicineTransaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, medicineTransactionID).Error; err != nil {
+			return err
+		}
+
+		if locked.ReservationStatus != stock.StatusReserved || locked.ReservedUntil == nil || locked.ReservedUntil.After(time.Now()) {
+			return nil
+		}
+
+		var active int64
+		if err := tx.Model(&schema.Checkout{}).
+			Where("medicine_transaction_id = ? AND payment_status IN ?", medicineTransactionID, []string{payment.StatusPending, payment.StatusSuccess}).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return nil
+		}
+
+		_, err := stock.Release(tx, medicineTransactionID, stock.MovementRelease, lifecycle.ActorSystem, 0, "reservation expired")
+		return err
+	})
+}
+
+// ExpirePendingCheckouts cancels the checkouts whose payment is still pending CheckoutPaymentTTL after they
+// were created or last reopened, so an order that is never paid does not hold its medicines forever
+func ExpirePendingCheckouts() error {
+
+	var checkouts []schema.Checkout
+	if err := configs.DB.
+		Where("payment_status = ? AND updated_at <= ?", payment.StatusPending, time.Now().Add(-stock.CheckoutPaymentTTL)).
+		Find(&checkouts).Error; err != nil {
+		return err
+	}
+
+	for _, checkout := range checkouts {
+		if err := expireCheckout(checkout.ID); err != nil {
+			log.Printf("failed to expire checkout %d: %v\n", checkout.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// expireCheckout cancels one pending checkout under the row lock and gives back the medicines of its
+// order, unless another checkout of the same order is still active
+func expireCheckout(checkoutID uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var locked schema.Checkout
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, checkoutID).Error; err != nil {
+			return err
+		}
+
+		if locked.PaymentStatus != payment.StatusPending {
+			return nil
+		}
+
+		if err := tx.Model(&locked).Updates(map[string]interface{}{
+			"payment_status":    payment.StatusCancelled,
+			"fulfilment_status": nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		var active int64
+		if err := tx.Model(&schema.Checkout{}).
+			Where("medicine_transaction_id = ? AND payment_status IN ?", locked.MedicineTransactionID, []string{payment.StatusPending, payment.StatusSuccess}).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return nil
+		}
+
+		if _, err := stock.Release(tx, locked.MedicineTransactionID, stock.MovementRelease, lifecycle.ActorSystem, 0, "payment deadline passed"); err != nil {
+			return err
+		}
+
+		return tx.Model(&schema.MedicineTransaction{}).
+			Where("id = ?", locked.MedicineTransactionID).
+			Update("status_transaction", "belum dibayar").Error
+	})
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"healthcare/configs"
+	"healthcare/jobs"
 	"healthcare/middlewares"
 	"healthcare/routes"
+	"healthcare/utils/helper/payment"
+	"healthcare/utils/helper/shipping"
 	"os"
 	"strconv"
 
@@ -17,6 +20,9 @@ func main() {
 	_ = godotenv.Load() // ignore error to anticipate server not run
 
 	configs.Init()
+	payment.Init()
+	shipping.Init()
+	jobs.Start()
 	e := echo.New()
 
 	// load middlewares
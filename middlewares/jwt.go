@@ -26,8 +26,34 @@ func GenerateToken(userID uint, email string, role string) (string, error) {
 	return tokenString, nil
 }
 
+// SocketToken moves the token query param of a websocket handshake into the Authorization header, because
+// browsers can not set headers on the handshake. It is only meant for the socket routes and strips the token
+// from the request uri so it does not end up in the access log.
+func SocketToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		query := req.URL.Query()
+		tokenString := query.Get("token")
+		if tokenString == "" {
+			return next(c)
+		}
+
+		query.Del("token")
+		req.URL.RawQuery = query.Encode()
+		req.RequestURI = req.URL.RequestURI()
+
+		if c.IsWebSocket() && req.Header.Get("Authorization") == "" {
+			req.Header.Set("Authorization", "Bearer "+tokenString)
+		}
+
+		return next(c)
+	}
+}
+
 func ExtractToken(c echo.Context) (*jwt.Token, error) {
 	tokenString := c.Request().Header.Get("Authorization")
+
 	if tokenString == "" {
 		return nil, c.JSON(http.StatusUnauthorized, helper.ErrorResponse("Missing Token"))
 	}
@@ -0,0 +1,26 @@
+package schema
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Address is a saved delivery address of a user, orders copy it so later edits do not change them
+type Address struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;index"`
+	Label      string `gorm:"type:varchar(50)"`
+	Recipient  string `gorm:"type:varchar(100);not null"`
+	HP         string `gorm:"type:varchar(20);not null"`
+	Street     string `gorm:"type:text;not null"`
+	Province   string `gorm:"type:varchar(100);not null"`
+	City       string `gorm:"type:varchar(100);not null"`
+	District   string `gorm:"type:varchar(100);not null"`
+	PostalCode string `gorm:"type:varchar(10);not null"`
+	Notes      string `gorm:"type:text"`
+	IsDefault  bool   `gorm:"not null;default:false"`
+	UpdatedAt  time.Time
+	CreatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,15 @@
+package schema
+
+import "time"
+
+// CartItem is one medicine in the server-side cart of a user, the price is remembered to spot later price changes
+type CartItem struct {
+	ID         uint     `gorm:"primaryKey"`
+	UserID     uint     `gorm:"not null;uniqueIndex:idx_cart_item"`
+	MedicineID uint     `gorm:"not null;uniqueIndex:idx_cart_item"`
+	Medicine   Medicine `gorm:"ForeignKey:MedicineID"`
+	Quantity   int      `gorm:"not null"`
+	PriceAtAdd int      `gorm:"not null"`
+	UpdatedAt  time.Time
+	CreatedAt  time.Time
+}
@@ -6,11 +6,20 @@ import (
 )
 
 type Checkout struct {
-	ID                    uint                `gorm:"primarykey"`
-	MedicineTransactionID uint                `gorm:"not null"`
-	PaymentConfirmation   string              `gorm:"not null"`
-	PaymentStatus         string              `gorm:"type:enum('pending', 'success', 'cancelled');default:'pending'"`
+	ID                    uint   `gorm:"primarykey"`
+	MedicineTransactionID uint   `gorm:"not null"`
+	PaymentConfirmation   string `gorm:"not null"`
+	PaymentProvider       string `gorm:"type:varchar(50);default:'manual'"`
+	PaymentReference      string `gorm:"index"`
+	PaymentStatus         string `gorm:"type:enum('pending', 'success', 'cancelled', 'refunded');default:'pending'"`
+	RefundStatus          string `gorm:"type:enum('pending', 'completed', 'rejected');default:null"`
+	FulfilmentStatus      string `gorm:"type:enum('packed', 'shipped', 'delivered', 'returned');default:null;index"`
+	Courier               string `gorm:"type:varchar(50)"`
+	TrackingNumber        string `gorm:"type:varchar(100)"`
+	ShippedAt             *time.Time
+	DeliveredAt           *time.Time
 	MedicineTransaction   MedicineTransaction `gorm:"ForeignKey:MedicineTransactionID;references:ID"`
+	FulfilmentEvents      []FulfilmentEvent   `gorm:"ForeignKey:CheckoutID;references:ID"`
 	UpdatedAt             time.Time
 	CreatedAt             time.Time
 	DeletedAt             gorm.DeletedAt `gorm:"index"`
@@ -0,0 +1,15 @@
+package schema
+
+import "time"
+
+// ConsultationTransition records every lifecycle change of a doctor transaction
+type ConsultationTransition struct {
+	ID                  uint   `gorm:"primaryKey"`
+	DoctorTransactionID uint   `gorm:"not null;index"`
+	FromStatus          string `gorm:"not null"`
+	ToStatus            string `gorm:"not null"`
+	ActorRole           string `gorm:"type:enum('user', 'doctor', 'admin', 'system');not null"`
+	ActorID             uint
+	Note                string `gorm:"type:text"`
+	CreatedAt           time.Time
+}
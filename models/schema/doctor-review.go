@@ -0,0 +1,20 @@
+package schema
+
+import "time"
+
+// DoctorReview is the patient's rating of a finished consultation, one per doctor transaction.
+// Hidden reviews are kept for moderation but left out of the doctor's rating.
+type DoctorReview struct {
+	ID                  uint   `gorm:"primaryKey"`
+	DoctorTransactionID uint   `gorm:"not null;uniqueIndex"`
+	DoctorID            uint   `gorm:"not null;index"`
+	UserID              uint   `gorm:"not null;index"`
+	Rating              int    `gorm:"not null"`
+	Comment             string `gorm:"type:text"`
+	Status              string `gorm:"type:enum('published', 'hidden');default:'published'"`
+	ModerationReason    string `gorm:"type:text"`
+	ModeratedBy         uint
+	ModeratedAt         *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DoctorSchedule is a weekly recurring working window, split into slots of SlotDuration minutes
+type DoctorSchedule struct {
+	ID           uint   `gorm:"primaryKey"`
+	DoctorID     uint   `gorm:"not null;index"`
+	DayOfWeek    int    `gorm:"not null"`
+	StartTime    string `gorm:"type:varchar(5);not null"`
+	EndTime      string `gorm:"type:varchar(5);not null"`
+	SlotDuration int    `gorm:"not null;default:30"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+// DoctorScheduleException blocks a whole day, or part of it when StartTime and EndTime are set
+type DoctorScheduleException struct {
+	ID        uint      `gorm:"primaryKey"`
+	DoctorID  uint      `gorm:"not null;index"`
+	Date      time.Time `gorm:"type:date;not null"`
+	StartTime string    `gorm:"type:varchar(5);default:null"`
+	EndTime   string    `gorm:"type:varchar(5);default:null"`
+	Reason    string    `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+// DoctorSlot is a booked slot, the unique index keeps a doctor from being booked twice at the same time
+type DoctorSlot struct {
+	ID                  uint      `gorm:"primaryKey"`
+	DoctorID            uint      `gorm:"not null;uniqueIndex:idx_doctor_slot"`
+	StartTime           time.Time `gorm:"not null;uniqueIndex:idx_doctor_slot"`
+	EndTime             time.Time `gorm:"not null"`
+	DoctorTransactionID uint      `gorm:"not null;uniqueIndex"`
+	CreatedAt           time.Time
+}
@@ -7,15 +7,24 @@ import (
 )
 
 type DoctorTransaction struct {
-	ID                  uint   `gorm:"primaryKey"`
-	DoctorID            uint   `gorm:"foreignKey:DoctorID"`
-	UserID              uint   `gorm:"foreignKey:UserID"`
-	HealthDetails       string `gorm:"not null"`
-	Price               int    `gorm:"not null"`
-	PaymentMethod       string `gorm:"type:enum('manual transfer bca', 'manual transfer bri', 'manual transfer bni');default:null"`
-	PaymentConfirmation string `gorm:"not null"`
-	PaymentStatus       string `gorm:"type:enum('pending', 'success', 'cancelled');default:'pending'"`
-	PatientStatus       string `gorm:"type:enum('pending', 'recovered', 'ongoing consultation', 'referred');default:'pending'"`
+	ID                  uint       `gorm:"primaryKey"`
+	DoctorID            uint       `gorm:"foreignKey:DoctorID"`
+	UserID              uint       `gorm:"foreignKey:UserID"`
+	HealthDetails       string     `gorm:"not null"`
+	Price               int        `gorm:"not null"`
+	VoucherID           *uint      `gorm:"default:null"`
+	VoucherCode         string     `gorm:"type:varchar(50)"`
+	Discount            int        `gorm:"not null;default:0"`
+	PaymentMethod       string     `gorm:"type:varchar(50);default:null"`
+	PaymentProvider     string     `gorm:"type:varchar(50);default:'manual'"`
+	PaymentReference    string     `gorm:"index"`
+	PaymentConfirmation string     `gorm:"not null"`
+	PaymentStatus       string     `gorm:"type:enum('pending', 'success', 'cancelled', 'refunded');default:'pending'"`
+	RefundStatus        string     `gorm:"type:enum('pending', 'completed', 'rejected');default:null"`
+	PatientStatus       string     `gorm:"type:enum('pending', 'recovered', 'ongoing consultation', 'referred');default:'pending'"`
+	ConsultationStatus  string     `gorm:"type:enum('booked', 'paid', 'cancelled', 'in consultation', 'closed', 'referred', 'refunded');default:'booked'"`
+	ScheduleStart       *time.Time `gorm:"default:null"`
+	ScheduleEnd         *time.Time `gorm:"default:null"`
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
 	DeletedAt           *gorm.DeletedAt `gorm:"index"`
@@ -0,0 +1,17 @@
+package schema
+
+import "time"
+
+// FulfilmentEvent records every fulfilment change of a checkout, together they form its tracking timeline
+type FulfilmentEvent struct {
+	ID             uint   `gorm:"primaryKey"`
+	CheckoutID     uint   `gorm:"not null;index"`
+	FromStatus     string `gorm:"type:varchar(20)"`
+	ToStatus       string `gorm:"type:varchar(20);not null"`
+	Courier        string `gorm:"type:varchar(50)"`
+	TrackingNumber string `gorm:"type:varchar(100)"`
+	ActorRole      string `gorm:"type:enum('user', 'doctor', 'admin', 'system');not null"`
+	ActorID        uint
+	Note           string `gorm:"type:text"`
+	CreatedAt      time.Time
+}
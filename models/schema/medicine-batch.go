@@ -0,0 +1,26 @@
+package schema
+
+import "time"
+
+// MedicineBatch holds the remaining on-hand quantity of one received batch of a medicine
+type MedicineBatch struct {
+	ID              uint      `gorm:"primaryKey"`
+	MedicineID      uint      `gorm:"not null;uniqueIndex:idx_medicine_batch"`
+	Medicine        Medicine  `gorm:"ForeignKey:MedicineID"`
+	BatchNumber     string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_medicine_batch"`
+	ExpiryDate      time.Time `gorm:"type:date;not null;index"`
+	InitialQuantity int       `gorm:"not null"`
+	Quantity        int       `gorm:"not null"`
+	UpdatedAt       time.Time
+	CreatedAt       time.Time
+}
+
+// MedicineBatchAllocation records which batches a paid medicine transaction was dispensed from
+type MedicineBatchAllocation struct {
+	ID                    uint `gorm:"primaryKey"`
+	MedicineTransactionID uint `gorm:"not null;index"`
+	MedicineID            uint `gorm:"not null"`
+	MedicineBatchID       uint `gorm:"not null;index"`
+	Quantity              int  `gorm:"not null"`
+	CreatedAt             time.Time
+}
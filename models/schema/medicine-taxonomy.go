@@ -0,0 +1,17 @@
+package schema
+
+import "time"
+
+type MedicineCategory struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(100);not null;uniqueIndex"`
+	UpdatedAt time.Time
+	CreatedAt time.Time
+}
+
+type MedicineType struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(100);not null;uniqueIndex"`
+	UpdatedAt time.Time
+	CreatedAt time.Time
+}
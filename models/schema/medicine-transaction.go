@@ -8,13 +8,29 @@ import (
 type MedicineTransaction struct {
 	ID                uint `gorm:"primarykey"`
 	UserID            uint
+	PrescriptionID    *uint             `gorm:"default:null"`
 	Name              string            `gorm:"not null"`
 	Address           string            `gorm:"not null"`
 	HP                string            `gorm:"not null"`
-	PaymentMethod     string            `gorm:"type:enum('manual transfer bca', 'manual transfer bri', 'manual transfer bni')"`
+	AddressID         *uint             `gorm:"default:null"`
+	Province          string            `gorm:"type:varchar(100);index"`
+	City              string            `gorm:"type:varchar(100);index"`
+	District          string            `gorm:"type:varchar(100)"`
+	PostalCode        string            `gorm:"type:varchar(10)"`
+	AddressNotes      string            `gorm:"type:text"`
+	PaymentMethod     string            `gorm:"type:varchar(50)"`
 	MedicineDetails   []MedicineDetails `gorm:"ForeignKey:MedicineTransactionID;references:ID"`
+	ShippingProvider  string            `gorm:"type:varchar(50)"`
+	ShippingService   string            `gorm:"type:varchar(50)"`
+	ShippingWeight    int               `gorm:"not null;default:0"`
+	ShippingFee       int               `gorm:"not null;default:0"`
+	VoucherID         *uint             `gorm:"default:null"`
+	VoucherCode       string            `gorm:"type:varchar(50)"`
+	Discount          int               `gorm:"not null;default:0"`
 	TotalPrice        int
-	StatusTransaction string `gorm:"type:enum('belum dibayar', 'sudah dibayar');default:'belum dibayar'"`
+	StatusTransaction string     `gorm:"type:enum('belum dibayar', 'sudah dibayar');default:'belum dibayar'"`
+	ReservationStatus string     `gorm:"type:enum('reserved', 'committed', 'released');default:null"`
+	ReservedUntil     *time.Time `gorm:"index"`
 	UpdatedAt         time.Time
 	CreatedAt         time.Time
 	DeletedAt         gorm.DeletedAt `gorm:"index"`
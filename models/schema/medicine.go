@@ -7,17 +7,22 @@ import (
 )
 
 type Medicine struct {
-	ID        uint   `gorm:"primarykey"`
-	Code      string `gorm:"not null"`
-	Name      string `gorm:"not null"`
-	Merk      string `gorm:"not null"`
-	Category  string `gorm:"not null"`
-	Type      string `gorm:"not null"`
-	Stock     int    `gorm:"not null"`
-	Price     int    `gorm:"not null"`
-	Details   string `gorm:"not null"`
-	Image     string `gorm:"not null"`
-	UpdatedAt time.Time
-	CreatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID                uint   `gorm:"primarykey"`
+	Code              string `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Name              string `gorm:"not null"`
+	Merk              string `gorm:"not null"`
+	CategoryID        *uint  `gorm:"index"`
+	Category          string `gorm:"not null"`
+	TypeID            *uint  `gorm:"index"`
+	Type              string `gorm:"not null"`
+	Stock             int    `gorm:"not null"`
+	ReorderThreshold  int    `gorm:"not null;default:0"`
+	Weight            int    `gorm:"not null;default:0"`
+	LowStockAlertedAt *time.Time
+	Price             int    `gorm:"not null"`
+	Details           string `gorm:"not null"`
+	Image             string `gorm:"not null"`
+	UpdatedAt         time.Time
+	CreatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
 }
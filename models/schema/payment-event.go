@@ -0,0 +1,18 @@
+package schema
+
+import "time"
+
+// PaymentEvent stores every verified notification of a payment provider, once per provider event
+type PaymentEvent struct {
+	ID                uint   `gorm:"primaryKey"`
+	Provider          string `gorm:"type:varchar(50);not null;uniqueIndex:idx_provider_event"`
+	EventID           string `gorm:"type:varchar(100);not null;uniqueIndex:idx_provider_event"`
+	ProviderReference string `gorm:"index"`
+	Status            string `gorm:"type:enum('pending', 'success', 'cancelled');not null"`
+	Payload           string `gorm:"type:text"`
+	Deliveries        int    `gorm:"not null;default:1"`
+	ProcessedAt       *time.Time
+	Error             string `gorm:"type:text"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
@@ -1 +1,30 @@
-package schema
\ No newline at end of file
+package schema
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Prescription struct {
+	ID                  uint                  `gorm:"primaryKey"`
+	DoctorTransactionID uint                  `gorm:"not null;unique"`
+	DoctorID            uint                  `gorm:"not null"`
+	UserID              uint                  `gorm:"not null"`
+	Notes               string                `gorm:"type:text"`
+	PrescriptionDetails []PrescriptionDetails `gorm:"ForeignKey:PrescriptionID;references:ID"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DeletedAt           gorm.DeletedAt `gorm:"index"`
+}
+
+type PrescriptionDetails struct {
+	ID             uint     `gorm:"primaryKey"`
+	PrescriptionID uint     `gorm:"not null"`
+	MedicineID     uint     `gorm:"not null"`
+	Medicine       Medicine `gorm:"ForeignKey:MedicineID"`
+	Quantity       int      `gorm:"not null"`
+	Dosage         string   `gorm:"not null"`
+	Frequency      string   `gorm:"not null"`
+	Duration       string   `gorm:"not null"`
+}
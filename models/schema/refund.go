@@ -0,0 +1,23 @@
+package schema
+
+import "time"
+
+// Refund returns the payment of a doctor transaction or a checkout to the user. Exactly one of
+// DoctorTransactionID and CheckoutID is set.
+type Refund struct {
+	ID                  uint   `gorm:"primaryKey"`
+	DoctorTransactionID *uint  `gorm:"index;default:null"`
+	CheckoutID          *uint  `gorm:"index;default:null"`
+	RoomchatExtensionID *uint  `gorm:"index;default:null"`
+	UserID              uint   `gorm:"not null;index"`
+	Amount              int    `gorm:"not null"`
+	Reason              string `gorm:"type:text;not null"`
+	Method              string `gorm:"type:varchar(50);not null"`
+	Status              string `gorm:"type:enum('pending', 'completed', 'rejected');default:'pending'"`
+	RequestedBy         string `gorm:"type:enum('user', 'admin', 'system');not null"`
+	Note                string `gorm:"type:text"`
+	ProcessedBy         uint
+	ProcessedAt         *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
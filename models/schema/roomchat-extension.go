@@ -0,0 +1,21 @@
+package schema
+
+import "time"
+
+// RoomchatExtension adds minutes to a roomchat. Free extensions are applied right away,
+// paid ones once the patient's payment is confirmed.
+type RoomchatExtension struct {
+	ID                  uint   `gorm:"primaryKey"`
+	RoomchatID          uint   `gorm:"not null;index"`
+	DoctorID            uint   `gorm:"not null"`
+	Minutes             int    `gorm:"not null"`
+	Price               int    `gorm:"not null;default:0"`
+	PaymentMethod       string `gorm:"type:varchar(50);default:null"`
+	PaymentProvider     string `gorm:"type:varchar(50);default:null"`
+	PaymentReference    string `gorm:"index"`
+	PaymentConfirmation string `gorm:"default:null"`
+	PaymentStatus       string `gorm:"type:enum('pending', 'success', 'cancelled');default:'pending'"`
+	AppliedAt           *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
@@ -3,10 +3,22 @@ package schema
 import "time"
 
 type Roomchat struct {
-	ID             uint `gorm:"primaryKey"`
-	TransactionID  uint
-	Status         bool
-	ExpirationTime *time.Time
-	CreatedAt      time.Time
-	Message        []Message `gorm:"ForeignKey:RoomchatID;references:ID"` // one to many
+	ID              uint `gorm:"primaryKey"`
+	TransactionID   uint
+	Status          bool
+	ExpirationTime  *time.Time
+	ExtendedMinutes int    `gorm:"not null;default:0"`
+	ClosingSummary  string `gorm:"type:text"`
+	CreatedAt       time.Time
+	Message         []Message `gorm:"ForeignKey:RoomchatID;references:ID"` // one to many
+}
+
+// RoomchatRead marks the last message a participant has read in a roomchat
+type RoomchatRead struct {
+	ID                uint   `gorm:"primaryKey"`
+	RoomchatID        uint   `gorm:"not null;uniqueIndex:idx_roomchat_reader"`
+	Role              string `gorm:"type:enum('user', 'doctor');not null;uniqueIndex:idx_roomchat_reader"`
+	ReaderID          uint   `gorm:"not null;uniqueIndex:idx_roomchat_reader"`
+	LastReadMessageID uint   `gorm:"not null;default:0"`
+	UpdatedAt         time.Time
 }
@@ -0,0 +1,18 @@
+package schema
+
+import "time"
+
+// StockMovement records every change of a medicine stock, rows are only ever appended
+type StockMovement struct {
+	ID                    uint   `gorm:"primaryKey"`
+	MedicineID            uint   `gorm:"not null;index"`
+	Type                  string `gorm:"type:enum('restock', 'sale', 'release', 'refund', 'adjustment', 'expiry');not null"`
+	Quantity              int    `gorm:"not null"`
+	StockAfter            int    `gorm:"not null"`
+	MedicineTransactionID *uint  `gorm:"default:null;index"`
+	MedicineBatchID       *uint  `gorm:"default:null;index"`
+	ActorRole             string `gorm:"type:enum('user', 'doctor', 'admin', 'system');not null"`
+	ActorID               uint
+	Reason                string    `gorm:"type:text"`
+	CreatedAt             time.Time `gorm:"index"`
+}
@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Voucher is a promo code giving a discount on consultations or medicines
+type Voucher struct {
+	ID           uint   `gorm:"primaryKey"`
+	Code         string `gorm:"type:varchar(50);not null;uniqueIndex"`
+	Description  string `gorm:"type:text"`
+	Scope        string `gorm:"type:enum('consultation', 'medicine');not null"`
+	DiscountType string `gorm:"type:enum('percentage', 'fixed');not null"`
+	Value        int    `gorm:"not null"`
+	MinSpend     int    `gorm:"not null;default:0"`
+	MaxDiscount  int    `gorm:"not null;default:0"`
+	StartsAt     time.Time
+	EndsAt       time.Time
+	UsageLimit   int             `gorm:"not null;default:0"`
+	PerUserLimit int             `gorm:"not null;default:0"`
+	IsActive     bool            `gorm:"not null;default:true"`
+	Targets      []VoucherTarget `gorm:"ForeignKey:VoucherID;references:ID"`
+	UpdatedAt    time.Time
+	CreatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+// VoucherTarget limits a voucher to a doctor, a specialist or a medicine category, a voucher without targets applies to everything in its scope
+type VoucherTarget struct {
+	ID                 uint   `gorm:"primaryKey"`
+	VoucherID          uint   `gorm:"not null;index"`
+	DoctorID           *uint  `gorm:"default:null"`
+	Specialist         string `gorm:"type:varchar(100)"`
+	MedicineCategoryID *uint  `gorm:"default:null"`
+}
+
+// VoucherRedemption records a voucher used on a transaction, released ones no longer count towards the usage limits
+type VoucherRedemption struct {
+	ID                    uint  `gorm:"primaryKey"`
+	VoucherID             uint  `gorm:"not null;index"`
+	UserID                uint  `gorm:"not null;index"`
+	DoctorTransactionID   *uint `gorm:"index"`
+	MedicineTransactionID *uint `gorm:"index"`
+	Discount              int   `gorm:"not null"`
+	ReleasedAt            *time.Time
+	CreatedAt             time.Time
+}
@@ -0,0 +1,14 @@
+package web
+
+type AddressRequest struct {
+	Label      string `json:"label" form:"label" validate:"omitempty,max=50"`
+	Recipient  string `json:"recipient" form:"recipient" validate:"required,max=100"`
+	HP         string `json:"hp" form:"hp" validate:"required,max=20"`
+	Street     string `json:"street" form:"street" validate:"required"`
+	Province   string `json:"province" form:"province" validate:"required,max=100"`
+	City       string `json:"city" form:"city" validate:"required,max=100"`
+	District   string `json:"district" form:"district" validate:"required,max=100"`
+	PostalCode string `json:"postal_code" form:"postal_code" validate:"required,numeric,max=10"`
+	Notes      string `json:"notes" form:"notes" validate:"omitempty,max=1000"`
+	IsDefault  bool   `json:"is_default" form:"is_default"`
+}
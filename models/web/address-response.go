@@ -0,0 +1,18 @@
+package web
+
+import "time"
+
+type AddressResponse struct {
+	ID         uint      `json:"id"`
+	Label      string    `json:"label"`
+	Recipient  string    `json:"recipient"`
+	HP         string    `json:"hp"`
+	Street     string    `json:"street"`
+	Province   string    `json:"province"`
+	City       string    `json:"city"`
+	District   string    `json:"district"`
+	PostalCode string    `json:"postal_code"`
+	Notes      string    `json:"notes"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -26,6 +26,7 @@ type AdminTransactionUsersResponse struct {
 	CreatedAt           time.Time `json:"created_at"`
 	PaymentConfirmation string    `json:"payment_confirmation"`
 	PaymentStatus       string    `json:"payment_status"`
+	ConsultationStatus  string    `json:"consultation_status"`
 }
 type AdminDoctorPaymentsResponse struct { 
 	TransactionID       uint      `json:"transaction_id"` 
@@ -0,0 +1,21 @@
+package web
+
+type CartItemRequest struct {
+	MedicineID uint `json:"medicine_id" form:"medicine_id" validate:"required"`
+	Quantity   int  `json:"quantity" form:"quantity" validate:"required,min=1"`
+}
+
+type CartItemUpdateRequest struct {
+	Quantity int `json:"quantity" form:"quantity" validate:"required,min=1"`
+}
+
+type CartOrderRequest struct {
+	AddressID          uint   `json:"address_id" form:"address_id"`
+	Name               string `json:"name" form:"name" validate:"omitempty"`
+	Address            string `json:"address" form:"address" validate:"omitempty"`
+	HP                 string `json:"hp" form:"hp" validate:"omitempty"`
+	PaymentMethod      string `json:"payment_method" form:"payment_method" validate:"required"`
+	ShippingService    string `json:"shipping_service" form:"shipping_service"`
+	VoucherCode        string `json:"voucher_code" form:"voucher_code" validate:"omitempty,max=50"`
+	AcceptPriceChanges bool   `json:"accept_price_changes" form:"accept_price_changes"`
+}
@@ -0,0 +1,27 @@
+package web
+
+import "time"
+
+type CartItemResponse struct {
+	ID           uint      `json:"id"`
+	MedicineID   uint      `json:"medicine_id"`
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	Image        string    `json:"image"`
+	Price        int       `json:"price"`
+	PriceAtAdd   int       `json:"price_at_add"`
+	PriceChanged bool      `json:"price_changed"`
+	Stock        int       `json:"stock"`
+	Available    bool      `json:"available"`
+	Quantity     int       `json:"quantity"`
+	TotalPrice   int       `json:"total_price"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CartResponse struct {
+	Items         []CartItemResponse `json:"items"`
+	TotalQuantity int                `json:"total_quantity"`
+	TotalPrice    int                `json:"total_price"`
+	PriceChanged  bool               `json:"price_changed"`
+	Available     bool               `json:"available"`
+}
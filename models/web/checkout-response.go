@@ -3,10 +3,19 @@ package web
 import "time"
 
 type CheckoutResponse struct {
-	ID                       uint                     `json:"id"`
-	PaymentStatus            string                   `json:"payment_status"`
-	MedicineTransactionID    uint                     `json:"medicine_transaction_id"`
-	MedicineCheckoutResponse MedicineCheckoutResponse `json:"medicine_transaction"`
-	CreatedAt                time.Time                `json:"created_at"`
-	PaymentConfirmation      string                   `json:"payment_confirmation"`
-}
\ No newline at end of file
+	ID                       uint                      `json:"id"`
+	PaymentStatus            string                    `json:"payment_status"`
+	RefundStatus             string                    `json:"refund_status"`
+	MedicineTransactionID    uint                      `json:"medicine_transaction_id"`
+	MedicineCheckoutResponse MedicineCheckoutResponse  `json:"medicine_transaction"`
+	CreatedAt                time.Time                 `json:"created_at"`
+	PaymentConfirmation      string                    `json:"payment_confirmation"`
+	PaymentProvider          string                    `json:"payment_provider"`
+	PaymentReference         string                    `json:"payment_reference"`
+	FulfilmentStatus         string                    `json:"fulfilment_status"`
+	Courier                  string                    `json:"courier"`
+	TrackingNumber           string                    `json:"tracking_number"`
+	ShippedAt                *time.Time                `json:"shipped_at"`
+	DeliveredAt              *time.Time                `json:"delivered_at"`
+	Timeline                 []FulfilmentEventResponse `json:"timeline,omitempty"`
+}
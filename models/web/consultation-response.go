@@ -0,0 +1,41 @@
+package web
+
+import "time"
+
+type ConsultationDoctorResponse struct {
+	ID             uint   `json:"id"`
+	Fullname       string `json:"fullname"`
+	Specialist     string `json:"specialist"`
+	ProfilePicture string `json:"profile_picture"`
+}
+
+type ConsultationHistoryResponse struct {
+	TransactionID      uint                       `json:"transaction_id"`
+	RoomchatID         uint                       `json:"roomchat_id"`
+	Doctor             ConsultationDoctorResponse `json:"doctor"`
+	HealthDetails      string                     `json:"health_details"`
+	PatientStatus      string                     `json:"patient_status"`
+	ConsultationStatus string                     `json:"consultation_status"`
+	ClosingSummary     string                     `json:"closing_summary"`
+	HasPrescription    bool                       `json:"has_prescription"`
+	StartedAt          time.Time                  `json:"started_at"`
+	EndedAt            *time.Time                 `json:"ended_at"`
+}
+
+type ConsultationTranscriptMessage struct {
+	Sender    string
+	Role      string
+	Message   string
+	Image     string
+	Audio     string
+	CreatedAt time.Time
+}
+
+// ConsultationTranscript is the data rendered into the exported transcript document
+type ConsultationTranscript struct {
+	Consultation ConsultationHistoryResponse
+	Patient      string
+	Messages     []ConsultationTranscriptMessage
+	Prescription *PrescriptionResponse
+	GeneratedAt  time.Time
+}
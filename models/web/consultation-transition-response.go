@@ -0,0 +1,13 @@
+package web
+
+import "time"
+
+type ConsultationTransitionResponse struct {
+	ID         uint      `json:"id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorRole  string    `json:"actor_role"`
+	ActorID    uint      `json:"actor_id"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
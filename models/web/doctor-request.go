@@ -34,7 +34,7 @@ type DoctorUpdateRequest struct {
 // Manage Patient
 type UpdateManageUserRequest struct {
 	HealthDetails string `json:"health_details" form:"health_details" validate:"omitempty,min=3"`
-	PatientStatus string `json:"patient_status" form:"patient_status" validate:"omitempty"`
+	PatientStatus string `json:"patient_status" form:"patient_status" validate:"omitempty,oneof=pending recovered 'ongoing consultation' referred"`
 }
 
 type ChangeDoctorStatusRequest struct {
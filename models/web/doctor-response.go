@@ -69,16 +69,18 @@ type DoctorIDResponseByAdmin struct {
 }
 
 type DoctorIDResponse struct {
-	ID               uint   `json:"id"`
-	ProfilePicture   string `json:"profile_picture"`
-	Status           bool   `json:"status"`
-	Fullname         string `json:"fullname"`
-	Specialist       string `json:"specialist"`
-	Price            int    `json:"price"`
-	Alumnus          string `json:"alumnus"`
-	Experience       string `json:"experience"`
-	NoSTR            int    `json:"no_str"`
-	LocationPractice string `json:"location_practice"`
+	ID               uint    `json:"id"`
+	ProfilePicture   string  `json:"profile_picture"`
+	Status           bool    `json:"status"`
+	Fullname         string  `json:"fullname"`
+	Specialist       string  `json:"specialist"`
+	Price            int     `json:"price"`
+	Alumnus          string  `json:"alumnus"`
+	Experience       string  `json:"experience"`
+	NoSTR            int     `json:"no_str"`
+	LocationPractice string  `json:"location_practice"`
+	Rating           float64 `json:"rating"`
+	ReviewCount      int64   `json:"review_count"`
 }
 
 // Manage Patient
@@ -90,6 +92,7 @@ type ManageUserResponse struct {
 	CreatedAt           time.Time `json:"created_at"`
 	HealthDetails       string    `json:"health_details"`
 	PatientStatus       string    `json:"patient_status"`
+	ConsultationStatus  string    `json:"consultation_status"`
 }
 
 type DoctorProfileRoomchat struct {
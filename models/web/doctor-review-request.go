@@ -0,0 +1,11 @@
+package web
+
+type DoctorReviewRequest struct {
+	Rating  int    `json:"rating" form:"rating" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" form:"comment" validate:"max=1000"`
+}
+
+type DoctorReviewModerationRequest struct {
+	Status string `json:"status" form:"status" validate:"required,oneof=published hidden"`
+	Reason string `json:"reason" form:"reason"`
+}
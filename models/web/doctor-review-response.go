@@ -0,0 +1,25 @@
+package web
+
+import "time"
+
+type DoctorReviewResponse struct {
+	ID                  uint      `json:"id"`
+	DoctorTransactionID uint      `json:"transaction_id"`
+	DoctorID            uint      `json:"doctor_id"`
+	UserID              uint      `json:"user_id"`
+	Rating              int       `json:"rating"`
+	Comment             string    `json:"comment"`
+	Status              string    `json:"status"`
+	ModerationReason    string    `json:"moderation_reason,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type DoctorPublicReviewResponse struct {
+	ID        uint      `json:"id"`
+	Fullname  string    `json:"fullname"`
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
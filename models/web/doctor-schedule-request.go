@@ -0,0 +1,15 @@
+package web
+
+type DoctorScheduleRequest struct {
+	DayOfWeek    *int   `json:"day_of_week" form:"day_of_week" validate:"required,min=0,max=6"`
+	StartTime    string `json:"start_time" form:"start_time" validate:"required"`
+	EndTime      string `json:"end_time" form:"end_time" validate:"required"`
+	SlotDuration int    `json:"slot_duration" form:"slot_duration" validate:"omitempty,min=10,max=240"`
+}
+
+type DoctorScheduleExceptionRequest struct {
+	Date      string `json:"date" form:"date" validate:"required"`
+	StartTime string `json:"start_time" form:"start_time" validate:"required_with=EndTime"`
+	EndTime   string `json:"end_time" form:"end_time" validate:"required_with=StartTime"`
+	Reason    string `json:"reason" form:"reason" validate:"required"`
+}
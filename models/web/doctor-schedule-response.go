@@ -0,0 +1,25 @@
+package web
+
+import "time"
+
+type DoctorScheduleResponse struct {
+	ID           uint   `json:"id"`
+	DayOfWeek    int    `json:"day_of_week"`
+	StartTime    string `json:"start_time"`
+	EndTime      string `json:"end_time"`
+	SlotDuration int    `json:"slot_duration"`
+}
+
+type DoctorScheduleExceptionResponse struct {
+	ID        uint   `json:"id"`
+	Date      string `json:"date"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Reason    string `json:"reason"`
+}
+
+type DoctorSlotResponse struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Available bool      `json:"available"`
+}
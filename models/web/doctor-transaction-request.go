@@ -2,5 +2,7 @@ package web
 
 type CreateDoctorTransactionRequest struct {
 	PaymentMethod       string `json:"payment_method" form:"payment_method" validate:"required"`
-	PaymentConfirmation string `json:"payment_confirmation" form:"payment_confirmation" validate:"required"`
+	PaymentConfirmation string `json:"payment_confirmation" form:"payment_confirmation"`
+	ScheduleStart       string `json:"schedule_start" form:"schedule_start"`
+	VoucherCode         string `json:"voucher_code" form:"voucher_code" validate:"omitempty,max=50"`
 }
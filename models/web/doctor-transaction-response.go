@@ -3,14 +3,23 @@ package web
 import "time"
 
 type CreateDoctorTransactionResponse struct {
-	ID                  uint      `json:"id"`
-	Fullname            string    `json:"fullname"`
-	Specialist          string    `json:"specialist"`
-	Price               int       `json:"price"`
-	PaymentMethod       string    `json:"payment_method"`
-	PaymentStatus       string    `json:"payment_status"`
-	PaymentConfirmation string    `json:"payment_confirmation"`
-	CreatedAt           time.Time `json:"created_at"`
+	ID                  uint       `json:"id"`
+	Fullname            string     `json:"fullname"`
+	Specialist          string     `json:"specialist"`
+	Price               int        `json:"price"`
+	VoucherCode         string     `json:"voucher_code,omitempty"`
+	Discount            int        `json:"discount"`
+	TotalPrice          int        `json:"total_price"`
+	PaymentMethod       string     `json:"payment_method"`
+	PaymentProvider     string     `json:"payment_provider"`
+	PaymentReference    string     `json:"payment_reference"`
+	PaymentStatus       string     `json:"payment_status"`
+	RefundStatus        string     `json:"refund_status"`
+	ConsultationStatus  string     `json:"consultation_status"`
+	PaymentConfirmation string     `json:"payment_confirmation"`
+	ScheduleStart       *time.Time `json:"schedule_start"`
+	ScheduleEnd         *time.Time `json:"schedule_end"`
+	CreatedAt           time.Time  `json:"created_at"`
 }
 
 type DoctorTransactionsResponse struct {
@@ -23,4 +32,4 @@ type DoctorTransactionDetailsResponse struct {
 	Fullname      string `json:"fullname"`
 	Specialist    string `json:"specialist"`
 	PatientStatus string `json:"patient_status"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,8 @@
+package web
+
+type FulfilmentRequest struct {
+	FulfilmentStatus string `json:"fulfilment_status" form:"fulfilment_status" validate:"required,oneof=packed shipped delivered returned"`
+	Courier          string `json:"courier" form:"courier" validate:"omitempty,max=50"`
+	TrackingNumber   string `json:"tracking_number" form:"tracking_number" validate:"omitempty,max=100"`
+	Note             string `json:"note" form:"note" validate:"omitempty,max=1000"`
+}
@@ -0,0 +1,14 @@
+package web
+
+import "time"
+
+type FulfilmentEventResponse struct {
+	ID             uint      `json:"id"`
+	FromStatus     string    `json:"from_status"`
+	ToStatus       string    `json:"to_status"`
+	Courier        string    `json:"courier"`
+	TrackingNumber string    `json:"tracking_number"`
+	ActorRole      string    `json:"actor_role"`
+	Note           string    `json:"note"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,7 @@
+package web
+
+type MedicineBatchRequest struct {
+	BatchNumber string `json:"batch_number" form:"batch_number" validate:"required,max=50"`
+	ExpiryDate  string `json:"expiry_date" form:"expiry_date" validate:"required"`
+	Quantity    int    `json:"quantity" form:"quantity" validate:"required,min=1"`
+}
@@ -0,0 +1,17 @@
+package web
+
+import "time"
+
+type MedicineBatchResponse struct {
+	ID              uint      `json:"id"`
+	MedicineID      uint      `json:"medicine_id"`
+	MedicineCode    string    `json:"medicine_code,omitempty"`
+	MedicineName    string    `json:"medicine_name,omitempty"`
+	BatchNumber     string    `json:"batch_number"`
+	ExpiryDate      string    `json:"expiry_date"`
+	DaysToExpiry    int       `json:"days_to_expiry"`
+	Expired         bool      `json:"expired"`
+	InitialQuantity int       `json:"initial_quantity"`
+	Quantity        int       `json:"quantity"`
+	CreatedAt       time.Time `json:"created_at"`
+}
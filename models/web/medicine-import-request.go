@@ -0,0 +1,15 @@
+package web
+
+type MedicineImportRow struct {
+	Code             string `validate:"required,max=100"`
+	Name             string `validate:"required"`
+	Merk             string `validate:"required"`
+	Category         string `validate:"required"`
+	Type             string `validate:"required"`
+	Stock            *int   `validate:"omitempty,min=0"`
+	Price            int    `validate:"min=0"`
+	ReorderThreshold *int   `validate:"omitempty,min=0"`
+	Weight           *int   `validate:"omitempty,min=0"`
+	Details          string `validate:"required"`
+	Image            string `validate:"omitempty,url"`
+}
@@ -0,0 +1,18 @@
+package web
+
+type MedicineImportResponse struct {
+	DryRun    bool                        `json:"dry_run"`
+	Total     int                         `json:"total"`
+	Created   int                         `json:"created"`
+	Updated   int                         `json:"updated"`
+	Unchanged int                         `json:"unchanged"`
+	Failed    int                         `json:"failed"`
+	Rows      []MedicineImportRowResponse `json:"rows"`
+}
+
+type MedicineImportRowResponse struct {
+	Line   int      `json:"line"`
+	Code   string   `json:"code"`
+	Action string   `json:"action"`
+	Errors []string `json:"errors,omitempty"`
+}
@@ -1,26 +1,34 @@
 package web
 
 type MedicineRequest struct {
-	Code     string `json:"code" form:"code" validate:"required"`
-	Name     string `json:"name" form:"name" validate:"required"`
-	Merk     string `json:"merk" form:"merk" validate:"required"`
-	Category string `json:"category" form:"category" validate:"required"`
-	Type     string `json:"type" form:"type" validate:"required"`
-	Stock    int    `json:"stock" form:"stock" validate:"required,min=0"`
-	Price    int    `json:"price" form:"price" validate:"required,min=0"`
-	Details  string `json:"details" form:"details" validate:"required"`
-	Image    string `json:"image" form:"image"`
+	Code             string `json:"code" form:"code" validate:"required"`
+	Name             string `json:"name" form:"name" validate:"required"`
+	Merk             string `json:"merk" form:"merk" validate:"required"`
+	CategoryID       uint   `json:"category_id" form:"category_id" validate:"required_without=Category"`
+	Category         string `json:"category" form:"category" validate:"required_without=CategoryID"`
+	TypeID           uint   `json:"type_id" form:"type_id" validate:"required_without=Type"`
+	Type             string `json:"type" form:"type" validate:"required_without=TypeID"`
+	Stock            int    `json:"stock" form:"stock" validate:"required,min=0"`
+	ReorderThreshold int    `json:"reorder_threshold" form:"reorder_threshold" validate:"omitempty,min=0"`
+	Weight           int    `json:"weight" form:"weight" validate:"omitempty,min=0"`
+	Price            int    `json:"price" form:"price" validate:"required,min=0"`
+	Details          string `json:"details" form:"details" validate:"required"`
+	Image            string `json:"image" form:"image"`
 }
 
 type MedicineUpdateRequest struct {
-	Code     string `json:"code" form:"code" validate:"omitempty"`
-	Name     string `json:"name" form:"name" validate:"omitempty"`
-	Merk     string `json:"merk" form:"merk" validate:"omitempty"`
-	Category string `json:"category" form:"category" validate:"omitempty"`
-	Type     string `json:"type" form:"type" validate:"omitempty"`
-	Stock    int    `json:"stock" form:"stock" validate:"omitempty,min=0"`
-	Price    int    `json:"price" form:"price" validate:"omitempty,min=0"`
-	Details  string `json:"details" form:"details" validate:"omitempty"`
+	Code             string `json:"code" form:"code" validate:"omitempty"`
+	Name             string `json:"name" form:"name" validate:"omitempty"`
+	Merk             string `json:"merk" form:"merk" validate:"omitempty"`
+	CategoryID       uint   `json:"category_id" form:"category_id" validate:"omitempty"`
+	Category         string `json:"category" form:"category" validate:"omitempty"`
+	TypeID           uint   `json:"type_id" form:"type_id" validate:"omitempty"`
+	Type             string `json:"type" form:"type" validate:"omitempty"`
+	Stock            int    `json:"stock" form:"stock" validate:"omitempty,min=0"`
+	ReorderThreshold *int   `json:"reorder_threshold" form:"reorder_threshold" validate:"omitempty,min=0"`
+	Weight           int    `json:"weight" form:"weight" validate:"omitempty,min=0"`
+	Price            int    `json:"price" form:"price" validate:"omitempty,min=0"`
+	Details          string `json:"details" form:"details" validate:"omitempty"`
 }
 
 type MedicineImageRequest struct {
@@ -0,0 +1,9 @@
+package web
+
+type MedicineCategoryRequest struct {
+	Name string `json:"name" form:"name" validate:"required,max=100"`
+}
+
+type MedicineTypeRequest struct {
+	Name string `json:"name" form:"name" validate:"required,max=100"`
+}
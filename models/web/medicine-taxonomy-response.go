@@ -0,0 +1,26 @@
+package web
+
+import "time"
+
+type MedicineCategoryResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MedicineTypeResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MedicineFacetResponse struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type MedicineFacetsResponse struct {
+	Categories []MedicineFacetResponse `json:"categories"`
+	Types      []MedicineFacetResponse `json:"types"`
+}
@@ -1,10 +1,13 @@
 package web
 
 type MedicineTransactionRequest struct {
-	Name            string            `json:"name" form:"name" validate:"required"`
-	Address         string            `json:"address" form:"address" validate:"required"`
-	HP              string            `json:"hp" form:"hp" validate:"required"`
+	AddressID       uint              `json:"address_id" form:"address_id"`
+	Name            string            `json:"name" form:"name" validate:"omitempty"`
+	Address         string            `json:"address" form:"address" validate:"omitempty"`
+	HP              string            `json:"hp" form:"hp" validate:"omitempty"`
 	PaymentMethod   string            `json:"payment_method" form:"payment_method" validate:"required"`
+	ShippingService string            `json:"shipping_service" form:"shipping_service"`
+	VoucherCode     string            `json:"voucher_code" form:"voucher_code" validate:"omitempty,max=50"`
 	MedicineDetails []MedicineDetails `json:"medicine_details" form:"medicine_details" validate:"required"`
 }
 
@@ -12,3 +15,13 @@ type MedicineDetails struct {
 	MedicineID uint `json:"medicine_id" form:"medicine_id" validate:"required"`
 	Quantity   int  `json:"quantity" form:"quantity" validate:"required,min=1"`
 }
+
+type PrescriptionOrderRequest struct {
+	AddressID       uint   `json:"address_id" form:"address_id"`
+	Name            string `json:"name" form:"name" validate:"omitempty"`
+	Address         string `json:"address" form:"address" validate:"omitempty"`
+	HP              string `json:"hp" form:"hp" validate:"omitempty"`
+	PaymentMethod   string `json:"payment_method" form:"payment_method" validate:"required"`
+	ShippingService string `json:"shipping_service" form:"shipping_service"`
+	VoucherCode     string `json:"voucher_code" form:"voucher_code" validate:"omitempty,max=50"`
+}
@@ -5,13 +5,29 @@ import "time"
 type MedicineTransactionResponse struct {
 	ID                      uint                      `json:"id"`
 	UserID                  uint                      `json:"user_id"`
+	PrescriptionID          *uint                     `json:"prescription_id"`
 	Name                    string                    `json:"name"`
 	Address                 string                    `json:"address"`
 	HP                      string                    `json:"hp"`
+	AddressID               *uint                     `json:"address_id"`
+	Province                string                    `json:"province"`
+	City                    string                    `json:"city"`
+	District                string                    `json:"district"`
+	PostalCode              string                    `json:"postal_code"`
+	AddressNotes            string                    `json:"address_notes"`
 	PaymentMethod           string                    `json:"payment_method"`
 	MedicineDetailsResponse []MedicineDetailsResponse `json:"medicine_details"`
+	Subtotal                int                       `json:"subtotal"`
+	ShippingProvider        string                    `json:"shipping_provider"`
+	ShippingService         string                    `json:"shipping_service"`
+	ShippingWeight          int                       `json:"shipping_weight"`
+	ShippingFee             int                       `json:"shipping_fee"`
+	VoucherCode             string                    `json:"voucher_code,omitempty"`
+	Discount                int                       `json:"discount"`
 	TotalPrice              int                       `json:"total_price"`
 	StatusTransaction       string                    `json:"status_transaction"`
+	ReservationStatus       string                    `json:"reservation_status"`
+	ReservedUntil           *time.Time                `json:"reserved_until"`
 	CreatedAt               time.Time                 `json:"created_at"`
 }
 
@@ -20,8 +36,21 @@ type MedicineCheckoutResponse struct {
 	Name                    string                    `json:"name"`
 	Address                 string                    `json:"address"`
 	HP                      string                    `json:"hp"`
+	AddressID               *uint                     `json:"address_id"`
+	Province                string                    `json:"province"`
+	City                    string                    `json:"city"`
+	District                string                    `json:"district"`
+	PostalCode              string                    `json:"postal_code"`
+	AddressNotes            string                    `json:"address_notes"`
 	PaymentMethod           string                    `json:"payment_method"`
 	MedicineDetailsResponse []MedicineDetailsResponse `json:"medicine_details"`
+	Subtotal                int                       `json:"subtotal"`
+	ShippingProvider        string                    `json:"shipping_provider"`
+	ShippingService         string                    `json:"shipping_service"`
+	ShippingWeight          int                       `json:"shipping_weight"`
+	ShippingFee             int                       `json:"shipping_fee"`
+	VoucherCode             string                    `json:"voucher_code,omitempty"`
+	Discount                int                       `json:"discount"`
 	TotalPrice              int                       `json:"total_price"`
 	StatusTransaction       string                    `json:"status_transaction"`
 }
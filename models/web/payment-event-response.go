@@ -0,0 +1,15 @@
+package web
+
+import "time"
+
+type PaymentEventResponse struct {
+	ID                uint       `json:"id"`
+	Provider          string     `json:"provider"`
+	EventID           string     `json:"event_id"`
+	ProviderReference string     `json:"provider_reference"`
+	Status            string     `json:"status"`
+	Deliveries        int        `json:"deliveries"`
+	ProcessedAt       *time.Time `json:"processed_at"`
+	Error             string     `json:"error"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
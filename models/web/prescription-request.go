@@ -1 +1,14 @@
 package web
+
+type PrescriptionRequest struct {
+	Notes               string                `json:"notes" form:"notes" validate:"omitempty,max=1000"`
+	PrescriptionDetails []PrescriptionDetails `json:"prescription_details" form:"prescription_details" validate:"required,min=1,dive"`
+}
+
+type PrescriptionDetails struct {
+	MedicineID uint   `json:"medicine_id" form:"medicine_id" validate:"required"`
+	Quantity   int    `json:"quantity" form:"quantity" validate:"required,min=1"`
+	Dosage     string `json:"dosage" form:"dosage" validate:"required"`
+	Frequency  string `json:"frequency" form:"frequency" validate:"required"`
+	Duration   string `json:"duration" form:"duration" validate:"required"`
+}
@@ -1 +1,23 @@
 package web
+
+import "time"
+
+type PrescriptionResponse struct {
+	ID                  uint                          `json:"id"`
+	TransactionID       uint                          `json:"transaction_id"`
+	DoctorID            uint                          `json:"doctor_id"`
+	UserID              uint                          `json:"user_id"`
+	Notes               string                        `json:"notes"`
+	PrescriptionDetails []PrescriptionDetailsResponse `json:"prescription_details"`
+	CreatedAt           time.Time                     `json:"created_at"`
+}
+
+type PrescriptionDetailsResponse struct {
+	MedicineID uint   `json:"medicine_id"`
+	Name       string `json:"name"`
+	Image      string `json:"image"`
+	Quantity   int    `json:"quantity"`
+	Dosage     string `json:"dosage"`
+	Frequency  string `json:"frequency"`
+	Duration   string `json:"duration"`
+}
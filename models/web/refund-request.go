@@ -0,0 +1,17 @@
+package web
+
+type RefundRequest struct {
+	Reason string `json:"reason" form:"reason" validate:"required,min=3"`
+	Method string `json:"method" form:"method"`
+}
+
+type AdminRefundRequest struct {
+	Amount int    `json:"amount" form:"amount" validate:"min=0"`
+	Reason string `json:"reason" form:"reason" validate:"required,min=3"`
+	Method string `json:"method" form:"method"`
+}
+
+type ProcessRefundRequest struct {
+	Status string `json:"status" form:"status" validate:"required,oneof=completed rejected"`
+	Note   string `json:"note" form:"note"`
+}
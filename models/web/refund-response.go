@@ -0,0 +1,19 @@
+package web
+
+import "time"
+
+type RefundResponse struct {
+	ID            uint       `json:"id"`
+	TransactionID *uint      `json:"transaction_id,omitempty"`
+	CheckoutID    *uint      `json:"checkout_id,omitempty"`
+	ExtensionID   *uint      `json:"roomchat_extension_id,omitempty"`
+	UserID        uint       `json:"user_id"`
+	Amount        int        `json:"amount"`
+	Reason        string     `json:"reason"`
+	Method        string     `json:"method"`
+	Status        string     `json:"status"`
+	RequestedBy   string     `json:"requested_by"`
+	Note          string     `json:"note"`
+	ProcessedAt   *time.Time `json:"processed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package web
+
+type ReorderSuggestionResponse struct {
+	MedicineID        uint     `json:"medicine_id"`
+	Code              string   `json:"code"`
+	Name              string   `json:"name"`
+	Stock             int      `json:"stock"`
+	ReorderThreshold  int      `json:"reorder_threshold"`
+	Sold              int      `json:"sold"`
+	DailySales        float64  `json:"daily_sales"`
+	DaysOfStock       *float64 `json:"days_of_stock"`
+	SuggestedQuantity int      `json:"suggested_quantity"`
+}
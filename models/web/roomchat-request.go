@@ -0,0 +1,23 @@
+package web
+
+type RoomchatEventRequest struct {
+	Type string `json:"type" validate:"required,oneof=typing stop_typing"`
+}
+
+type RoomchatReadRequest struct {
+	MessageID uint `json:"message_id" form:"message_id" validate:"required"`
+}
+
+type RoomchatExtensionRequest struct {
+	Minutes int  `json:"minutes" form:"minutes" validate:"required,min=5,max=60"`
+	Paid    bool `json:"paid" form:"paid"`
+}
+
+type RoomchatExtensionPaymentRequest struct {
+	PaymentMethod       string `json:"payment_method" form:"payment_method" validate:"required"`
+	PaymentConfirmation string `json:"payment_confirmation" form:"payment_confirmation"`
+}
+
+type RoomchatCloseRequest struct {
+	Summary string `json:"summary" form:"summary" validate:"required,min=3"`
+}
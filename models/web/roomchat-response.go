@@ -17,6 +17,7 @@ type RoomchatListResponse struct {
 	Fullname       string     `json:"fullname"`
 	ProfilePicture string     `json:"profile_picture"`
 	LastMessage    string     `json:"last_message"`
+	UnreadCount    int64      `json:"unread_count"`
 	Status         bool       `json:"status"`
 	ExpirationTime *time.Time `json:"expiration_time"`
 	CreatedAt      time.Time  `json:"created_at"`
@@ -29,6 +30,7 @@ type RoomchatUserDetailsResponse struct {
 	CreatedAt      time.Time               `json:"created_at"`
 	Status         bool                    `json:"status"`
 	ExpirationTime *time.Time              `json:"expiration_time"`
+	ClosingSummary string                  `json:"closing_summary"`
 	Messages       []CreateMessageResponse `json:"messages"`
 }
 
@@ -39,5 +41,38 @@ type RoomchatDoctorDetailsResponse struct {
 	CreatedAt      time.Time               `json:"created_at"`
 	Status         bool                    `json:"status"`
 	ExpirationTime *time.Time              `json:"expiration_time"`
+	ClosingSummary string                  `json:"closing_summary"`
 	Messages       []CreateMessageResponse `json:"messages"`
 }
+
+type RoomchatEventResponse struct {
+	Type       string                     `json:"type"`
+	RoomchatID uint                       `json:"roomchat_id"`
+	Role       string                     `json:"role"`
+	SenderID   uint                       `json:"sender_id"`
+	MessageID  uint                       `json:"message_id,omitempty"`
+	Message    *CreateMessageResponse     `json:"message,omitempty"`
+	Extension  *RoomchatExtensionResponse `json:"extension,omitempty"`
+	ExpiresAt  *time.Time                 `json:"expiration_time,omitempty"`
+	CreatedAt  time.Time                  `json:"created_at"`
+}
+
+type RoomchatReadResponse struct {
+	RoomchatID        uint      `json:"roomchat_id"`
+	LastReadMessageID uint      `json:"last_read_message_id"`
+	UnreadCount       int64     `json:"unread_count"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type RoomchatExtensionResponse struct {
+	ID                  uint       `json:"id"`
+	RoomchatID          uint       `json:"roomchat_id"`
+	Minutes             int        `json:"minutes"`
+	Price               int        `json:"price"`
+	PaymentMethod       string     `json:"payment_method"`
+	PaymentProvider     string     `json:"payment_provider,omitempty"`
+	PaymentConfirmation string     `json:"payment_confirmation"`
+	PaymentStatus       string     `json:"payment_status"`
+	AppliedAt           *time.Time `json:"applied_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
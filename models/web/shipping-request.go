@@ -0,0 +1,9 @@
+package web
+
+type ShippingRateRequest struct {
+	AddressID       uint              `json:"address_id" form:"address_id"`
+	Province        string            `json:"province" form:"province" validate:"omitempty,max=100"`
+	City            string            `json:"city" form:"city" validate:"omitempty,max=100"`
+	PostalCode      string            `json:"postal_code" form:"postal_code" validate:"omitempty,numeric,max=10"`
+	MedicineDetails []MedicineDetails `json:"medicine_details" form:"medicine_details" validate:"omitempty,dive"`
+}
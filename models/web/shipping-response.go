@@ -0,0 +1,18 @@
+package web
+
+type ShippingOptionResponse struct {
+	Provider string `json:"provider"`
+	Service  string `json:"service"`
+	Name     string `json:"name"`
+	Fee      int    `json:"fee"`
+	MinDays  int    `json:"min_days"`
+	MaxDays  int    `json:"max_days"`
+}
+
+type ShippingRatesResponse struct {
+	Province   string                   `json:"province"`
+	City       string                   `json:"city"`
+	PostalCode string                   `json:"postal_code"`
+	Weight     int                      `json:"weight"`
+	Options    []ShippingOptionResponse `json:"options"`
+}
@@ -0,0 +1,7 @@
+package web
+
+type StockMovementRequest struct {
+	Type     string `json:"type" form:"type" validate:"required,oneof=restock adjustment expiry"`
+	Quantity int    `json:"quantity" form:"quantity" validate:"required"`
+	Reason   string `json:"reason" form:"reason" validate:"required,min=3"`
+}
@@ -0,0 +1,34 @@
+package web
+
+import "time"
+
+type StockMovementResponse struct {
+	ID            uint      `json:"id"`
+	MedicineID    uint      `json:"medicine_id"`
+	Type          string    `json:"type"`
+	Quantity      int       `json:"quantity"`
+	StockAfter    int       `json:"stock_after"`
+	TransactionID *uint     `json:"medicine_transaction_id,omitempty"`
+	BatchID       *uint     `json:"medicine_batch_id,omitempty"`
+	ActorRole     string    `json:"actor_role"`
+	ActorID       uint      `json:"actor_id"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type StockReconciliationResponse struct {
+	MedicineID     uint       `json:"medicine_id"`
+	Code           string     `json:"code"`
+	Name           string     `json:"name"`
+	Stock          int        `json:"stock"`
+	LedgerStock    int        `json:"ledger_stock"`
+	Difference     int        `json:"difference"`
+	Movements      int64      `json:"movements"`
+	LastMovementAt *time.Time `json:"last_movement_at"`
+	Restocked      int        `json:"restocked"`
+	Sold           int        `json:"sold"`
+	Released       int        `json:"released"`
+	Refunded       int        `json:"refunded"`
+	Adjusted       int        `json:"adjusted"`
+	Expired        int        `json:"expired"`
+}
@@ -0,0 +1,19 @@
+package web
+
+type VoucherRequest struct {
+	Code         string   `json:"code" form:"code" validate:"required,max=50"`
+	Description  string   `json:"description" form:"description"`
+	Scope        string   `json:"scope" form:"scope" validate:"required,oneof=consultation medicine"`
+	DiscountType string   `json:"discount_type" form:"discount_type" validate:"required,oneof=percentage fixed"`
+	Value        int      `json:"value" form:"value" validate:"required,min=1"`
+	MinSpend     int      `json:"min_spend" form:"min_spend" validate:"min=0"`
+	MaxDiscount  int      `json:"max_discount" form:"max_discount" validate:"min=0"`
+	StartsAt     string   `json:"starts_at" form:"starts_at" validate:"required"`
+	EndsAt       string   `json:"ends_at" form:"ends_at" validate:"required"`
+	UsageLimit   int      `json:"usage_limit" form:"usage_limit" validate:"min=0"`
+	PerUserLimit int      `json:"per_user_limit" form:"per_user_limit" validate:"min=0"`
+	IsActive     *bool    `json:"is_active" form:"is_active"`
+	DoctorIDs    []uint   `json:"doctor_ids" form:"doctor_ids"`
+	Specialists  []string `json:"specialists" form:"specialists" validate:"dive,required,max=100"`
+	CategoryIDs  []uint   `json:"category_ids" form:"category_ids"`
+}
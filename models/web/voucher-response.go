@@ -0,0 +1,24 @@
+package web
+
+import "time"
+
+type VoucherResponse struct {
+	ID           uint      `json:"id"`
+	Code         string    `json:"code"`
+	Description  string    `json:"description"`
+	Scope        string    `json:"scope"`
+	DiscountType string    `json:"discount_type"`
+	Value        int       `json:"value"`
+	MinSpend     int       `json:"min_spend"`
+	MaxDiscount  int       `json:"max_discount"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	UsageLimit   int       `json:"usage_limit"`
+	PerUserLimit int       `json:"per_user_limit"`
+	Used         int       `json:"used"`
+	IsActive     bool      `json:"is_active"`
+	DoctorIDs    []uint    `json:"doctor_ids"`
+	Specialists  []string  `json:"specialists"`
+	CategoryIDs  []uint    `json:"category_ids"`
+	CreatedAt    time.Time `json:"created_at"`
+}
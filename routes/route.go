@@ -12,6 +12,7 @@ func SetupRoutes(e *echo.Echo) {
 	AdminJWT := middlewares.AdminRoleAuth
 	UserJWT := middlewares.UserIDRoleAuth
 	DoctorJWT := middlewares.DoctorIDRoleAuth
+	SocketJWT := middlewares.SocketToken
 
 	gAdmins := e.Group("/api/v1/admins")
 	gAdmins.POST("/login", controllers.LoginAdminController)
@@ -28,18 +29,51 @@ func SetupRoutes(e *echo.Echo) {
 	gAdmins.PUT("/doctor-payments/:transaction_id", controllers.UpdatePaymentStatusByAdminController, AdminJWT)
 	gAdmins.GET("/doctor-payment/:user_id", controllers.GetUserPaymentsByAdminsController, AdminJWT)
 	gAdmins.GET("/doctor-payments", controllers.GetAllDoctorsPaymentsByAdminsController, AdminJWT)
+	gAdmins.GET("/doctor-payments/:transaction_id/history", controllers.GetDoctorTransactionHistoryByAdminController, AdminJWT)
+	gAdmins.GET("/doctor-reviews", controllers.GetAllDoctorReviewsByAdminController, AdminJWT)
+	gAdmins.PUT("/doctor-reviews/:review_id", controllers.ModerateDoctorReviewByAdminController, AdminJWT)
+	gAdmins.GET("/roomchat-extensions", controllers.GetAllRoomchatExtensionsByAdminController, AdminJWT)
+	gAdmins.PUT("/roomchat-extensions/:extension_id", controllers.UpdateRoomchatExtensionPaymentByAdminController, AdminJWT)
 	gAdmins.GET("/doctor-payment", controllers.GetDoctorTransactionByIDController, AdminJWT)
+	gAdmins.POST("/doctor-payments/:transaction_id/refunds", controllers.CreateDoctorTransactionRefundByAdminController, AdminJWT)
+	gAdmins.GET("/refunds", controllers.GetAllRefundsByAdminController, AdminJWT)
+	gAdmins.PUT("/refunds/:refund_id", controllers.ProcessRefundByAdminController, AdminJWT)
+	gAdmins.GET("/payment-events", controllers.GetAllPaymentEventsByAdminController, AdminJWT)
 	gAdmins.POST("/medicines", controllers.CreateMedicineController, AdminJWT)
 	gAdmins.GET("/medicines", controllers.GetMedicineAdminController, AdminJWT)
+	gAdmins.GET("/medicines/export", controllers.ExportMedicinesByAdminController, AdminJWT)
+	gAdmins.POST("/medicines/import", controllers.ImportMedicinesByAdminController, AdminJWT)
 	gAdmins.GET("/medicines/:medicine_id", controllers.GetMedicineAdminByIDController, AdminJWT)
 	gAdmins.PUT("/medicines/:medicine_id", controllers.UpdateMedicineController, AdminJWT)
 	gAdmins.DELETE("/medicines/:medicine_id", controllers.DeleteMedicineController, AdminJWT)
 	gAdmins.GET("/medicines/:medicine_id/image", controllers.GetImageMedicineController, AdminJWT)
 	gAdmins.PUT("/medicines/:medicine_id/image", controllers.UpdateImageMedicineController, AdminJWT)
 	gAdmins.DELETE("/medicines/:medicine_id/image", controllers.DeleteImageMedicineController, AdminJWT)
+	gAdmins.GET("/medicines/:medicine_id/stock-movements", controllers.GetStockMovementsByAdminController, AdminJWT)
+	gAdmins.POST("/medicines/:medicine_id/stock-movements", controllers.CreateStockMovementByAdminController, AdminJWT)
+	gAdmins.GET("/medicines/:medicine_id/batches", controllers.GetMedicineBatchesByAdminController, AdminJWT)
+	gAdmins.POST("/medicines/:medicine_id/batches", controllers.CreateMedicineBatchByAdminController, AdminJWT)
+	gAdmins.GET("/medicine-batches/expiring", controllers.GetExpiringMedicineBatchesByAdminController, AdminJWT)
+	gAdmins.POST("/medicine-categories", controllers.CreateMedicineCategoryByAdminController, AdminJWT)
+	gAdmins.GET("/medicine-categories", controllers.GetMedicineCategoriesController, AdminJWT)
+	gAdmins.PUT("/medicine-categories/:category_id", controllers.UpdateMedicineCategoryByAdminController, AdminJWT)
+	gAdmins.DELETE("/medicine-categories/:category_id", controllers.DeleteMedicineCategoryByAdminController, AdminJWT)
+	gAdmins.POST("/medicine-types", controllers.CreateMedicineTypeByAdminController, AdminJWT)
+	gAdmins.GET("/medicine-types", controllers.GetMedicineTypesController, AdminJWT)
+	gAdmins.PUT("/medicine-types/:type_id", controllers.UpdateMedicineTypeByAdminController, AdminJWT)
+	gAdmins.DELETE("/medicine-types/:type_id", controllers.DeleteMedicineTypeByAdminController, AdminJWT)
+	gAdmins.POST("/vouchers", controllers.CreateVoucherByAdminController, AdminJWT)
+	gAdmins.GET("/vouchers", controllers.GetVouchersByAdminController, AdminJWT)
+	gAdmins.GET("/vouchers/:voucher_id", controllers.GetVoucherByAdminController, AdminJWT)
+	gAdmins.PUT("/vouchers/:voucher_id", controllers.UpdateVoucherByAdminController, AdminJWT)
+	gAdmins.DELETE("/vouchers/:voucher_id", controllers.DeleteVoucherByAdminController, AdminJWT)
+	gAdmins.GET("/stock-reconciliation", controllers.GetStockReconciliationByAdminController, AdminJWT)
+	gAdmins.GET("/reorder-suggestions", controllers.GetReorderSuggestionsByAdminController, AdminJWT)
 	gAdmins.PUT("/medicines-payments/checkout/:checkout_id", controllers.UpdateCheckoutController, AdminJWT)
 	gAdmins.GET("/medicines-payments/checkout", controllers.GetAdminCheckoutController, AdminJWT)
 	gAdmins.GET("/medicines-payments/checkout/:checkout_id", controllers.GetAdminCheckoutByIDController, AdminJWT)
+	gAdmins.PUT("/medicines-payments/checkout/:checkout_id/fulfilment", controllers.UpdateCheckoutFulfilmentByAdminController, AdminJWT)
+	gAdmins.POST("/medicines-payments/checkout/:checkout_id/refunds", controllers.CreateCheckoutRefundByAdminController, AdminJWT)
 	gAdmins.POST("/get-otp", controllers.GetOTPForPasswordAdmin)
 	gAdmins.POST("/verify-otp", controllers.VerifyOTPAdmin)
 	gAdmins.POST("/change-password", controllers.ResetPasswordAdmin)
@@ -53,18 +87,52 @@ func SetupRoutes(e *echo.Echo) {
 	gUsers.DELETE("", controllers.DeleteUserController, UserJWT)
 	gUsers.GET("/medicines", controllers.GetMedicineUserController)
 	gUsers.GET("/medicines/:medicine_id", controllers.GetMedicineUserByIDController)
+	gUsers.GET("/medicine-categories", controllers.GetMedicineCategoriesController)
+	gUsers.GET("/medicine-types", controllers.GetMedicineTypesController)
 	gUsers.GET("/doctors/available", controllers.GetAvailableDoctor)
 	gUsers.GET("/doctors", controllers.GetSpecializeDoctor)
 	gUsers.GET("/doctors/:doctor_id", controllers.GetDoctorByIDController)
+	gUsers.GET("/doctors/:doctor_id/slots", controllers.GetDoctorSlotsController)
+	gUsers.GET("/doctors/:doctor_id/reviews", controllers.GetDoctorReviewsController)
 	gUsers.GET("/articles", controllers.GetAllArticles)
 	gUsers.GET("/articles/:article_id", controllers.GetArticleByID)
 	gUsers.GET("/article", controllers.GetAllArticlesByTitle)
 	gUsers.POST("/doctor-payments/:doctor_id", controllers.CreateDoctorTransactionController, UserJWT)
 	gUsers.GET("/doctor-payments", controllers.GetAllDoctorTransactionsController, UserJWT)
 	gUsers.GET("/doctor-payments/:transaction_id", controllers.GetDoctorTransactionController, UserJWT)
+	gUsers.GET("/doctor-payments/:transaction_id/payment-status", controllers.CheckDoctorTransactionPaymentController, UserJWT)
+	gUsers.POST("/doctor-payments/:transaction_id/refunds", controllers.RequestDoctorTransactionRefundController, UserJWT)
+	gUsers.GET("/doctor-payments/:transaction_id/history", controllers.GetDoctorTransactionHistoryController, UserJWT)
+	gUsers.GET("/doctor-payments/:transaction_id/review", controllers.GetDoctorReviewController, UserJWT)
+	gUsers.POST("/doctor-payments/:transaction_id/review", controllers.CreateDoctorReviewController, UserJWT)
+	gUsers.PUT("/doctor-payments/:transaction_id/review", controllers.UpdateDoctorReviewController, UserJWT)
+	gUsers.GET("/consultations", controllers.GetConsultationHistoryController, UserJWT)
+	gUsers.GET("/consultations/:transaction_id/transcript", controllers.ExportConsultationTranscriptController, UserJWT)
+	gUsers.GET("/chats", controllers.GetAllUserRoomchatController, UserJWT)
 	gUsers.POST("/chats/:transaction_id", controllers.CreateRoomchatController, UserJWT)
 	gUsers.GET("/chats/:roomchat_id", controllers.GetUserRoomchatController, UserJWT)
 	gUsers.POST("/chats/:roomchat_id/message", controllers.CreateComplaintMessageController, UserJWT)
+	gUsers.GET("/chats/:roomchat_id/ws", controllers.UserRoomchatSocketController, SocketJWT, UserJWT)
+	gUsers.GET("/chats/:roomchat_id/messages", controllers.GetUserRoomchatMessagesController, UserJWT)
+	gUsers.PUT("/chats/:roomchat_id/read", controllers.UserReadRoomchatController, UserJWT)
+	gUsers.GET("/chats/:roomchat_id/extensions", controllers.GetUserRoomchatExtensionsController, UserJWT)
+	gUsers.PUT("/chats/:roomchat_id/extensions/:extension_id", controllers.PayRoomchatExtensionController, UserJWT)
+	gUsers.GET("/prescriptions", controllers.GetUserPrescriptionsController, UserJWT)
+	gUsers.GET("/prescriptions/:prescription_id", controllers.GetUserPrescriptionByIDController, UserJWT)
+	gUsers.POST("/prescriptions/:prescription_id/medicines-payments", controllers.CreatePrescriptionMedicineTransactionController, UserJWT)
+	gUsers.GET("/doctor-payments/:transaction_id/prescription", controllers.GetUserPrescriptionByTransactionController, UserJWT)
+	gUsers.GET("/addresses", controllers.GetAddressesController, UserJWT)
+	gUsers.POST("/addresses", controllers.CreateAddressController, UserJWT)
+	gUsers.GET("/addresses/:address_id", controllers.GetAddressByIDController, UserJWT)
+	gUsers.PUT("/addresses/:address_id", controllers.UpdateAddressController, UserJWT)
+	gUsers.DELETE("/addresses/:address_id", controllers.DeleteAddressController, UserJWT)
+	gUsers.POST("/shipping-rates", controllers.GetShippingRatesController, UserJWT)
+	gUsers.GET("/cart", controllers.GetCartController, UserJWT)
+	gUsers.DELETE("/cart", controllers.ClearCartController, UserJWT)
+	gUsers.POST("/cart/items", controllers.AddCartItemController, UserJWT)
+	gUsers.PUT("/cart/items/:item_id", controllers.UpdateCartItemController, UserJWT)
+	gUsers.DELETE("/cart/items/:item_id", controllers.DeleteCartItemController, UserJWT)
+	gUsers.POST("/cart/medicines-payments", controllers.CreateCartMedicineTransactionController, UserJWT)
 	gUsers.POST("/medicines-payments", controllers.CreateMedicineTransaction, UserJWT)
 	gUsers.GET("/medicines-payments", controllers.GetMedicineTransactionController, UserJWT)
 	gUsers.GET("/medicines-payments/:medtrans_id", controllers.GetMedicineTransactionByIDController, UserJWT)
@@ -72,6 +140,9 @@ func SetupRoutes(e *echo.Echo) {
 	gUsers.POST("/medicines-payments/checkout", controllers.CreateCheckoutController, UserJWT)
 	gUsers.GET("/medicines-payments/checkout", controllers.GetUserCheckoutController, UserJWT)
 	gUsers.GET("/medicines-payments/checkout/:checkout_id", controllers.GetUserCheckoutByIDController, UserJWT)
+	gUsers.GET("/medicines-payments/checkout/:checkout_id/payment-status", controllers.CheckCheckoutPaymentController, UserJWT)
+	gUsers.POST("/medicines-payments/checkout/:checkout_id/refunds", controllers.RequestCheckoutRefundController, UserJWT)
+	gUsers.GET("/refunds", controllers.GetUserRefundsController, UserJWT)
 	gUsers.POST("/get-otp", controllers.GetOTPForPasswordUser)
 	gUsers.POST("/verify-otp", controllers.VerifyOTPUser)
 	gUsers.POST("/change-password", controllers.ResetPasswordUser)
@@ -84,6 +155,14 @@ func SetupRoutes(e *echo.Echo) {
 	gDoctors.PUT("/profile", controllers.UpdateDoctorController, DoctorJWT)
 	gDoctors.PUT("/status", controllers.ChangeDoctorStatusController, DoctorJWT)
 	gDoctors.GET("/status", controllers.GetDoctorStatusController, DoctorJWT)
+	gDoctors.GET("/schedules", controllers.GetDoctorSchedulesController, DoctorJWT)
+	gDoctors.POST("/schedules", controllers.CreateDoctorScheduleController, DoctorJWT)
+	gDoctors.PUT("/schedules/:schedule_id", controllers.UpdateDoctorScheduleController, DoctorJWT)
+	gDoctors.DELETE("/schedules/:schedule_id", controllers.DeleteDoctorScheduleController, DoctorJWT)
+	gDoctors.GET("/schedule-exceptions", controllers.GetDoctorScheduleExceptionsController, DoctorJWT)
+	gDoctors.POST("/schedule-exceptions", controllers.CreateDoctorScheduleExceptionController, DoctorJWT)
+	gDoctors.DELETE("/schedule-exceptions/:exception_id", controllers.DeleteDoctorScheduleExceptionController, DoctorJWT)
+	gDoctors.GET("/slots", controllers.GetDoctorOwnSlotsController, DoctorJWT)
 	gDoctors.DELETE("", controllers.DeleteDoctorController, DoctorJWT)
 	gDoctors.GET("/articles", controllers.DoctorGetAllArticles, DoctorJWT)
 	gDoctors.GET("/articles/:article_id", controllers.DoctorGetArticleByID, DoctorJWT)
@@ -94,6 +173,14 @@ func SetupRoutes(e *echo.Echo) {
 	gDoctors.GET("/chats", controllers.GetAllDoctorRoomchatController, DoctorJWT)
 	gDoctors.GET("/chats/:roomchat_id", controllers.GetDoctorRoomchatController, DoctorJWT)
 	gDoctors.POST("/chats/:roomchat_id/message", controllers.CreateAdviceMessageController, DoctorJWT)
+	gDoctors.GET("/chats/:roomchat_id/ws", controllers.DoctorRoomchatSocketController, SocketJWT, DoctorJWT)
+	gDoctors.GET("/chats/:roomchat_id/messages", controllers.GetDoctorRoomchatMessagesController, DoctorJWT)
+	gDoctors.PUT("/chats/:roomchat_id/read", controllers.DoctorReadRoomchatController, DoctorJWT)
+	gDoctors.POST("/chats/:roomchat_id/extensions", controllers.CreateRoomchatExtensionController, DoctorJWT)
+	gDoctors.PUT("/chats/:roomchat_id/close", controllers.CloseRoomchatController, DoctorJWT)
+	gDoctors.POST("/chats/:roomchat_id/prescription", controllers.CreatePrescriptionController, DoctorJWT)
+	gDoctors.GET("/prescriptions", controllers.GetDoctorPrescriptionsController, DoctorJWT)
+	gDoctors.GET("/prescriptions/:prescription_id", controllers.GetDoctorPrescriptionByIDController, DoctorJWT)
 	gDoctors.GET("/manage-user", controllers.GetManageUserController, DoctorJWT)
 	gDoctors.PUT("/manage-user/:transaction_id", controllers.UpdateManageUserController, DoctorJWT)
 	gDoctors.POST("/get-otp", controllers.GetOTPForPasswordDoctor)
@@ -102,5 +189,6 @@ func SetupRoutes(e *echo.Echo) {
 	gDoctors.GET("/medicines", controllers.GetMedicineUserController)
 
 	e.POST("/chatbot", controllers.Chatbot)
+	e.POST("/api/v1/payments/:provider/callback", controllers.PaymentCallbackController)
 
 }
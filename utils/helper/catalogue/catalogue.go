@@ -0,0 +1,200 @@
+package catalogue
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Columns of the catalogue csv, in export order
+var Columns = []string{"code", "name", "merk", "category", "type", "stock", "price", "reorder_threshold", "weight", "details", "image"}
+
+// requiredColumns have to be present in an imported header, the others may be left out
+var requiredColumns = []string{"code", "name", "merk", "category", "type", "price", "details"}
+
+// MaxRows caps the data rows of one import
+const MaxRows = 5000
+
+var (
+	ErrEmptyFile   = errors.New("csv file is empty")
+	ErrTooManyRows = fmt.Errorf("csv file has more than %d rows", MaxRows)
+)
+
+// Row is one data row of an imported csv with the problems found while reading it
+type Row struct {
+	Line     int
+	Medicine web.MedicineImportRow
+	Errors   []string
+}
+
+// Read parses an imported catalogue. Problems with single rows are kept on the row, only an unreadable
+// file or header fails the whole read.
+func Read(r io.Reader) ([]Row, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrEmptyFile
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	positions := map[string]int{}
+	for i, column := range header {
+		positions[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+
+	var missing []string
+	for _, column := range requiredColumns {
+		if _, ok := positions[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("csv header is missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	var rows []Row
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Line: line, Errors: []string{parseErr.Err.Error()}})
+				continue
+			}
+			return nil, err
+		}
+
+		if blank(record) {
+			continue
+		}
+
+		if len(rows) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+
+		rows = append(rows, parseRow(line, record, positions))
+	}
+
+	return rows, nil
+}
+
+// Write exports medicines in the column order of Columns
+func Write(w io.Writer, medicines []schema.Medicine) error {
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(Columns); err != nil {
+		return err
+	}
+
+	for _, medicine := range medicines {
+		if err := writer.Write([]string{
+			cell(medicine.Code),
+			cell(medicine.Name),
+			cell(medicine.Merk),
+			cell(medicine.Category),
+			cell(medicine.Type),
+			strconv.Itoa(medicine.Stock),
+			strconv.Itoa(medicine.Price),
+			strconv.Itoa(medicine.ReorderThreshold),
+			strconv.Itoa(medicine.Weight),
+			cell(medicine.Details),
+			cell(medicine.Image),
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formulaPrefixes start a cell that spreadsheet applications would run as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// cell quotes a text value that would be read as a formula, Read takes the quote off again
+func cell(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// uncell takes off the quote cell put in front of a formula-like value
+func uncell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+func parseRow(line int, record []string, positions map[string]int) Row {
+
+	row := Row{Line: line}
+
+	value := func(column string) string {
+		i, ok := positions[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	optionalInt := func(column string) *int {
+		raw := value(column)
+		if raw == "" {
+			return nil
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s '%s' is not a whole number", column, raw))
+			return nil
+		}
+		return &parsed
+	}
+
+	row.Medicine = web.MedicineImportRow{
+		Code:     uncell(value("code")),
+		Name:     uncell(value("name")),
+		Merk:     uncell(value("merk")),
+		Category: uncell(value("category")),
+		Type:     uncell(value("type")),
+		Details:  uncell(value("details")),
+		Image:    uncell(value("image")),
+	}
+
+	row.Medicine.Stock = optionalInt("stock")
+	row.Medicine.ReorderThreshold = optionalInt("reorder_threshold")
+	row.Medicine.Weight = optionalInt("weight")
+
+	if price := optionalInt("price"); price != nil {
+		row.Medicine.Price = *price
+	} else if value("price") == "" {
+		row.Errors = append(row.Errors, "price is required")
+	}
+
+	return row
+}
+
+func blank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
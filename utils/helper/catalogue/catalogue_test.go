@@ -0,0 +1,48 @@
+package catalogue
+
+import (
+	"bytes"
+	"encoding/csv"
+	"healthcare/models/schema"
+	"testing"
+)
+
+func TestWriteQuotesFormulas(t *testing.T) {
+	medicines := []schema.Medicine{{
+		Code:     "=HYPERLINK(\"http://example.com\")",
+		Name:     "+Paracetamol",
+		Merk:     "-Merk",
+		Category: "@Category",
+		Type:     "Tablet",
+		Stock:    -2,
+		Price:    5000,
+		Details:  "plain details",
+	}}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, medicines); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(buffer.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"'=HYPERLINK(\"http://example.com\")", "'+Paracetamol", "'-Merk", "'@Category", "Tablet", "-2", "5000", "0", "0", "plain details", ""}
+	for i, value := range want {
+		if records[1][i] != value {
+			t.Errorf("column %s: got %q, want %q", Columns[i], records[1][i], value)
+		}
+	}
+
+	rows, err := Read(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := rows[0].Medicine
+	if row.Code != medicines[0].Code || row.Name != medicines[0].Name || row.Merk != medicines[0].Merk || row.Category != medicines[0].Category {
+		t.Errorf("got %+v after reading the export back, want the values of %+v", row, medicines[0])
+	}
+}
@@ -0,0 +1,152 @@
+package consultation
+
+import (
+	"errors"
+	"healthcare/models/schema"
+	"healthcare/utils/helper/hub"
+	"healthcare/utils/helper/lifecycle"
+	"healthcare/utils/helper/voucher"
+	"healthcare/utils/response"
+
+	"gorm.io/gorm"
+)
+
+// Transition moves a doctor transaction to a new consultation status through the lifecycle inside tx and
+// applies what the new status means for the rest of the service. A cancelled or refunded consultation gives
+// its voucher use back, and a consultation that ends lapses the pending extension offers of its roomchat.
+// Callers tell the sockets of an ended consultation with Disconnect once tx is committed.
+func Transition(tx *gorm.DB, transaction *schema.DoctorTransaction, to string, actorRole string, actorID uint, note string) error {
+
+	if err := lifecycle.Transition(tx, transaction, to, actorRole, actorID, note); err != nil {
+		return err
+	}
+
+	switch to {
+	case lifecycle.StatusCancelled, lifecycle.StatusRefunded:
+		if err := voucher.ReleaseDoctorTransaction(tx, transaction.ID); err != nil {
+			return err
+		}
+	}
+
+	if !Ended(to) {
+		return nil
+	}
+
+	var roomchat schema.Roomchat
+	err := tx.Select("id").First(&roomchat, "transaction_id = ?", transaction.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return LapseExtensions(tx, roomchat.ID, transaction.UserID)
+}
+
+// Ended reports whether a consultation status closes the roomchat of the consultation
+func Ended(status string) bool {
+	return status == lifecycle.StatusClosed || status == lifecycle.StatusReferred || status == lifecycle.StatusRefunded
+}
+
+// CloseRoomchat ends the consultation of an open roomchat inside tx, through Transition when the transaction
+// is in consultation, and lapses its pending extension offers. It reports false when the roomchat had already
+// been closed.
+func CloseRoomchat(tx *gorm.DB, transaction *schema.DoctorTransaction, roomchatID uint, actorRole string, actorID uint, note string) (bool, error) {
+
+	if transaction.ConsultationStatus == lifecycle.StatusInConsultation {
+		err := Transition(tx, transaction, lifecycle.StatusClosed, actorRole, actorID, note)
+		if errors.Is(err, lifecycle.ErrInvalidTransition) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	result := tx.Model(&schema.Roomchat{}).Where("id = ? AND status = ?", roomchatID, true).Update("status", false)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	return true, LapseExtensions(tx, roomchatID, transaction.UserID)
+}
+
+// Disconnect tells the sockets of a closed roomchat it was closed and then drops them
+func Disconnect(roomchatID uint, actorRole string, actorID uint) {
+	hub.Default.Publish(roomchatID, response.ConvertToRoomchatEventResponse("closed", roomchatID, actorRole, actorID))
+	hub.Default.CloseRoom(roomchatID)
+}
+
+// DisconnectTransaction disconnects the roomchat of a doctor transaction whose consultation ended, if it has one
+func DisconnectTransaction(db *gorm.DB, transaction *schema.DoctorTransaction, actorRole string, actorID uint) {
+	if !Ended(transaction.ConsultationStatus) {
+		return
+	}
+
+	var roomchat schema.Roomchat
+	if err := db.Select("id").First(&roomchat, "transaction_id = ?", transaction.ID).Error; err != nil {
+		return
+	}
+
+	Disconnect(roomchat.ID, actorRole, actorID)
+}
+
+// LapseExtension cancels a pending extension of a roomchat that closed before it was applied. An extension the
+// patient already paid for gets a refund opened by the system, so the payment is not lost with the roomchat.
+func LapseExtension(tx *gorm.DB, extension *schema.RoomchatExtension, userID uint, paid bool) error {
+
+	result := tx.Model(&schema.RoomchatExtension{}).
+		Where("id = ? AND payment_status = ?", extension.ID, "pending").
+		Update("payment_status", "cancelled")
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	extension.PaymentStatus = "cancelled"
+
+	if !paid {
+		return nil
+	}
+	return RefundExtension(tx, extension, userID)
+}
+
+// LapseExtensions cancels every pending extension of a closed roomchat, see LapseExtension. A pending extension
+// counts as paid once the patient uploaded a proof of transfer, provider charges are only paid when they succeed.
+func LapseExtensions(tx *gorm.DB, roomchatID uint, userID uint) error {
+
+	var extensions []schema.RoomchatExtension
+	if err := tx.Where("roomchat_id = ? AND payment_status = ?", roomchatID, "pending").Find(&extensions).Error; err != nil {
+		return err
+	}
+
+	for i := range extensions {
+		if err := LapseExtension(tx, &extensions[i], userID, extensions[i].PaymentConfirmation != ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefundExtension opens a system refund of a paid extension that was never applied, once per extension
+func RefundExtension(tx *gorm.DB, extension *schema.RoomchatExtension, userID uint) error {
+
+	if extension.Price == 0 {
+		return nil
+	}
+
+	var refunds int64
+	if err := tx.Model(&schema.Refund{}).Where("roomchat_extension_id = ?", extension.ID).Count(&refunds).Error; err != nil {
+		return err
+	}
+	if refunds > 0 {
+		return nil
+	}
+
+	refund := schema.Refund{
+		RoomchatExtensionID: &extension.ID,
+		UserID:              userID,
+		Amount:              extension.Price,
+		Reason:              "roomchat closed before the extension was applied",
+		Method:              extension.PaymentMethod,
+		RequestedBy:         lifecycle.ActorSystem,
+	}
+	return tx.Create(&refund).Error
+}
@@ -0,0 +1,13 @@
+package helper
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// IsDuplicateKey reports whether a database error comes from a unique index rejecting a row
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
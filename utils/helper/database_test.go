@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsDuplicateKey(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'idx_doctor_review'"}
+
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"duplicate entry", duplicate, true},
+		{"wrapped duplicate entry", fmt.Errorf("create review: %w", duplicate), true},
+		{"other mysql error", &mysql.MySQLError{Number: 1452, Message: "foreign key constraint fails"}, false},
+		{"other error", errors.New("connection refused"), false},
+		{"no error", nil, false},
+	}
+
+	for _, tc := range cases {
+		if got := IsDuplicateKey(tc.err); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
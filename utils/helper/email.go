@@ -82,6 +82,14 @@ func SendNotificationEmail(to, fullname, notificationType, userType, userEmail,
 		case "complaints":
 			subject = "Healthify Notification"
 			body = "Hello, " + fullname + "! You have a new consultation request that requires immediate attention. Please review and attend to it promptly."
+
+		case "closed":
+			subject = "Healthify Notification"
+			body = fmt.Sprintf("Hello, %s! Your doctor has ended the consultation in room %d. Open the app to read the closing summary.", fullname, roomNumber)
+
+		case "expired":
+			subject = "Healthify Notification"
+			body = fmt.Sprintf("Hello, %s! The consultation in room %d has ended because its time is up. You can still read the conversation in the app.", fullname, roomNumber)
 		
 		
 		default:
@@ -0,0 +1,144 @@
+package fulfilment
+
+import (
+	"errors"
+	"fmt"
+	"healthcare/models/schema"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Fulfilment statuses of a paid checkout, a paid checkout without one is waiting to be packed
+const (
+	StatusPacked    = "packed"
+	StatusShipped   = "shipped"
+	StatusDelivered = "delivered"
+	StatusReturned  = "returned"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid fulfilment status transition")
+	ErrNotPaid           = errors.New("only a paid checkout can be fulfilled")
+	ErrTrackingRequired  = errors.New("courier and tracking number are required to ship")
+)
+
+// transitions lists the statuses each status may move to, the empty status is a paid checkout not packed yet
+var transitions = map[string][]string{
+	"":              {StatusPacked},
+	StatusPacked:    {StatusShipped},
+	StatusShipped:   {StatusDelivered, StatusReturned},
+	StatusDelivered: {StatusReturned},
+}
+
+func CanTransition(from, to string) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Shipment is what an admin sends along with a fulfilment status, the courier and tracking number
+// are kept from earlier steps when left empty
+type Shipment struct {
+	Courier        string
+	TrackingNumber string
+	Note           string
+}
+
+// Transition moves a paid checkout to a new fulfilment status inside tx and appends the change to
+// its tracking timeline. Moving to the current status is a no-op.
+func Transition(tx *gorm.DB, checkout *schema.Checkout, to string, shipment Shipment, actorRole string, actorID uint) error {
+
+	if checkout.PaymentStatus != "success" {
+		return ErrNotPaid
+	}
+
+	from := checkout.FulfilmentStatus
+	if from == to {
+		return nil
+	}
+
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w from '%s' to '%s'", ErrInvalidTransition, statusName(from), to)
+	}
+
+	courier := checkout.Courier
+	if shipment.Courier != "" {
+		courier = shipment.Courier
+	}
+	trackingNumber := checkout.TrackingNumber
+	if shipment.TrackingNumber != "" {
+		trackingNumber = shipment.TrackingNumber
+	}
+
+	if to == StatusShipped && (courier == "" || trackingNumber == "") {
+		return ErrTrackingRequired
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"fulfilment_status": to,
+		"courier":           courier,
+		"tracking_number":   trackingNumber,
+	}
+
+	switch to {
+	case StatusShipped:
+		updates["shipped_at"] = now
+	case StatusDelivered:
+		updates["delivered_at"] = now
+	}
+
+	// the status guard makes concurrent changes of the same checkout fail instead of overwrite each other
+	query := tx.Model(&schema.Checkout{}).Where("id = ?", checkout.ID)
+	if from == "" {
+		query = query.Where("fulfilment_status IS NULL")
+	} else {
+		query = query.Where("fulfilment_status = ?", from)
+	}
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w from '%s' to '%s', status was changed by another request", ErrInvalidTransition, statusName(from), to)
+	}
+
+	event := schema.FulfilmentEvent{
+		CheckoutID:     checkout.ID,
+		FromStatus:     from,
+		ToStatus:       to,
+		Courier:        courier,
+		TrackingNumber: trackingNumber,
+		ActorRole:      actorRole,
+		ActorID:        actorID,
+		Note:           shipment.Note,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return err
+	}
+
+	checkout.FulfilmentStatus = to
+	checkout.Courier = courier
+	checkout.TrackingNumber = trackingNumber
+	switch to {
+	case StatusShipped:
+		checkout.ShippedAt = &now
+	case StatusDelivered:
+		checkout.DeliveredAt = &now
+	}
+	checkout.FulfilmentEvents = append(checkout.FulfilmentEvents, event)
+
+	return nil
+}
+
+func statusName(status string) string {
+	if status == "" {
+		return "awaiting fulfilment"
+	}
+	return status
+}
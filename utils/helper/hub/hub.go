@@ -0,0 +1,105 @@
+package hub
+
+import "sync"
+
+// size of the outgoing buffer of every subscriber, events are dropped for
+// subscribers that can not keep up instead of blocking the publisher
+const bufferSize = 32
+
+type Client struct {
+	RoomchatID uint
+	Role       string
+	ID         uint
+	Send       chan interface{}
+}
+
+// Hub keeps the subscribers of every roomchat in memory and fans out events to them
+type Hub struct {
+	mu    sync.RWMutex
+	rooms map[uint]map[*Client]struct{}
+}
+
+var Default = New()
+
+func New() *Hub {
+	return &Hub{
+		rooms: make(map[uint]map[*Client]struct{}),
+	}
+}
+
+func (h *Hub) Subscribe(roomchatID uint, role string, id uint) *Client {
+	client := &Client{
+		RoomchatID: roomchatID,
+		Role:       role,
+		ID:         id,
+		Send:       make(chan interface{}, bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.rooms[roomchatID] == nil {
+		h.rooms[roomchatID] = make(map[*Client]struct{})
+	}
+	h.rooms[roomchatID][client] = struct{}{}
+
+	return client
+}
+
+func (h *Hub) Unsubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clients, ok := h.rooms[client.RoomchatID]
+	if !ok {
+		return
+	}
+
+	if _, ok := clients[client]; !ok {
+		return
+	}
+
+	delete(clients, client)
+	close(client.Send)
+
+	if len(clients) == 0 {
+		delete(h.rooms, client.RoomchatID)
+	}
+}
+
+// CloseRoom unsubscribes every subscriber of a roomchat, their closed Send channels end the open connections
+func (h *Hub) CloseRoom(roomchatID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.rooms[roomchatID] {
+		close(client.Send)
+	}
+	delete(h.rooms, roomchatID)
+}
+
+func (h *Hub) Publish(roomchatID uint, event interface{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.rooms[roomchatID] {
+		select {
+		case client.Send <- event:
+		default:
+		}
+	}
+}
+
+// Online reports whether the given participant has at least one open connection to the roomchat
+func (h *Hub) Online(roomchatID uint, role string, id uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.rooms[roomchatID] {
+		if client.Role == role && client.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package hub
+
+import "testing"
+
+func TestSubscribePublish(t *testing.T) {
+	h := New()
+
+	user := h.Subscribe(1, "user", 10)
+	doctor := h.Subscribe(1, "doctor", 20)
+	other := h.Subscribe(2, "user", 30)
+
+	h.Publish(1, "typing")
+
+	for _, client := range []*Client{user, doctor} {
+		select {
+		case event := <-client.Send:
+			if event != "typing" {
+				t.Errorf("got event %v, want typing", event)
+			}
+		default:
+			t.Errorf("%s %d did not receive the event", client.Role, client.ID)
+		}
+	}
+
+	select {
+	case event := <-other.Send:
+		t.Errorf("subscriber of another roomchat received %v", event)
+	default:
+	}
+
+	if !h.Online(1, "doctor", 20) {
+		t.Error("doctor should be online")
+	}
+	if h.Online(1, "doctor", 30) {
+		t.Error("unknown doctor should not be online")
+	}
+}
+
+func TestPublishDropsWhenBufferFull(t *testing.T) {
+	h := New()
+	client := h.Subscribe(1, "user", 10)
+
+	for i := 0; i < bufferSize+5; i++ {
+		h.Publish(1, i)
+	}
+
+	if len(client.Send) != bufferSize {
+		t.Errorf("got %d buffered events, want %d", len(client.Send), bufferSize)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	h := New()
+
+	user := h.Subscribe(1, "user", 10)
+	doctor := h.Subscribe(1, "doctor", 20)
+
+	h.Unsubscribe(user)
+
+	if _, ok := <-user.Send; ok {
+		t.Error("send channel of an unsubscribed client should be closed")
+	}
+	if h.Online(1, "user", 10) {
+		t.Error("unsubscribed user should be offline")
+	}
+
+	// unsubscribing twice must not close the channel again
+	h.Unsubscribe(user)
+
+	h.Publish(1, "message")
+	if event := <-doctor.Send; event != "message" {
+		t.Errorf("got event %v, want message", event)
+	}
+
+	h.Unsubscribe(doctor)
+	if _, ok := h.rooms[1]; ok {
+		t.Error("empty roomchat should be removed")
+	}
+}
+
+func TestCloseRoom(t *testing.T) {
+	h := New()
+
+	user := h.Subscribe(1, "user", 10)
+	doctor := h.Subscribe(1, "doctor", 20)
+	h.Subscribe(2, "user", 30)
+
+	h.Publish(1, "closed")
+	h.CloseRoom(1)
+
+	for _, client := range []*Client{user, doctor} {
+		if event := <-client.Send; event != "closed" {
+			t.Errorf("got event %v, want closed before the channel ends", event)
+		}
+		if _, ok := <-client.Send; ok {
+			t.Errorf("send channel of %s should be closed", client.Role)
+		}
+	}
+
+	// a connection ending after its room was closed unsubscribes without panicking
+	h.Unsubscribe(user)
+
+	if !h.Online(2, "user", 30) {
+		t.Error("subscriber of another roomchat should stay online")
+	}
+}
@@ -0,0 +1,166 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"healthcare/models/schema"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Consultation statuses of a doctor transaction
+const (
+	StatusBooked         = "booked"
+	StatusPaid           = "paid"
+	StatusCancelled      = "cancelled"
+	StatusInConsultation = "in consultation"
+	StatusClosed         = "closed"
+	StatusReferred       = "referred"
+	StatusRefunded       = "refunded"
+)
+
+// Actor roles recorded in the transition history
+const (
+	ActorUser   = "user"
+	ActorDoctor = "doctor"
+	ActorAdmin  = "admin"
+	ActorSystem = "system"
+)
+
+// BookingPaymentTTL is how long an unpaid booking of a schedule slot holds the slot, it lapses earlier when the slot starts
+const BookingPaymentTTL = 2 * time.Hour
+
+var ErrInvalidTransition = errors.New("invalid consultation status transition")
+
+// transitions lists the statuses each status may move to, anything else is rejected
+var transitions = map[string][]string{
+	StatusBooked:         {StatusPaid, StatusCancelled},
+	StatusPaid:           {StatusInConsultation, StatusCancelled, StatusRefunded},
+	StatusInConsultation: {StatusClosed, StatusReferred, StatusRefunded},
+	StatusClosed:         {StatusReferred},
+	StatusCancelled:      {StatusRefunded},
+}
+
+func CanTransition(from, to string) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves a doctor transaction to a new consultation status inside tx. It keeps the
+// payment status, patient status, roomchat and booked slot consistent with the new status and
+// appends the change to the transition history. Moving to the current status is a no-op.
+func Transition(tx *gorm.DB, transaction *schema.DoctorTransaction, to string, actorRole string, actorID uint, note string) error {
+
+	from := transaction.ConsultationStatus
+	if from == "" {
+		from = StatusBooked
+	}
+
+	if from == to {
+		return nil
+	}
+
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w from '%s' to '%s'", ErrInvalidTransition, from, to)
+	}
+
+	updates := map[string]interface{}{"consultation_status": to}
+
+	switch to {
+	case StatusPaid:
+		updates["payment_status"] = "success"
+	case StatusCancelled:
+		updates["payment_status"] = "cancelled"
+	case StatusInConsultation:
+		updates["patient_status"] = "ongoing consultation"
+	case StatusClosed:
+		// only a doctor records the outcome, a consultation closed by the system keeps its patient status
+		if actorRole != ActorSystem {
+			updates["patient_status"] = "recovered"
+		}
+	case StatusReferred:
+		updates["patient_status"] = "referred"
+	case StatusRefunded:
+		updates["payment_status"] = "refunded"
+	}
+
+	// the status guard makes concurrent transitions of the same transaction fail instead of overwrite each other
+	result := tx.Model(&schema.DoctorTransaction{}).
+		Where("id = ? AND consultation_status = ?", transaction.ID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w from '%s' to '%s', status was changed by another request", ErrInvalidTransition, from, to)
+	}
+
+	switch to {
+	case StatusClosed, StatusReferred, StatusRefunded:
+		if err := tx.Model(&schema.Roomchat{}).Where("transaction_id = ?", transaction.ID).Update("status", false).Error; err != nil {
+			return err
+		}
+	}
+
+	switch to {
+	case StatusCancelled, StatusRefunded:
+		if err := tx.Where("doctor_transaction_id = ?", transaction.ID).Delete(&schema.DoctorSlot{}).Error; err != nil {
+			return err
+		}
+	}
+
+	history := schema.ConsultationTransition{
+		DoctorTransactionID: transaction.ID,
+		FromStatus:          from,
+		ToStatus:            to,
+		ActorRole:           actorRole,
+		ActorID:             actorID,
+		Note:                note,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	transaction.ConsultationStatus = to
+	if status, ok := updates["payment_status"].(string); ok {
+		transaction.PaymentStatus = status
+	}
+	if status, ok := updates["patient_status"].(string); ok {
+		transaction.PatientStatus = status
+	}
+
+	return nil
+}
+
+// PaymentStatusTarget maps a payment status set by an admin to the consultation status it leads to
+func PaymentStatusTarget(paymentStatus string) string {
+	switch paymentStatus {
+	case "success":
+		return StatusPaid
+	case "cancelled":
+		return StatusCancelled
+	default:
+		return StatusBooked
+	}
+}
+
+// PatientStatusTarget maps a patient status set by a doctor to the consultation status it leads to
+func PatientStatusTarget(current string, patientStatus string) string {
+	switch patientStatus {
+	case "recovered":
+		return StatusClosed
+	case "referred":
+		return StatusReferred
+	case "ongoing consultation":
+		return StatusInConsultation
+	case "pending":
+		return StatusBooked
+	default:
+		return current
+	}
+}
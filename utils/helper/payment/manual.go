@@ -0,0 +1,34 @@
+package payment
+
+import "net/http"
+
+// Manual is the bank transfer flow, the user uploads a proof of transfer and an admin settles the payment
+type Manual struct{}
+
+func (Manual) Name() string {
+	return "manual"
+}
+
+func (Manual) Methods() []string {
+	return []string{"manual transfer bca", "manual transfer bri", "manual transfer bni"}
+}
+
+func (Manual) RequiresConfirmation() bool {
+	return true
+}
+
+func (Manual) CreateCharge(charge Charge) (ChargeResult, error) {
+	if charge.Confirmation == "" {
+		return ChargeResult{}, ErrConfirmationRequired
+	}
+	return ChargeResult{ProviderReference: charge.Reference, Status: StatusPending}, nil
+}
+
+// QueryStatus is not supported, manual transfers are only settled by an admin
+func (Manual) QueryStatus(providerReference string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (Manual) HandleCallback(header http.Header, body []byte) (CallbackEvent, error) {
+	return CallbackEvent{}, ErrNotSupported
+}
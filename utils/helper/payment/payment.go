@@ -0,0 +1,113 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+)
+
+// Payment statuses reported by providers, they match the payment_status columns
+const (
+	StatusPending   = "pending"
+	StatusSuccess   = "success"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	ErrUnknownMethod        = errors.New("unsupported payment method")
+	ErrUnknownProvider      = errors.New("unknown payment provider")
+	ErrNotSupported         = errors.New("operation not supported by the payment provider")
+	ErrConfirmationRequired = errors.New("payment confirmation file is required")
+	ErrChargeNotFound       = errors.New("payment charge not found")
+	ErrInvalidStatus        = errors.New("invalid payment status")
+)
+
+// Charge asks a provider to collect the payment of an order
+type Charge struct {
+	Reference    string // our own reference of the paid order, e.g. doctor-transaction-12
+	Amount       int
+	Method       string
+	Confirmation string // proof of transfer uploaded by the user
+}
+
+// ChargeResult is the provider's answer to a charge
+type ChargeResult struct {
+	ProviderReference string
+	Status            string
+}
+
+// CallbackEvent is a payment notification sent by a provider
+type CallbackEvent struct {
+	EventID           string
+	ProviderReference string
+	Status            string
+}
+
+// PaymentProvider collects payments for one or more payment methods
+type PaymentProvider interface {
+	Name() string
+	Methods() []string
+	// RequiresConfirmation reports whether the user has to upload a proof of payment
+	RequiresConfirmation() bool
+	CreateCharge(charge Charge) (ChargeResult, error)
+	// QueryStatus asks the provider for the current status of a charge
+	QueryStatus(providerReference string) (string, error)
+	// HandleCallback parses and authenticates a notification sent by the provider
+	HandleCallback(header http.Header, body []byte) (CallbackEvent, error)
+}
+
+var (
+	providers = map[string]PaymentProvider{}
+	methods   = map[string]PaymentProvider{}
+)
+
+// Register makes a provider and its payment methods available, it is meant to be called on startup
+func Register(provider PaymentProvider) {
+	providers[provider.Name()] = provider
+	for _, method := range provider.Methods() {
+		methods[method] = provider
+	}
+}
+
+// Init registers the providers enabled for this environment
+func Init() {
+	Register(Manual{})
+
+	if os.Getenv("PAYMENT_SANDBOX") == "true" {
+		Register(NewSandbox(os.Getenv("PAYMENT_SANDBOX_SECRET")))
+	}
+}
+
+// ForMethod returns the provider collecting a payment method
+func ForMethod(method string) (PaymentProvider, error) {
+	provider, ok := methods[method]
+	if !ok {
+		return nil, ErrUnknownMethod
+	}
+	return provider, nil
+}
+
+// ByName returns a registered provider
+func ByName(name string) (PaymentProvider, error) {
+	provider, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Methods lists every payment method currently accepted
+func Methods() []string {
+	results := make([]string, 0, len(methods))
+	for method := range methods {
+		results = append(results, method)
+	}
+	sort.Strings(results)
+	return results
+}
+
+// StatusIsValid reports whether a provider status is one of the known payment statuses
+func StatusIsValid(status string) bool {
+	return status == StatusPending || status == StatusSuccess || status == StatusCancelled
+}
@@ -0,0 +1,114 @@
+package payment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Sandbox is an in-process provider for local development and tests. Charges stay pending
+// until they are settled, either by calling Settle or by posting a callback signed with the secret.
+type Sandbox struct {
+	mu      sync.Mutex
+	secret  string
+	charges map[string]string
+}
+
+type sandboxCallback struct {
+	EventID   string `json:"event_id"`
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+}
+
+func NewSandbox(secret string) *Sandbox {
+	return &Sandbox{secret: secret, charges: map[string]string{}}
+}
+
+func (s *Sandbox) Name() string {
+	return "sandbox"
+}
+
+func (s *Sandbox) Methods() []string {
+	return []string{"sandbox"}
+}
+
+func (s *Sandbox) RequiresConfirmation() bool {
+	return false
+}
+
+func (s *Sandbox) CreateCharge(charge Charge) (ChargeResult, error) {
+	reference, err := randomReference("sbx-")
+	if err != nil {
+		return ChargeResult{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.charges[reference] = StatusPending
+
+	return ChargeResult{ProviderReference: reference, Status: StatusPending}, nil
+}
+
+func (s *Sandbox) QueryStatus(providerReference string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, ok := s.charges[providerReference]
+	if !ok {
+		return "", ErrChargeNotFound
+	}
+	return status, nil
+}
+
+// Settle moves a sandbox charge to a final status
+func (s *Sandbox) Settle(providerReference string, status string) error {
+	if !StatusIsValid(status) {
+		return ErrInvalidStatus
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.charges[providerReference]; !ok {
+		return ErrChargeNotFound
+	}
+	s.charges[providerReference] = status
+
+	return nil
+}
+
+func (s *Sandbox) HandleCallback(header http.Header, body []byte) (CallbackEvent, error) {
+	if !VerifySignature(s.secret, body, header.Get(SignatureHeader)) {
+		return CallbackEvent{}, ErrInvalidSignature
+	}
+
+	var callback sandboxCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return CallbackEvent{}, err
+	}
+
+	if !StatusIsValid(callback.Status) {
+		return CallbackEvent{}, ErrInvalidStatus
+	}
+
+	// charges created before a restart are unknown to the sandbox, the callback is trusted once signed
+	s.mu.Lock()
+	s.charges[callback.Reference] = callback.Status
+	s.mu.Unlock()
+
+	return CallbackEvent{
+		EventID:           callback.EventID,
+		ProviderReference: callback.Reference,
+		Status:            callback.Status,
+	}, nil
+}
+
+func randomReference(prefix string) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,156 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+const testSecret = "sandbox-secret"
+
+// signedCallback builds a sandbox callback body and the header signing it
+func signedCallback(t *testing.T, secret string, eventID string, reference string, status string) (http.Header, []byte) {
+	t.Helper()
+
+	body, err := json.Marshal(sandboxCallback{EventID: eventID, Reference: reference, Status: status})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := http.Header{}
+	header.Set(SignatureHeader, Sign(secret, body))
+
+	return header, body
+}
+
+func TestSandboxChargeAndSettle(t *testing.T) {
+	charges := []Charge{
+		{Reference: "doctor-transaction-12", Amount: 50000, Method: "sandbox"},
+		{Reference: "checkout-7", Amount: 125000, Method: "sandbox"},
+	}
+
+	for _, charge := range charges {
+		sandbox := NewSandbox(testSecret)
+
+		result, err := sandbox.CreateCharge(charge)
+		if err != nil {
+			t.Fatalf("%s: charge failed: %v", charge.Reference, err)
+		}
+		if result.Status != StatusPending {
+			t.Errorf("%s: got status %q on charge, want %q", charge.Reference, result.Status, StatusPending)
+		}
+		if result.ProviderReference == "" || result.ProviderReference == charge.Reference {
+			t.Errorf("%s: got provider reference %q, want a sandbox reference", charge.Reference, result.ProviderReference)
+		}
+
+		if status, err := sandbox.QueryStatus(result.ProviderReference); err != nil || status != StatusPending {
+			t.Errorf("%s: got status %q, %v before settling, want %q", charge.Reference, status, err, StatusPending)
+		}
+
+		if err := sandbox.Settle(result.ProviderReference, StatusSuccess); err != nil {
+			t.Fatalf("%s: settle failed: %v", charge.Reference, err)
+		}
+		if status, err := sandbox.QueryStatus(result.ProviderReference); err != nil || status != StatusSuccess {
+			t.Errorf("%s: got status %q, %v after settling, want %q", charge.Reference, status, err, StatusSuccess)
+		}
+
+		if err := sandbox.Settle(result.ProviderReference, "paid"); !errors.Is(err, ErrInvalidStatus) {
+			t.Errorf("%s: got %v settling an unknown status, want %v", charge.Reference, err, ErrInvalidStatus)
+		}
+	}
+}
+
+func TestSandboxUnknownCharge(t *testing.T) {
+	sandbox := NewSandbox(testSecret)
+
+	if _, err := sandbox.QueryStatus("sbx-missing"); !errors.Is(err, ErrChargeNotFound) {
+		t.Errorf("query: got %v, want %v", err, ErrChargeNotFound)
+	}
+	if err := sandbox.Settle("sbx-missing", StatusSuccess); !errors.Is(err, ErrChargeNotFound) {
+		t.Errorf("settle: got %v, want %v", err, ErrChargeNotFound)
+	}
+}
+
+func TestSandboxCallback(t *testing.T) {
+	sandbox := NewSandbox(testSecret)
+
+	doctorCharge, err := sandbox.CreateCharge(Charge{Reference: "doctor-transaction-3", Amount: 50000, Method: "sandbox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkoutCharge, err := sandbox.CreateCharge(Charge{Reference: "checkout-9", Amount: 80000, Method: "sandbox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		eventID   string
+		reference string
+		status    string
+	}{
+		{"evt-1", doctorCharge.ProviderReference, StatusSuccess},
+		{"evt-2", checkoutCharge.ProviderReference, StatusCancelled},
+	}
+
+	for _, tc := range cases {
+		header, body := signedCallback(t, testSecret, tc.eventID, tc.reference, tc.status)
+
+		event, err := sandbox.HandleCallback(header, body)
+		if err != nil {
+			t.Fatalf("%s: callback failed: %v", tc.eventID, err)
+		}
+
+		want := CallbackEvent{EventID: tc.eventID, ProviderReference: tc.reference, Status: tc.status}
+		if event != want {
+			t.Errorf("%s: got event %+v, want %+v", tc.eventID, event, want)
+		}
+
+		if status, _ := sandbox.QueryStatus(tc.reference); status != tc.status {
+			t.Errorf("%s: got status %q after the callback, want %q", tc.eventID, status, tc.status)
+		}
+	}
+}
+
+func TestSandboxCallbackRejected(t *testing.T) {
+	sandbox := NewSandbox(testSecret)
+
+	charge, err := sandbox.CreateCharge(Charge{Reference: "checkout-4", Amount: 30000, Method: "sandbox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, body := signedCallback(t, testSecret, "evt-1", charge.ProviderReference, StatusSuccess)
+	forged, _ := signedCallback(t, "other-secret", "evt-1", charge.ProviderReference, StatusSuccess)
+	invalidHeader, invalidBody := signedCallback(t, testSecret, "evt-2", charge.ProviderReference, "paid")
+
+	cases := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   error
+	}{
+		{"missing signature", http.Header{}, body, ErrInvalidSignature},
+		{"signed with another secret", forged, body, ErrInvalidSignature},
+		{"unknown status", invalidHeader, invalidBody, ErrInvalidStatus},
+	}
+
+	for _, tc := range cases {
+		if _, err := sandbox.HandleCallback(tc.header, tc.body); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	if status, _ := sandbox.QueryStatus(charge.ProviderReference); status != StatusPending {
+		t.Errorf("got status %q after rejected callbacks, want %q", status, StatusPending)
+	}
+}
+
+func TestSandboxWithoutSecretRejectsCallbacks(t *testing.T) {
+	sandbox := NewSandbox("")
+
+	header, body := signedCallback(t, "", "evt-1", "sbx-any", StatusSuccess)
+	if _, err := sandbox.HandleCallback(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("got %v, want %v", err, ErrInvalidSignature)
+	}
+}
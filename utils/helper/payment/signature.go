@@ -0,0 +1,28 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the callback body
+const SignatureHeader = "X-Payment-Signature"
+
+var ErrInvalidSignature = errors.New("invalid payment callback signature")
+
+// Sign computes the signature a provider sends along a callback body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a callback signature in constant time, an empty secret never verifies
+func VerifySignature(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,106 @@
+package shipping
+
+import "strings"
+
+// zone groups destinations that share the same delivery rates
+type zone struct {
+	name           string
+	postalPrefixes []string
+	cities         []string
+	services       []service
+}
+
+// service prices a delivery by the started kilogram, the first one costs firstKg and every next one nextKg
+type service struct {
+	code    string
+	name    string
+	firstKg int
+	nextKg  int
+	minDays int
+	maxDays int
+}
+
+// zones are matched in order by postal code prefix, then by city, the last zone takes everything else
+var zones = []zone{
+	{
+		name:           "jabodetabek",
+		postalPrefixes: []string{"10", "11", "12", "13", "14", "15", "16", "17"},
+		cities:         []string{"jakarta", "jakarta pusat", "jakarta utara", "jakarta barat", "jakarta selatan", "jakarta timur", "bogor", "depok", "tangerang", "tangerang selatan", "bekasi"},
+		services: []service{
+			{code: "instant", name: "Instant (same day)", firstKg: 25000, nextKg: 5000, minDays: 0, maxDays: 0},
+			{code: "regular", name: "Regular", firstKg: 9000, nextKg: 3000, minDays: 1, maxDays: 2},
+			{code: "express", name: "Express", firstKg: 15000, nextKg: 5000, minDays: 1, maxDays: 1},
+		},
+	},
+	{
+		name:           "java-bali",
+		postalPrefixes: []string{"4", "5", "6", "80", "81", "82"},
+		cities:         []string{"bandung", "semarang", "yogyakarta", "surabaya", "malang", "solo", "surakarta", "denpasar"},
+		services: []service{
+			{code: "regular", name: "Regular", firstKg: 14000, nextKg: 7000, minDays: 2, maxDays: 4},
+			{code: "express", name: "Express", firstKg: 24000, nextKg: 12000, minDays: 1, maxDays: 2},
+		},
+	},
+	{
+		name: "national",
+		services: []service{
+			{code: "regular", name: "Regular", firstKg: 28000, nextKg: 14000, minDays: 3, maxDays: 7},
+			{code: "express", name: "Express", firstKg: 45000, nextKg: 22000, minDays: 2, maxDays: 3},
+		},
+	},
+}
+
+// Local prices deliveries from the built in zone tables
+type Local struct{}
+
+func (Local) Name() string {
+	return "local"
+}
+
+func (Local) Rates(parcel Parcel) ([]Option, error) {
+
+	zone := findZone(parcel)
+
+	kilograms := (parcel.Weight + 999) / 1000
+	if kilograms < 1 {
+		kilograms = 1
+	}
+
+	options := make([]Option, 0, len(zone.services))
+	for _, service := range zone.services {
+		options = append(options, Option{
+			Service: service.code,
+			Name:    service.name,
+			Fee:     service.firstKg + (kilograms-1)*service.nextKg,
+			MinDays: service.minDays,
+			MaxDays: service.maxDays,
+		})
+	}
+
+	return options, nil
+}
+
+func findZone(parcel Parcel) zone {
+
+	postalCode := strings.TrimSpace(parcel.PostalCode)
+	city := strings.ToLower(strings.TrimSpace(parcel.City))
+	city = strings.TrimPrefix(strings.TrimPrefix(city, "kota "), "kabupaten ")
+
+	for _, zone := range zones {
+		for _, prefix := range zone.postalPrefixes {
+			if postalCode != "" && strings.HasPrefix(postalCode, prefix) {
+				return zone
+			}
+		}
+	}
+
+	for _, zone := range zones {
+		for _, name := range zone.cities {
+			if city == name {
+				return zone
+			}
+		}
+	}
+
+	return zones[len(zones)-1]
+}
@@ -0,0 +1,99 @@
+package shipping
+
+import (
+	"errors"
+	"sort"
+)
+
+// DefaultItemWeight is the weight in grams of a medicine that has no weight of its own
+const DefaultItemWeight = 100
+
+var (
+	ErrNoProvider     = errors.New("no shipping rate provider available")
+	ErrUnknownService = errors.New("unsupported shipping service")
+	ErrNoRates        = errors.New("no delivery option to this address")
+)
+
+// Parcel is what has to be delivered and where to
+type Parcel struct {
+	Province   string
+	City       string
+	PostalCode string
+	Weight     int // grams
+}
+
+// Option is one way to deliver a parcel, Service is the code a user chooses it with
+type Option struct {
+	Provider string
+	Service  string
+	Name     string
+	Fee      int
+	MinDays  int
+	MaxDays  int
+}
+
+// RateProvider prices the delivery of parcels
+type RateProvider interface {
+	Name() string
+	Rates(parcel Parcel) ([]Option, error)
+}
+
+var provider RateProvider
+
+// Register makes a provider the one pricing deliveries, it is meant to be called on startup
+func Register(rateProvider RateProvider) {
+	provider = rateProvider
+}
+
+// Init registers the rate provider enabled for this environment
+func Init() {
+	Register(Local{})
+}
+
+// Quote returns the delivery options of a parcel, the cheapest first
+func Quote(parcel Parcel) ([]Option, error) {
+	if provider == nil {
+		return nil, ErrNoProvider
+	}
+
+	if parcel.Weight <= 0 {
+		parcel.Weight = DefaultItemWeight
+	}
+
+	options, err := provider.Rates(parcel)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) == 0 {
+		return nil, ErrNoRates
+	}
+
+	for i := range options {
+		options[i].Provider = provider.Name()
+	}
+
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].Fee < options[j].Fee
+	})
+
+	return options, nil
+}
+
+// Choose returns the option of a service for a parcel, the cheapest option when no service is given
+func Choose(parcel Parcel, service string) (*Option, error) {
+	options, err := Quote(parcel)
+	if err != nil {
+		return nil, err
+	}
+
+	if service == "" {
+		return &options[0], nil
+	}
+
+	for i := range options {
+		if options[i].Service == service {
+			return &options[i], nil
+		}
+	}
+	return nil, ErrUnknownService
+}
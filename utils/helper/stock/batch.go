@@ -0,0 +1,273 @@
+package stock
+
+import (
+	"errors"
+	"healthcare/models/schema"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrExpiredStock       = errors.New("not enough unexpired batch stock")
+	ErrBatchExpiryChanged = errors.New("batch number already received with another expiry date")
+	ErrBatchTracked       = errors.New("stock of a batch tracked medicine has to be received as a batch")
+)
+
+// Today is the first moment of the current day, a batch can be sold until the end of its expiry date
+func Today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Expired reports whether a batch is past its expiry date
+func Expired(batch schema.MedicineBatch) bool {
+	return batch.ExpiryDate.Before(Today())
+}
+
+// Tracked reports whether a medicine is batch tracked, its stock then only changes through its batches
+func Tracked(tx *gorm.DB, medicineID uint) (bool, error) {
+	var batches int64
+	if err := tx.Model(&schema.MedicineBatch{}).Where("medicine_id = ?", medicineID).Count(&batches).Error; err != nil {
+		return false, err
+	}
+	return batches > 0, nil
+}
+
+// Receive adds a quantity of a batch to the stock of a medicine, a known batch number is topped up
+func Receive(tx *gorm.DB, medicineID uint, batchNumber string, expiryDate time.Time, quantity int, actorRole string, actorID uint, reason string) (*schema.MedicineBatch, error) {
+
+	var batch schema.MedicineBatch
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("medicine_id = ? AND batch_number = ?", medicineID, batchNumber).
+		First(&batch).Error
+
+	switch {
+	case err == nil:
+		if !batch.ExpiryDate.Equal(expiryDate) {
+			return nil, ErrBatchExpiryChanged
+		}
+		batch.InitialQuantity += quantity
+		batch.Quantity += quantity
+		if err := tx.Save(&batch).Error; err != nil {
+			return nil, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		batch = schema.MedicineBatch{
+			MedicineID:      medicineID,
+			BatchNumber:     batchNumber,
+			ExpiryDate:      expiryDate,
+			InitialQuantity: quantity,
+			Quantity:        quantity,
+		}
+		if err := tx.Create(&batch).Error; err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if err := move(tx, &schema.StockMovement{
+		MedicineID:      medicineID,
+		Type:            MovementRestock,
+		Quantity:        quantity,
+		MedicineBatchID: &batch.ID,
+		ActorRole:       actorRole,
+		ActorID:         actorID,
+		Reason:          reason,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+// WriteOff takes the remaining quantity of an expired batch off the stock. Units still reserved by
+// unpaid orders stay on the batch until those orders let them go, it returns the quantity written off.
+func WriteOff(tx *gorm.DB, batchID uint, actorRole string, actorID uint) (int, error) {
+
+	var batch schema.MedicineBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, batchID).Error; err != nil {
+		return 0, err
+	}
+
+	if batch.Quantity == 0 || !Expired(batch) {
+		return 0, nil
+	}
+
+	var medicine schema.Medicine
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&medicine, batch.MedicineID).Error; err != nil {
+		return 0, ErrMedicineNotFound
+	}
+
+	quantity := batch.Quantity
+	if medicine.Stock < quantity {
+		quantity = medicine.Stock
+	}
+	if quantity == 0 {
+		return 0, nil
+	}
+
+	if err := move(tx, &schema.StockMovement{
+		MedicineID:      batch.MedicineID,
+		Type:            MovementExpiry,
+		Quantity:        -quantity,
+		MedicineBatchID: &batch.ID,
+		ActorRole:       actorRole,
+		ActorID:         actorID,
+		Reason:          "batch " + batch.BatchNumber + " expired",
+	}); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Model(&batch).Update("quantity", gorm.Expr("quantity - ?", quantity)).Error; err != nil {
+		return 0, err
+	}
+
+	return quantity, nil
+}
+
+// allocate dispenses the medicine details of a paid order first-expired-first-out from the unexpired
+// batches. Medicines without any batch are not batch tracked and are skipped, units received without
+// a batch, such as stock adjustments, cover what the unexpired batches of a medicine cannot.
+func allocate(tx *gorm.DB, medicineTransaction *schema.MedicineTransaction) error {
+	for _, md := range sorted(medicineTransaction.MedicineDetails) {
+		var batches []schema.MedicineBatch
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("medicine_id = ?", md.MedicineID).
+			Order("expiry_date ASC, id ASC").
+			Find(&batches).Error; err != nil {
+			return err
+		}
+
+		if len(batches) == 0 {
+			continue
+		}
+
+		remaining := md.Quantity
+		for _, batch := range batches {
+			if remaining == 0 {
+				break
+			}
+			if batch.Quantity == 0 || Expired(batch) {
+				continue
+			}
+
+			quantity := batch.Quantity
+			if remaining < quantity {
+				quantity = remaining
+			}
+
+			if err := tx.Model(&batch).Update("quantity", gorm.Expr("quantity - ?", quantity)).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Create(&schema.MedicineBatchAllocation{
+				MedicineTransactionID: medicineTransaction.ID,
+				MedicineID:            md.MedicineID,
+				MedicineBatchID:       batch.ID,
+				Quantity:              quantity,
+			}).Error; err != nil {
+				return err
+			}
+
+			remaining -= quantity
+		}
+
+		if remaining > 0 {
+			unbatched, err := unbatchedStock(tx, medicineTransaction.ID, md)
+			if err != nil {
+				return err
+			}
+			if remaining > unbatched {
+				return ErrExpiredStock
+			}
+		}
+	}
+	return nil
+}
+
+// unbatchedStock counts the units of a medicine that are on hand without a batch. The on hand units are
+// the stock plus what unpaid orders and the order of md reserved, the batches hold the rest.
+func unbatchedStock(tx *gorm.DB, medicineTransactionID uint, md schema.MedicineDetails) (int, error) {
+
+	var medicine schema.Medicine
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").First(&medicine, md.MedicineID).Error; err != nil {
+		return 0, ErrMedicineNotFound
+	}
+
+	var reserved int
+	if err := tx.Model(&schema.MedicineDetails{}).
+		Joins("JOIN medicine_transactions ON medicine_transactions.id = medicine_details.medicine_transaction_id").
+		Where("medicine_details.medicine_id = ? AND medicine_transactions.reservation_status = ?", md.MedicineID, StatusReserved).
+		Where("medicine_transactions.id <> ? AND medicine_transactions.deleted_at IS NULL", medicineTransactionID).
+		Select("COALESCE(SUM(medicine_details.quantity), 0)").
+		Scan(&reserved).Error; err != nil {
+		return 0, err
+	}
+
+	var batched int
+	if err := tx.Model(&schema.MedicineBatch{}).
+		Where("medicine_id = ?", md.MedicineID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&batched).Error; err != nil {
+		return 0, err
+	}
+
+	unbatched := medicine.Stock + reserved + md.Quantity - batched
+	if unbatched < 0 {
+		return 0, nil
+	}
+	return unbatched, nil
+}
+
+// checkUnexpired refuses a medicine detail that only the units of expired batches could cover. A detail the
+// whole stock cannot cover is left to move, which refuses it as insufficient stock.
+func checkUnexpired(tx *gorm.DB, md schema.MedicineDetails) error {
+
+	var expired int
+	if err := tx.Model(&schema.MedicineBatch{}).
+		Where("medicine_id = ? AND expiry_date < ?", md.MedicineID, Today()).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&expired).Error; err != nil {
+		return err
+	}
+	if expired == 0 {
+		return nil
+	}
+
+	var medicine schema.Medicine
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").First(&medicine, md.MedicineID).Error; err != nil {
+		return ErrMedicineNotFound
+	}
+
+	if medicine.Stock >= md.Quantity && medicine.Stock-expired < md.Quantity {
+		return ErrExpiredStock
+	}
+	return nil
+}
+
+// deallocate puts the dispensed quantities of an order back on their batches, expired ones are
+// written off again by the batch expiry job
+func deallocate(tx *gorm.DB, medicineTransactionID uint) error {
+
+	var allocations []schema.MedicineBatchAllocation
+	if err := tx.Where("medicine_transaction_id = ?", medicineTransactionID).Order("medicine_batch_id ASC").Find(&allocations).Error; err != nil {
+		return err
+	}
+
+	for _, allocation := range allocations {
+		if err := tx.Model(&schema.MedicineBatch{}).
+			Where("id = ?", allocation.MedicineBatchID).
+			Update("quantity", gorm.Expr("quantity + ?", allocation.Quantity)).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	return tx.Where("medicine_transaction_id = ?", medicineTransactionID).Delete(&schema.MedicineBatchAllocation{}).Error
+}
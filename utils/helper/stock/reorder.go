@@ -0,0 +1,100 @@
+package stock
+
+import (
+	"healthcare/models/schema"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Defaults of the reorder suggestions, in days
+const (
+	DefaultSalesWindow = 30
+	DefaultCoverDays   = 14
+)
+
+// Suggestion is how much of a medicine to reorder so its stock covers the coming days at the recent sales velocity
+type Suggestion struct {
+	Medicine          schema.Medicine
+	Sold              int
+	DailySales        float64
+	DaysOfStock       *float64
+	SuggestedQuantity int
+}
+
+// Suggest returns the medicines that should be reordered, the ones running out soonest first. A medicine
+// needs reordering when its stock is below its reorder threshold or will not last coverDays at the
+// velocity of its paid orders of the last salesWindow days.
+func Suggest(tx *gorm.DB, salesWindow int, coverDays int) ([]Suggestion, error) {
+
+	var sales []struct {
+		MedicineID uint
+		Sold       int
+	}
+	if err := tx.Table("medicine_details").
+		Select("medicine_details.medicine_id, SUM(medicine_details.quantity) AS sold").
+		Joins("JOIN medicine_transactions ON medicine_transactions.id = medicine_details.medicine_transaction_id").
+		Where("medicine_transactions.reservation_status = ? AND medicine_transactions.created_at >= ?", StatusCommitted, time.Now().AddDate(0, 0, -salesWindow)).
+		Group("medicine_details.medicine_id").
+		Scan(&sales).Error; err != nil {
+		return nil, err
+	}
+
+	sold := make(map[uint]int, len(sales))
+	medicineIDs := []uint{}
+	for _, sale := range sales {
+		sold[sale.MedicineID] = sale.Sold
+		medicineIDs = append(medicineIDs, sale.MedicineID)
+	}
+
+	var medicines []schema.Medicine
+	query := tx.Where("stock < reorder_threshold")
+	if len(medicineIDs) > 0 {
+		query = tx.Where("(stock < reorder_threshold OR id IN ?)", medicineIDs)
+	}
+	if err := query.Find(&medicines).Error; err != nil {
+		return nil, err
+	}
+
+	suggestions := []Suggestion{}
+	for _, medicine := range medicines {
+		suggestion := Suggestion{
+			Medicine:   medicine,
+			Sold:       sold[medicine.ID],
+			DailySales: float64(sold[medicine.ID]) / float64(salesWindow),
+		}
+
+		target := medicine.ReorderThreshold
+		if suggestion.DailySales > 0 {
+			daysOfStock := float64(medicine.Stock) / suggestion.DailySales
+			suggestion.DaysOfStock = &daysOfStock
+			target += int(math.Ceil(suggestion.DailySales * float64(coverDays)))
+		}
+
+		if medicine.Stock >= target {
+			continue
+		}
+
+		suggestion.SuggestedQuantity = target - medicine.Stock
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return daysLeft(suggestions[i]) < daysLeft(suggestions[j])
+	})
+
+	return suggestions, nil
+}
+
+// daysLeft treats medicines without recent sales as lasting forever, below threshold ones already run short
+func daysLeft(suggestion Suggestion) float64 {
+	if suggestion.DaysOfStock != nil {
+		return *suggestion.DaysOfStock
+	}
+	if suggestion.Medicine.Stock < suggestion.Medicine.ReorderThreshold {
+		return 0
+	}
+	return math.Inf(1)
+}
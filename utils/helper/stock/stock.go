@@ -0,0 +1,291 @@
+package stock
+
+import (
+	"errors"
+	"healthcare/models/schema"
+	"healthcare/utils/helper/constanta"
+	"healthcare/utils/helper/voucher"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reservation statuses of a medicine transaction
+const (
+	StatusReserved  = "reserved"
+	StatusCommitted = "committed"
+	StatusReleased  = "released"
+)
+
+// Movement types of the stock ledger
+const (
+	MovementRestock    = "restock"
+	MovementSale       = "sale"
+	MovementRelease    = "release"
+	MovementRefund     = "refund"
+	MovementAdjustment = "adjustment"
+	MovementExpiry     = "expiry"
+)
+
+// ReservationTTL is how long an order keeps its medicines without an active checkout
+const ReservationTTL = 30 * time.Minute
+
+// CheckoutPaymentTTL is how long a pending checkout keeps the medicines of its order before it is cancelled
+const CheckoutPaymentTTL = 24 * time.Hour
+
+var (
+	ErrMedicineNotFound  = errors.New("medicine id " + constanta.ErrNotFound)
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+// Reserve fills the prices of a new medicine transaction, adding its shipping fee to the total and taking its
+// voucher discount off, saves it as reserved and takes the ordered quantities off the stock, all inside tx
+func Reserve(tx *gorm.DB, medicineTransaction *schema.MedicineTransaction, actorRole string, actorID uint) error {
+
+	// the medicines are locked in medicine order before the details are saved, the foreign key checks of the
+	// details take shared locks on the same rows and concurrent orders would deadlock upgrading them
+	for _, md := range sorted(medicineTransaction.MedicineDetails) {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&schema.Medicine{}, md.MedicineID).Error; err != nil {
+			return ErrMedicineNotFound
+		}
+	}
+
+	totalPrice := 0
+	for i, md := range medicineTransaction.MedicineDetails {
+		medicine := schema.Medicine{}
+		if err := tx.First(&medicine, md.MedicineID).Error; err != nil {
+			return ErrMedicineNotFound
+		}
+
+		medicineTransaction.MedicineDetails[i].TotalPriceMedicine = md.Quantity * medicine.Price
+
+		totalPrice += medicineTransaction.MedicineDetails[i].TotalPriceMedicine
+	}
+
+	// prices may have dropped since the discount was worked out, it never takes more than the medicines cost
+	if medicineTransaction.Discount > totalPrice {
+		medicineTransaction.Discount = totalPrice
+	}
+
+	reservedUntil := time.Now().Add(ReservationTTL)
+
+	medicineTransaction.TotalPrice = totalPrice - medicineTransaction.Discount + medicineTransaction.ShippingFee
+	medicineTransaction.ReservationStatus = StatusReserved
+	medicineTransaction.ReservedUntil = &reservedUntil
+
+	if err := tx.Create(medicineTransaction).Error; err != nil {
+		return err
+	}
+
+	return deduct(tx, medicineTransaction.ID, medicineTransaction.MedicineDetails, actorRole, actorID, "order placed")
+}
+
+// Commit turns the reservation of a paid medicine transaction into a sale dispensed from its batches. A reservation
+// that was already released, or an order placed before reservations existed, takes the stock and claims its voucher again.
+func Commit(tx *gorm.DB, medicineTransactionID uint, actorRole string, actorID uint) error {
+
+	medicineTransaction, err := lock(tx, medicineTransactionID)
+	if err != nil {
+		return err
+	}
+
+	switch medicineTransaction.ReservationStatus {
+	case StatusCommitted:
+		return nil
+	case StatusReserved:
+	default:
+		if err := deduct(tx, medicineTransaction.ID, medicineTransaction.MedicineDetails, actorRole, actorID, "order paid"); err != nil {
+			return err
+		}
+		if err := voucher.ReclaimMedicineTransaction(tx, medicineTransaction); err != nil {
+			return err
+		}
+	}
+
+	if err := allocate(tx, medicineTransaction); err != nil {
+		return err
+	}
+
+	return tx.Model(medicineTransaction).Updates(map[string]interface{}{
+		"reservation_status": StatusCommitted,
+		"reserved_until":     nil,
+	}).Error
+}
+
+// Renew holds the medicines of a medicine transaction for another ReservationTTL, taking the stock
+// and claiming its voucher again when the reservation was released. A committed sale goes back to a reservation and
+// its batches get their units back until the order is paid again.
+func Renew(tx *gorm.DB, medicineTransactionID uint, actorRole string, actorID uint) error {
+
+	medicineTransaction, err := lock(tx, medicineTransactionID)
+	if err != nil {
+		return err
+	}
+
+	switch medicineTransaction.ReservationStatus {
+	case StatusReserved:
+	case StatusCommitted:
+		if err := deallocate(tx, medicineTransaction.ID); err != nil {
+			return err
+		}
+	default:
+		if err := deduct(tx, medicineTransaction.ID, medicineTransaction.MedicineDetails, actorRole, actorID, "reservation renewed"); err != nil {
+			return err
+		}
+		if err := voucher.ReclaimMedicineTransaction(tx, medicineTransaction); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(medicineTransaction).Updates(map[string]interface{}{
+		"reservation_status": StatusReserved,
+		"reserved_until":     time.Now().Add(ReservationTTL),
+	}).Error
+}
+
+// Release puts the reserved or sold medicines of a medicine transaction back on the stock and records
+// them as movementType, the voucher use of the order is given back with them. It reports false when
+// there was nothing to release.
+func Release(tx *gorm.DB, medicineTransactionID uint, movementType string, actorRole string, actorID uint, reason string) (bool, error) {
+
+	medicineTransaction, err := lock(tx, medicineTransactionID)
+	if err != nil {
+		return false, err
+	}
+
+	if medicineTransaction.ReservationStatus != StatusReserved && medicineTransaction.ReservationStatus != StatusCommitted {
+		return false, nil
+	}
+
+	if medicineTransaction.ReservationStatus == StatusCommitted {
+		if err := deallocate(tx, medicineTransaction.ID); err != nil {
+			return false, err
+		}
+	}
+
+	for _, md := range sorted(medicineTransaction.MedicineDetails) {
+		if err := move(tx, &schema.StockMovement{
+			MedicineID:            md.MedicineID,
+			Type:                  movementType,
+			Quantity:              md.Quantity,
+			MedicineTransactionID: &medicineTransaction.ID,
+			ActorRole:             actorRole,
+			ActorID:               actorID,
+			Reason:                reason,
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	if err := voucher.ReleaseMedicineTransaction(tx, medicineTransaction.ID); err != nil {
+		return false, err
+	}
+
+	err = tx.Model(medicineTransaction).Updates(map[string]interface{}{
+		"reservation_status": StatusReleased,
+		"reserved_until":     nil,
+	}).Error
+
+	return err == nil, err
+}
+
+// Adjust changes the stock of one medicine by quantity outside of any order, a negative quantity
+// may not take the stock below zero
+func Adjust(tx *gorm.DB, medicineID uint, quantity int, movementType string, actorRole string, actorID uint, reason string) (*schema.StockMovement, error) {
+	movement := &schema.StockMovement{
+		MedicineID: medicineID,
+		Type:       movementType,
+		Quantity:   quantity,
+		ActorRole:  actorRole,
+		ActorID:    actorID,
+		Reason:     reason,
+	}
+	if err := move(tx, movement); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// Set records the difference between the current and the wanted stock of a medicine as an adjustment,
+// it returns nil when the stock already matches
+func Set(tx *gorm.DB, medicineID uint, stock int, actorRole string, actorID uint, reason string) (*schema.StockMovement, error) {
+
+	var medicine schema.Medicine
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&medicine, medicineID).Error; err != nil {
+		return nil, ErrMedicineNotFound
+	}
+
+	if medicine.Stock == stock {
+		return nil, nil
+	}
+
+	return Adjust(tx, medicineID, stock-medicine.Stock, MovementAdjustment, actorRole, actorID, reason)
+}
+
+// deduct records the medicine details of an order as sales. Units of expired batches that were not written
+// off yet are still counted in the stock, an order may only take the units left beside them.
+func deduct(tx *gorm.DB, medicineTransactionID uint, medicineDetails []schema.MedicineDetails, actorRole string, actorID uint, reason string) error {
+	for _, md := range sorted(medicineDetails) {
+		if err := checkUnexpired(tx, md); err != nil {
+			return err
+		}
+		if err := move(tx, &schema.StockMovement{
+			MedicineID:            md.MedicineID,
+			Type:                  MovementSale,
+			Quantity:              -md.Quantity,
+			MedicineTransactionID: &medicineTransactionID,
+			ActorRole:             actorRole,
+			ActorID:               actorID,
+			Reason:                reason,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// move changes the stock by the quantity of the movement with a conditional update, so concurrent
+// changes can never take it below zero, and appends the movement to the ledger
+func move(tx *gorm.DB, movement *schema.StockMovement) error {
+
+	result := tx.Model(&schema.Medicine{}).
+		Where("id = ? AND stock + ? >= 0", movement.MedicineID, movement.Quantity).
+		Update("stock", gorm.Expr("stock + ?", movement.Quantity))
+	if result.Error != nil {
+		return errors.New(constanta.ErrActionUpdated + "medicine stock")
+	}
+
+	var medicine schema.Medicine
+	if err := tx.Select("id", "stock").First(&medicine, movement.MedicineID).Error; err != nil {
+		return ErrMedicineNotFound
+	}
+
+	if result.RowsAffected == 0 && movement.Quantity != 0 {
+		return ErrInsufficientStock
+	}
+
+	movement.StockAfter = medicine.Stock
+
+	return tx.Create(movement).Error
+}
+
+// lock loads a medicine transaction with its details and holds its row until tx ends
+func lock(tx *gorm.DB, medicineTransactionID uint) (*schema.MedicineTransaction, error) {
+	var medicineTransaction schema.MedicineTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("MedicineDetails").First(&medicineTransaction, medicineTransactionID).Error; err != nil {
+		return nil, err
+	}
+	return &medicineTransaction, nil
+}
+
+// sorted orders the details by medicine so concurrent transactions lock the rows in the same order
+func sorted(medicineDetails []schema.MedicineDetails) []schema.MedicineDetails {
+	details := make([]schema.MedicineDetails, len(medicineDetails))
+	copy(details, medicineDetails)
+	sort.SliceStable(details, func(i, j int) bool {
+		return details[i].MedicineID < details[j].MedicineID
+	})
+	return details
+}
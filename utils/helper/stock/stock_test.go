@@ -0,0 +1,169 @@
+package stock
+
+import (
+	"errors"
+	"fmt"
+	"healthcare/configs"
+	"healthcare/models/schema"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB connects to the MySQL database of TEST_DB_DSN and migrates it, tests that need a database skip without it
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set")
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect test database: %v", err)
+	}
+
+	configs.DB = db
+	configs.InitialMigration()
+
+	return db
+}
+
+func TestConcurrentReserveNeverOversells(t *testing.T) {
+	db := openTestDB(t)
+
+	const stock = 10
+	const orders = 25
+
+	suffix := time.Now().UnixNano()
+
+	user := schema.User{Fullname: "stock test", Email: fmt.Sprintf("stock-%d@test.local", suffix), Password: "-", OTP: "-"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	medicine := schema.Medicine{Code: fmt.Sprintf("STOCK-TEST-%d", suffix), Name: "stock test", Merk: "-", Category: "-", Type: "-", Price: 1000, Details: "-", Image: "-"}
+	if err := db.Create(&medicine).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := Adjust(tx, medicine.ID, stock, MovementRestock, "admin", 0, "opening stock")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved, refused := 0, 0
+
+	for i := 0; i < orders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return Reserve(tx, &schema.MedicineTransaction{
+					UserID:          user.ID,
+					Name:            "stock test",
+					Address:         "-",
+					HP:              "-",
+					MedicineDetails: []schema.MedicineDetails{{MedicineID: medicine.ID, Quantity: 1}},
+				}, "user", user.ID)
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				reserved++
+			case errors.Is(err, ErrInsufficientStock):
+				refused++
+			default:
+				t.Errorf("reserve failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != stock || refused != orders-stock {
+		t.Errorf("got %d reserved and %d refused orders, want %d and %d", reserved, refused, stock, orders-stock)
+	}
+
+	var current schema.Medicine
+	if err := db.First(&current, medicine.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if current.Stock != 0 {
+		t.Errorf("got stock %d, want 0", current.Stock)
+	}
+
+	var ledger int
+	if err := db.Model(&schema.StockMovement{}).Select("COALESCE(SUM(quantity), 0)").Where("medicine_id = ?", medicine.ID).Scan(&ledger).Error; err != nil {
+		t.Fatal(err)
+	}
+	if ledger != current.Stock {
+		t.Errorf("got ledger total %d, want it to match the stock %d", ledger, current.Stock)
+	}
+
+	var movements int64
+	db.Model(&schema.StockMovement{}).Where("medicine_id = ? AND type = ?", medicine.ID, MovementSale).Count(&movements)
+	if movements != stock {
+		t.Errorf("got %d sale movements, want %d", movements, stock)
+	}
+}
+
+func TestReserveRefusesExpiredBatchStock(t *testing.T) {
+	db := openTestDB(t)
+
+	suffix := time.Now().UnixNano()
+
+	user := schema.User{Fullname: "stock test", Email: fmt.Sprintf("stock-expired-%d@test.local", suffix), Password: "-", OTP: "-"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	medicine := schema.Medicine{Code: fmt.Sprintf("STOCK-EXPIRED-%d", suffix), Name: "stock test", Merk: "-", Category: "-", Type: "-", Price: 1000, Details: "-", Image: "-"}
+	if err := db.Create(&medicine).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := Receive(tx, medicine.ID, "EXPIRED", Today().AddDate(0, 0, -1), 5, "admin", 0, "expired batch"); err != nil {
+			return err
+		}
+		_, err := Receive(tx, medicine.ID, "FRESH", Today().AddDate(0, 1, 0), 2, "admin", 0, "fresh batch")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reserve := func(quantity int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			return Reserve(tx, &schema.MedicineTransaction{
+				UserID:          user.ID,
+				Name:            "stock test",
+				Address:         "-",
+				HP:              "-",
+				MedicineDetails: []schema.MedicineDetails{{MedicineID: medicine.ID, Quantity: quantity}},
+			}, "user", user.ID)
+		})
+	}
+
+	if err := reserve(3); !errors.Is(err, ErrExpiredStock) {
+		t.Errorf("got %v reserving more than the unexpired stock, want %v", err, ErrExpiredStock)
+	}
+	if err := reserve(8); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("got %v reserving more than the whole stock, want %v", err, ErrInsufficientStock)
+	}
+	if err := reserve(2); err != nil {
+		t.Errorf("got %v reserving the unexpired stock, want it reserved", err)
+	}
+}
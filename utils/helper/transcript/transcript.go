@@ -0,0 +1,72 @@
+package transcript
+
+import (
+	"bytes"
+	"healthcare/models/web"
+	"html/template"
+	"time"
+)
+
+const dateTimeLayout = "02 Jan 2006 15:04"
+
+var page = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"datetime": func(t time.Time) string { return t.Format(dateTimeLayout) },
+}).Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>Riwayat Konsultasi #{{.Consultation.TransactionID}}</title>
+<style>
+body { font-family: Arial, sans-serif; color: #222; max-width: 800px; margin: 24px auto; }
+h1 { font-size: 20px; }
+h2 { font-size: 16px; margin-top: 28px; border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+table { border-collapse: collapse; width: 100%; }
+td, th { text-align: left; padding: 6px; border-bottom: 1px solid #eee; vertical-align: top; }
+.message { margin: 8px 0; padding: 8px 12px; border-radius: 6px; background: #f3f6fb; }
+.message.doctor { background: #eaf7ef; }
+.meta { font-size: 12px; color: #666; }
+</style>
+</head>
+<body>
+<h1>Riwayat Konsultasi #{{.Consultation.TransactionID}}</h1>
+<table>
+<tr><th>Pasien</th><td>{{.Patient}}</td></tr>
+<tr><th>Dokter</th><td>{{.Consultation.Doctor.Fullname}} ({{.Consultation.Doctor.Specialist}})</td></tr>
+<tr><th>Mulai</th><td>{{datetime .Consultation.StartedAt}}</td></tr>
+<tr><th>Selesai</th><td>{{with .Consultation.EndedAt}}{{datetime .}}{{else}}-{{end}}</td></tr>
+<tr><th>Keluhan</th><td>{{.Consultation.HealthDetails}}</td></tr>
+<tr><th>Status Pasien</th><td>{{.Consultation.PatientStatus}}</td></tr>
+{{with .Consultation.ClosingSummary}}<tr><th>Ringkasan</th><td>{{.}}</td></tr>{{end}}
+</table>
+
+<h2>Percakapan</h2>
+{{range .Messages}}<div class="message {{.Role}}">
+<div class="meta">{{.Sender}} &middot; {{datetime .CreatedAt}}</div>
+{{with .Message}}<div>{{.}}</div>{{end}}
+{{with .Image}}<div><a href="{{.}}">Gambar</a></div>{{end}}
+{{with .Audio}}<div><a href="{{.}}">Pesan suara</a></div>{{end}}
+</div>
+{{else}}<p>Tidak ada pesan.</p>
+{{end}}
+{{with .Prescription}}
+<h2>Resep</h2>
+{{with .Notes}}<p>{{.}}</p>{{end}}
+<table>
+<tr><th>Obat</th><th>Jumlah</th><th>Dosis</th><th>Frekuensi</th><th>Durasi</th></tr>
+{{range .PrescriptionDetails}}<tr><td>{{.Name}}</td><td>{{.Quantity}}</td><td>{{.Dosage}}</td><td>{{.Frequency}}</td><td>{{.Duration}}</td></tr>
+{{end}}
+</table>
+{{end}}
+<p class="meta">Dibuat pada {{datetime .GeneratedAt}}</p>
+</body>
+</html>
+`))
+
+// RenderHTML renders the consultation transcript as a standalone HTML document
+func RenderHTML(data web.ConsultationTranscript) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,259 @@
+package voucher
+
+import (
+	"errors"
+	"healthcare/models/schema"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Scopes a voucher can be used in
+const (
+	ScopeConsultation = "consultation"
+	ScopeMedicine     = "medicine"
+)
+
+// Discount types of a voucher
+const (
+	TypePercentage = "percentage"
+	TypeFixed      = "fixed"
+)
+
+var (
+	ErrNotFound      = errors.New("voucher not found")
+	ErrInactive      = errors.New("voucher is not active")
+	ErrNotStarted    = errors.New("voucher cannot be used yet")
+	ErrExpired       = errors.New("voucher has expired")
+	ErrWrongScope    = errors.New("voucher cannot be used for this purchase")
+	ErrNotApplicable = errors.New("voucher does not apply to this doctor or these medicines")
+	ErrMinSpend      = errors.New("purchase does not reach the minimum spend of the voucher")
+	ErrUsageLimit    = errors.New("voucher has been fully used")
+	ErrUserLimit     = errors.New("voucher usage limit for this user has been reached")
+)
+
+// Line is one priced part of a purchase a voucher may discount, a consultation with a doctor or an ordered medicine
+type Line struct {
+	DoctorID   uint
+	Specialist string
+	CategoryID *uint
+	Amount     int
+}
+
+// Normalize returns the code a voucher is stored and looked up with
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Discount returns the discount of a voucher on an eligible amount, never more than the amount itself
+func Discount(voucher schema.Voucher, amount int) int {
+
+	discount := voucher.Value
+	if voucher.DiscountType == TypePercentage {
+		discount = amount * voucher.Value / 100
+		if voucher.MaxDiscount > 0 && discount > voucher.MaxDiscount {
+			discount = voucher.MaxDiscount
+		}
+	}
+
+	if discount > amount {
+		discount = amount
+	}
+	if discount < 0 {
+		discount = 0
+	}
+	return discount
+}
+
+// matches tells whether a purchase line is one of the targets of a voucher, a voucher without targets matches every line
+func matches(targets []schema.VoucherTarget, line Line) bool {
+	if len(targets) == 0 {
+		return true
+	}
+
+	for _, target := range targets {
+		switch {
+		case target.DoctorID != nil && *target.DoctorID == line.DoctorID && line.DoctorID != 0:
+			return true
+		case target.Specialist != "" && strings.EqualFold(target.Specialist, line.Specialist):
+			return true
+		case target.MedicineCategoryID != nil && line.CategoryID != nil && *target.MedicineCategoryID == *line.CategoryID:
+			return true
+		}
+	}
+	return false
+}
+
+// Claim locks a voucher by its code inside tx and checks it can be used by a user on a purchase, returning
+// the voucher and its discount on the lines it applies to. The row lock keeps concurrent claims from going
+// past the usage limits, the caller records the redemption in the same tx once its transaction is saved.
+func Claim(tx *gorm.DB, code string, scope string, userID uint, lines []Line) (*schema.Voucher, int, error) {
+
+	var voucher schema.Voucher
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", Normalize(code)).First(&voucher).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrNotFound
+		}
+		return nil, 0, err
+	}
+
+	if err := tx.Where("voucher_id = ?", voucher.ID).Find(&voucher.Targets).Error; err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	switch {
+	case !voucher.IsActive:
+		return nil, 0, ErrInactive
+	case voucher.Scope != scope:
+		return nil, 0, ErrWrongScope
+	case !voucher.StartsAt.IsZero() && now.Before(voucher.StartsAt):
+		return nil, 0, ErrNotStarted
+	case !voucher.EndsAt.IsZero() && now.After(voucher.EndsAt):
+		return nil, 0, ErrExpired
+	}
+
+	eligible := 0
+	applies := false
+	for _, line := range lines {
+		if matches(voucher.Targets, line) {
+			eligible += line.Amount
+			applies = true
+		}
+	}
+	if !applies {
+		return nil, 0, ErrNotApplicable
+	}
+	if eligible < voucher.MinSpend {
+		return nil, 0, ErrMinSpend
+	}
+
+	if voucher.UsageLimit > 0 {
+		var used int64
+		if err := tx.Model(&schema.VoucherRedemption{}).
+			Where("voucher_id = ? AND released_at IS NULL", voucher.ID).
+			Count(&used).Error; err != nil {
+			return nil, 0, err
+		}
+		if used >= int64(voucher.UsageLimit) {
+			return nil, 0, ErrUsageLimit
+		}
+	}
+
+	if voucher.PerUserLimit > 0 {
+		var used int64
+		if err := tx.Model(&schema.VoucherRedemption{}).
+			Where("voucher_id = ? AND user_id = ? AND released_at IS NULL", voucher.ID, userID).
+			Count(&used).Error; err != nil {
+			return nil, 0, err
+		}
+		if used >= int64(voucher.PerUserLimit) {
+			return nil, 0, ErrUserLimit
+		}
+	}
+
+	return &voucher, Discount(voucher, eligible), nil
+}
+
+// RedeemDoctorTransaction records the voucher of a saved doctor transaction, if it has one
+func RedeemDoctorTransaction(tx *gorm.DB, doctorTransaction *schema.DoctorTransaction) error {
+	if doctorTransaction.VoucherID == nil {
+		return nil
+	}
+	return tx.Create(&schema.VoucherRedemption{
+		VoucherID:           *doctorTransaction.VoucherID,
+		UserID:              doctorTransaction.UserID,
+		DoctorTransactionID: &doctorTransaction.ID,
+		Discount:            doctorTransaction.Discount,
+	}).Error
+}
+
+// RedeemMedicineTransaction records the voucher of a saved medicine transaction, if it has one
+func RedeemMedicineTransaction(tx *gorm.DB, medicineTransaction *schema.MedicineTransaction) error {
+	if medicineTransaction.VoucherID == nil {
+		return nil
+	}
+	return tx.Create(&schema.VoucherRedemption{
+		VoucherID:             *medicineTransaction.VoucherID,
+		UserID:                medicineTransaction.UserID,
+		MedicineTransactionID: &medicineTransaction.ID,
+		Discount:              medicineTransaction.Discount,
+	}).Error
+}
+
+// ReclaimMedicineTransaction claims the voucher of a medicine transaction again when its released reservation
+// takes the stock back, and records the redemption. A voucher that can no longer be used, because it ran out or
+// ended while the order held nothing, is dropped from the order and the discount is added back to its total.
+func ReclaimMedicineTransaction(tx *gorm.DB, medicineTransaction *schema.MedicineTransaction) error {
+
+	if medicineTransaction.VoucherID == nil {
+		return nil
+	}
+
+	medicineIDs := make([]uint, len(medicineTransaction.MedicineDetails))
+	for i, md := range medicineTransaction.MedicineDetails {
+		medicineIDs[i] = md.MedicineID
+	}
+
+	var medicines []schema.Medicine
+	if err := tx.Unscoped().Select("id", "category_id").Where("id IN ?", medicineIDs).Find(&medicines).Error; err != nil {
+		return err
+	}
+
+	categories := make(map[uint]*uint, len(medicines))
+	for _, medicine := range medicines {
+		categories[medicine.ID] = medicine.CategoryID
+	}
+
+	// the lines keep the prices the order was placed with, the discount was worked out on them
+	lines := make([]Line, len(medicineTransaction.MedicineDetails))
+	for i, md := range medicineTransaction.MedicineDetails {
+		lines[i] = Line{CategoryID: categories[md.MedicineID], Amount: md.TotalPriceMedicine}
+	}
+
+	_, _, err := Claim(tx, medicineTransaction.VoucherCode, ScopeMedicine, medicineTransaction.UserID, lines)
+	if err == nil {
+		return RedeemMedicineTransaction(tx, medicineTransaction)
+	}
+	if !refused(err) {
+		return err
+	}
+
+	medicineTransaction.TotalPrice += medicineTransaction.Discount
+	medicineTransaction.VoucherID = nil
+	medicineTransaction.VoucherCode = ""
+	medicineTransaction.Discount = 0
+
+	return tx.Model(medicineTransaction).Updates(map[string]interface{}{
+		"voucher_id":   nil,
+		"voucher_code": "",
+		"discount":     0,
+		"total_price":  medicineTransaction.TotalPrice,
+	}).Error
+}
+
+// refused reports whether a claim failed because the voucher cannot be used, rather than on the database
+func refused(err error) bool {
+	for _, reason := range []error{ErrNotFound, ErrInactive, ErrNotStarted, ErrExpired, ErrWrongScope, ErrNotApplicable, ErrMinSpend, ErrUsageLimit, ErrUserLimit} {
+		if errors.Is(err, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleaseDoctorTransaction gives back the voucher use of a cancelled doctor transaction
+func ReleaseDoctorTransaction(tx *gorm.DB, doctorTransactionID uint) error {
+	return tx.Model(&schema.VoucherRedemption{}).
+		Where("doctor_transaction_id = ? AND released_at IS NULL", doctorTransactionID).
+		Update("released_at", time.Now()).Error
+}
+
+// ReleaseMedicineTransaction gives back the voucher use of a medicine transaction whose medicines were released
+func ReleaseMedicineTransaction(tx *gorm.DB, medicineTransactionID uint) error {
+	return tx.Model(&schema.VoucherRedemption{}).
+		Where("medicine_transaction_id = ? AND released_at IS NULL", medicineTransactionID).
+		Update("released_at", time.Now()).Error
+}
@@ -91,3 +91,38 @@ func PaginationResponse(message string, data interface{}, pagination interface{}
 		}
 	}
 }
+
+type TCursorPagination struct {
+	Limit   int  `json:"limit"`
+	Before  uint `json:"before"`
+	After   uint `json:"after"`
+	HasMore bool `json:"has_more"`
+}
+
+func CursorPagination(limit int, before uint, after uint, hasMore bool) TCursorPagination {
+	return TCursorPagination{
+		Limit:   limit,
+		Before:  before,
+		After:   after,
+		HasMore: hasMore,
+	}
+}
+
+type TFPSuccessResponse struct {
+	Meta       TResponseMeta `json:"meta"`
+	Results    interface{}   `json:"results"`
+	Pagination interface{}   `json:"pagination"`
+	Facets     interface{}   `json:"facets"`
+}
+
+func FacetedPaginationResponse(message string, data interface{}, pagination interface{}, facets interface{}) interface{} {
+	return TFPSuccessResponse{
+		Meta: TResponseMeta{
+			Success: true,
+			Message: message,
+		},
+		Results:    data,
+		Pagination: pagination,
+		Facets:     facets,
+	}
+}
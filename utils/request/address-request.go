@@ -0,0 +1,23 @@
+package request
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"strings"
+)
+
+func ConvertToAddressRequest(address web.AddressRequest, userID uint) *schema.Address {
+	return &schema.Address{
+		UserID:     userID,
+		Label:      strings.TrimSpace(address.Label),
+		Recipient:  strings.TrimSpace(address.Recipient),
+		HP:         strings.TrimSpace(address.HP),
+		Street:     strings.TrimSpace(address.Street),
+		Province:   strings.TrimSpace(address.Province),
+		City:       strings.TrimSpace(address.City),
+		District:   strings.TrimSpace(address.District),
+		PostalCode: strings.TrimSpace(address.PostalCode),
+		Notes:      strings.TrimSpace(address.Notes),
+		IsDefault:  address.IsDefault,
+	}
+}
@@ -0,0 +1,37 @@
+package request
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToCartItemRequest(item web.CartItemRequest, userID uint) *schema.CartItem {
+	return &schema.CartItem{
+		UserID:     userID,
+		MedicineID: item.MedicineID,
+		Quantity:   item.Quantity,
+	}
+}
+
+func ConvertToCartMedicineTransactionRequest(order web.CartOrderRequest, items []schema.CartItem, userID uint) *schema.MedicineTransaction {
+
+	medicineDetails := make([]schema.MedicineDetails, len(items))
+
+	for i, item := range items {
+		medicineDetails[i] = schema.MedicineDetails{
+			MedicineID: item.MedicineID,
+			Quantity:   item.Quantity,
+		}
+	}
+
+	return &schema.MedicineTransaction{
+		UserID:          userID,
+		Name:            order.Name,
+		Address:         order.Address,
+		HP:              order.HP,
+		PaymentMethod:   order.PaymentMethod,
+		ShippingService: order.ShippingService,
+		VoucherCode:     order.VoucherCode,
+		MedicineDetails: medicineDetails,
+	}
+}
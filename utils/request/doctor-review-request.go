@@ -0,0 +1,16 @@
+package request
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToDoctorReviewRequest(review web.DoctorReviewRequest, transaction *schema.DoctorTransaction) *schema.DoctorReview {
+	return &schema.DoctorReview{
+		DoctorTransactionID: transaction.ID,
+		DoctorID:            transaction.DoctorID,
+		UserID:              transaction.UserID,
+		Rating:              review.Rating,
+		Comment:             review.Comment,
+	}
+}
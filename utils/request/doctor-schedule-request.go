@@ -0,0 +1,32 @@
+package request
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"time"
+)
+
+func ConvertToDoctorScheduleRequest(schedule web.DoctorScheduleRequest, doctorID uint) *schema.DoctorSchedule {
+	slotDuration := schedule.SlotDuration
+	if slotDuration == 0 {
+		slotDuration = 30
+	}
+
+	return &schema.DoctorSchedule{
+		DoctorID:     doctorID,
+		DayOfWeek:    *schedule.DayOfWeek,
+		StartTime:    schedule.StartTime,
+		EndTime:      schedule.EndTime,
+		SlotDuration: slotDuration,
+	}
+}
+
+func ConvertToDoctorScheduleExceptionRequest(exception web.DoctorScheduleExceptionRequest, doctorID uint, date time.Time) *schema.DoctorScheduleException {
+	return &schema.DoctorScheduleException{
+		DoctorID:  doctorID,
+		Date:      date,
+		StartTime: exception.StartTime,
+		EndTime:   exception.EndTime,
+		Reason:    exception.Reason,
+	}
+}
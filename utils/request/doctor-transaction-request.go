@@ -12,5 +12,6 @@ func ConvertToCreateDoctorTransactionRequest(doctorTransaction web.CreateDoctorT
 		Price:               price,
 		PaymentMethod:       doctorTransaction.PaymentMethod,
 		PaymentConfirmation: doctorTransaction.PaymentConfirmation,
+		VoucherCode:         doctorTransaction.VoucherCode,
 	}
 }
@@ -7,15 +7,17 @@ import (
 
 func ConvertToMedicineRequest(medicine web.MedicineRequest) *schema.Medicine {
 	return &schema.Medicine{
-		Code:     medicine.Code,
-		Name:     medicine.Name,
-		Merk:     medicine.Merk,
-		Category: medicine.Category,
-		Type:     medicine.Type,
-		Stock:    medicine.Stock,
-		Price:    medicine.Price,
-		Details:  medicine.Details,
-		Image:    medicine.Image,
+		Code:             medicine.Code,
+		Name:             medicine.Name,
+		Merk:             medicine.Merk,
+		Category:         medicine.Category,
+		Type:             medicine.Type,
+		Stock:            medicine.Stock,
+		ReorderThreshold: medicine.ReorderThreshold,
+		Weight:           medicine.Weight,
+		Price:            medicine.Price,
+		Details:          medicine.Details,
+		Image:            medicine.Image,
 	}
 }
 
@@ -0,0 +1,19 @@
+package request
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"strings"
+)
+
+func ConvertToMedicineCategoryRequest(category web.MedicineCategoryRequest) *schema.MedicineCategory {
+	return &schema.MedicineCategory{
+		Name: strings.TrimSpace(category.Name),
+	}
+}
+
+func ConvertToMedicineTypeRequest(medicineType web.MedicineTypeRequest) *schema.MedicineType {
+	return &schema.MedicineType{
+		Name: strings.TrimSpace(medicineType.Name),
+	}
+}
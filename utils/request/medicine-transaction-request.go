@@ -22,6 +22,32 @@ func ConvertToMedicineTransactionRequest(mt web.MedicineTransactionRequest, user
 		Address:         mt.Address,
 		HP:              mt.HP,
 		PaymentMethod:   mt.PaymentMethod,
+		ShippingService: mt.ShippingService,
+		VoucherCode:     mt.VoucherCode,
+		MedicineDetails: medicineDetails,
+	}
+}
+
+func ConvertToPrescriptionMedicineTransactionRequest(order web.PrescriptionOrderRequest, prescription schema.Prescription, userID uint) *schema.MedicineTransaction {
+
+	medicineDetails := make([]schema.MedicineDetails, len(prescription.PrescriptionDetails))
+
+	for i, pd := range prescription.PrescriptionDetails {
+		medicineDetails[i] = schema.MedicineDetails{
+			MedicineID: pd.MedicineID,
+			Quantity:   pd.Quantity,
+		}
+	}
+
+	return &schema.MedicineTransaction{
+		UserID:          userID,
+		PrescriptionID:  &prescription.ID,
+		Name:            order.Name,
+		Address:         order.Address,
+		HP:              order.HP,
+		PaymentMethod:   order.PaymentMethod,
+		ShippingService: order.ShippingService,
+		VoucherCode:     order.VoucherCode,
 		MedicineDetails: medicineDetails,
 	}
 }
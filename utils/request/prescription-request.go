@@ -1,2 +1,29 @@
 package request
 
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToPrescriptionRequest(prescription web.PrescriptionRequest, transaction schema.DoctorTransaction) *schema.Prescription {
+
+	prescriptionDetails := make([]schema.PrescriptionDetails, len(prescription.PrescriptionDetails))
+
+	for i, pdReq := range prescription.PrescriptionDetails {
+		prescriptionDetails[i] = schema.PrescriptionDetails{
+			MedicineID: pdReq.MedicineID,
+			Quantity:   pdReq.Quantity,
+			Dosage:     pdReq.Dosage,
+			Frequency:  pdReq.Frequency,
+			Duration:   pdReq.Duration,
+		}
+	}
+
+	return &schema.Prescription{
+		DoctorTransactionID: transaction.ID,
+		DoctorID:            transaction.DoctorID,
+		UserID:              transaction.UserID,
+		Notes:               prescription.Notes,
+		PrescriptionDetails: prescriptionDetails,
+	}
+}
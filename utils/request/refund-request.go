@@ -0,0 +1,37 @@
+package request
+
+import "healthcare/models/schema"
+
+func ConvertToDoctorTransactionRefundRequest(doctorTransaction *schema.DoctorTransaction, amount int, reason, method, requestedBy string) *schema.Refund {
+	if amount == 0 {
+		amount = doctorTransaction.Price
+	}
+	if method == "" {
+		method = doctorTransaction.PaymentMethod
+	}
+	return &schema.Refund{
+		DoctorTransactionID: &doctorTransaction.ID,
+		UserID:              doctorTransaction.UserID,
+		Amount:              amount,
+		Reason:              reason,
+		Method:              method,
+		RequestedBy:         requestedBy,
+	}
+}
+
+func ConvertToCheckoutRefundRequest(checkout *schema.Checkout, amount int, reason, method, requestedBy string) *schema.Refund {
+	if amount == 0 {
+		amount = checkout.MedicineTransaction.TotalPrice
+	}
+	if method == "" {
+		method = checkout.MedicineTransaction.PaymentMethod
+	}
+	return &schema.Refund{
+		CheckoutID:  &checkout.ID,
+		UserID:      checkout.MedicineTransaction.UserID,
+		Amount:      amount,
+		Reason:      reason,
+		Method:      method,
+		RequestedBy: requestedBy,
+	}
+}
@@ -1,6 +1,9 @@
 package request
 
-import "healthcare/models/schema"
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
 
 func CreateRoomchatRequest(transactionID uint) schema.Roomchat {
 	return schema.Roomchat{
@@ -12,4 +15,12 @@ func ConvertToGetAllRoomchatRequest(userID uint,  fullname string,) *schema.Doct
 	return &schema.DoctorTransaction{
 		UserID:              userID,
 	}
-}
\ No newline at end of file
+}
+func ConvertToRoomchatExtensionRequest(extension web.RoomchatExtensionRequest, roomchatID, doctorID uint, price int) *schema.RoomchatExtension {
+	return &schema.RoomchatExtension{
+		RoomchatID: roomchatID,
+		DoctorID:   doctorID,
+		Minutes:    extension.Minutes,
+		Price:      price,
+	}
+}
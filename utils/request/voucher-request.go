@@ -0,0 +1,43 @@
+package request
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"strings"
+	"time"
+)
+
+func ConvertToVoucherRequest(v web.VoucherRequest, startsAt, endsAt time.Time) *schema.Voucher {
+
+	var targets []schema.VoucherTarget
+	for i := range v.DoctorIDs {
+		targets = append(targets, schema.VoucherTarget{DoctorID: &v.DoctorIDs[i]})
+	}
+	for _, specialist := range v.Specialists {
+		targets = append(targets, schema.VoucherTarget{Specialist: strings.TrimSpace(specialist)})
+	}
+	for i := range v.CategoryIDs {
+		targets = append(targets, schema.VoucherTarget{MedicineCategoryID: &v.CategoryIDs[i]})
+	}
+
+	isActive := true
+	if v.IsActive != nil {
+		isActive = *v.IsActive
+	}
+
+	return &schema.Voucher{
+		Code:         strings.ToUpper(strings.TrimSpace(v.Code)),
+		Description:  v.Description,
+		Scope:        v.Scope,
+		DiscountType: v.DiscountType,
+		Value:        v.Value,
+		MinSpend:     v.MinSpend,
+		MaxDiscount:  v.MaxDiscount,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		UsageLimit:   v.UsageLimit,
+		PerUserLimit: v.PerUserLimit,
+		IsActive:     isActive,
+		Targets:      targets,
+	}
+}
@@ -0,0 +1,31 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToAddressResponse(address *schema.Address) *web.AddressResponse {
+	return &web.AddressResponse{
+		ID:         address.ID,
+		Label:      address.Label,
+		Recipient:  address.Recipient,
+		HP:         address.HP,
+		Street:     address.Street,
+		Province:   address.Province,
+		City:       address.City,
+		District:   address.District,
+		PostalCode: address.PostalCode,
+		Notes:      address.Notes,
+		IsDefault:  address.IsDefault,
+		CreatedAt:  address.CreatedAt,
+	}
+}
+
+func ConvertToAddressListResponse(addresses []schema.Address) []web.AddressResponse {
+	var results []web.AddressResponse
+	for i := range addresses {
+		results = append(results, *ConvertToAddressResponse(&addresses[i]))
+	}
+	return results
+}
@@ -37,6 +37,7 @@ func ConvertToAdminTransactionUsersResponse(transactions []schema.DoctorTransact
 			CreatedAt:           transaction.CreatedAt,
 			PaymentConfirmation: transaction.PaymentConfirmation,
 			PaymentStatus:       transaction.PaymentStatus,
+			ConsultationStatus:  transaction.ConsultationStatus,
 		}
 		results = append(results, adminsResponse)
 	}
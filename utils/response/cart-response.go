@@ -0,0 +1,41 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+// ConvertToCartItemResponse shows a cart line at the current price and stock, a deleted medicine is no longer available
+func ConvertToCartItemResponse(item *schema.CartItem) *web.CartItemResponse {
+	return &web.CartItemResponse{
+		ID:           item.ID,
+		MedicineID:   item.MedicineID,
+		Code:         item.Medicine.Code,
+		Name:         item.Medicine.Name,
+		Image:        item.Medicine.Image,
+		Price:        item.Medicine.Price,
+		PriceAtAdd:   item.PriceAtAdd,
+		PriceChanged: item.Medicine.ID != 0 && item.Medicine.Price != item.PriceAtAdd,
+		Stock:        item.Medicine.Stock,
+		Available:    item.Medicine.ID != 0 && item.Medicine.Stock >= item.Quantity,
+		Quantity:     item.Quantity,
+		TotalPrice:   item.Quantity * item.Medicine.Price,
+		UpdatedAt:    item.UpdatedAt,
+	}
+}
+
+func ConvertToCartResponse(items []schema.CartItem) *web.CartResponse {
+	cart := web.CartResponse{
+		Items:     []web.CartItemResponse{},
+		Available: true,
+	}
+	for i := range items {
+		item := ConvertToCartItemResponse(&items[i])
+		cart.Items = append(cart.Items, *item)
+		cart.TotalQuantity += item.Quantity
+		cart.TotalPrice += item.TotalPrice
+		cart.PriceChanged = cart.PriceChanged || item.PriceChanged
+		cart.Available = cart.Available && item.Available
+	}
+	return &cart
+}
@@ -21,8 +21,21 @@ func ConvertToGetCheckoutResponse(checkout *schema.Checkout) web.CheckoutRespons
 		Name:                    checkout.MedicineTransaction.Name,
 		Address:                 checkout.MedicineTransaction.Address,
 		HP:                      checkout.MedicineTransaction.HP,
+		AddressID:               checkout.MedicineTransaction.AddressID,
+		Province:                checkout.MedicineTransaction.Province,
+		City:                    checkout.MedicineTransaction.City,
+		District:                checkout.MedicineTransaction.District,
+		PostalCode:              checkout.MedicineTransaction.PostalCode,
+		AddressNotes:            checkout.MedicineTransaction.AddressNotes,
 		PaymentMethod:           checkout.MedicineTransaction.PaymentMethod,
 		MedicineDetailsResponse: medicineDetailsResponse,
+		Subtotal:                checkout.MedicineTransaction.TotalPrice - checkout.MedicineTransaction.ShippingFee + checkout.MedicineTransaction.Discount,
+		ShippingProvider:        checkout.MedicineTransaction.ShippingProvider,
+		ShippingService:         checkout.MedicineTransaction.ShippingService,
+		ShippingWeight:          checkout.MedicineTransaction.ShippingWeight,
+		ShippingFee:             checkout.MedicineTransaction.ShippingFee,
+		VoucherCode:             checkout.MedicineTransaction.VoucherCode,
+		Discount:                checkout.MedicineTransaction.Discount,
 		TotalPrice:              checkout.MedicineTransaction.TotalPrice,
 		StatusTransaction:       checkout.MedicineTransaction.StatusTransaction,
 	}
@@ -30,10 +43,19 @@ func ConvertToGetCheckoutResponse(checkout *schema.Checkout) web.CheckoutRespons
 	return web.CheckoutResponse{
 		ID:                       checkout.ID,
 		PaymentStatus:            checkout.PaymentStatus,
+		RefundStatus:             checkout.RefundStatus,
 		MedicineTransactionID:    checkout.MedicineTransactionID,
 		MedicineCheckoutResponse: MedicineCheckoutResponse,
 		CreatedAt:                checkout.CreatedAt,
 		PaymentConfirmation:      checkout.PaymentConfirmation,
+		PaymentProvider:          checkout.PaymentProvider,
+		PaymentReference:         checkout.PaymentReference,
+		FulfilmentStatus:         checkout.FulfilmentStatus,
+		Courier:                  checkout.Courier,
+		TrackingNumber:           checkout.TrackingNumber,
+		ShippedAt:                checkout.ShippedAt,
+		DeliveredAt:              checkout.DeliveredAt,
+		Timeline:                 ConvertToFulfilmentEventListResponse(checkout.FulfilmentEvents),
 	}
 }
 
@@ -46,4 +68,4 @@ func ConvertToGetAllCheckoutResponse(checkouts []schema.Checkout) []web.Checkout
 	}
 
 	return responses
-}
\ No newline at end of file
+}
@@ -0,0 +1,59 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"time"
+)
+
+func ConvertToConsultationHistoryResponse(transaction schema.DoctorTransaction, doctor schema.Doctor, hasPrescription bool) web.ConsultationHistoryResponse {
+	return web.ConsultationHistoryResponse{
+		TransactionID: transaction.ID,
+		RoomchatID:    transaction.Roomchat.ID,
+		Doctor: web.ConsultationDoctorResponse{
+			ID:             doctor.ID,
+			Fullname:       doctor.Fullname,
+			Specialist:     doctor.Specialist,
+			ProfilePicture: doctor.ProfilePicture,
+		},
+		HealthDetails:      transaction.HealthDetails,
+		PatientStatus:      transaction.PatientStatus,
+		ConsultationStatus: transaction.ConsultationStatus,
+		ClosingSummary:     transaction.Roomchat.ClosingSummary,
+		HasPrescription:    hasPrescription,
+		StartedAt:          transaction.Roomchat.CreatedAt,
+		EndedAt:            transaction.Roomchat.ExpirationTime,
+	}
+}
+
+func ConvertToConsultationTranscript(transaction schema.DoctorTransaction, doctor schema.Doctor, user schema.User, messages []schema.Message, prescription *schema.Prescription) web.ConsultationTranscript {
+
+	transcript := web.ConsultationTranscript{
+		Consultation: ConvertToConsultationHistoryResponse(transaction, doctor, prescription != nil),
+		Patient:      user.Fullname,
+		GeneratedAt:  time.Now(),
+	}
+
+	for _, message := range messages {
+		sender, role := user.Fullname, "user"
+		if message.DoctorID != 0 {
+			sender, role = doctor.Fullname, "doctor"
+		}
+
+		transcript.Messages = append(transcript.Messages, web.ConsultationTranscriptMessage{
+			Sender:    sender,
+			Role:      role,
+			Message:   message.Message,
+			Image:     message.Image,
+			Audio:     message.Audio,
+			CreatedAt: message.CreatedAt,
+		})
+	}
+
+	if prescription != nil {
+		prescriptionResponse := ConvertToPrescriptionResponse(prescription)
+		transcript.Prescription = &prescriptionResponse
+	}
+
+	return transcript
+}
@@ -0,0 +1,22 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToConsultationTransitionListResponse(transitions []schema.ConsultationTransition) []web.ConsultationTransitionResponse {
+	var results []web.ConsultationTransitionResponse
+	for _, transition := range transitions {
+		results = append(results, web.ConsultationTransitionResponse{
+			ID:         transition.ID,
+			FromStatus: transition.FromStatus,
+			ToStatus:   transition.ToStatus,
+			ActorRole:  transition.ActorRole,
+			ActorID:    transition.ActorID,
+			Note:       transition.Note,
+			CreatedAt:  transition.CreatedAt,
+		})
+	}
+	return results
+}
@@ -85,7 +85,7 @@ func ConvertToGetAllDoctorByAdminResponse(doctors []schema.Doctor) []web.DoctorA
 	return results
 }
 
-func ConvertToGetIDDoctorResponse(doctor *schema.Doctor) web.DoctorIDResponse {
+func ConvertToGetIDDoctorResponse(doctor *schema.Doctor, rating float64, reviewCount int64) web.DoctorIDResponse {
 	return web.DoctorIDResponse{
 		ID:               doctor.ID,
 		ProfilePicture:   doctor.ProfilePicture,
@@ -97,6 +97,8 @@ func ConvertToGetIDDoctorResponse(doctor *schema.Doctor) web.DoctorIDResponse {
 		NoSTR:            doctor.NoSTR,
 		Alumnus:          doctor.Alumnus,
 		LocationPractice: doctor.LocationPractice,
+		Rating:           rating,
+		ReviewCount:      reviewCount,
 	}
 }
 func ConvertToGetDoctorbyAdminResponse(doctor *schema.Doctor) web.DoctorIDResponseByAdmin {
@@ -123,6 +125,7 @@ func ConvertToManageUserResponse(managePatient schema.DoctorTransaction, user sc
 		CreatedAt:           managePatient.CreatedAt,
 		HealthDetails:       managePatient.HealthDetails,
 		PatientStatus:       managePatient.PatientStatus,
+		ConsultationStatus:  managePatient.ConsultationStatus,
 	}
 }
 
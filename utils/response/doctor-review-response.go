@@ -0,0 +1,40 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToDoctorReviewResponse(review *schema.DoctorReview) web.DoctorReviewResponse {
+	return web.DoctorReviewResponse{
+		ID:                  review.ID,
+		DoctorTransactionID: review.DoctorTransactionID,
+		DoctorID:            review.DoctorID,
+		UserID:              review.UserID,
+		Rating:              review.Rating,
+		Comment:             review.Comment,
+		Status:              review.Status,
+		ModerationReason:    review.ModerationReason,
+		CreatedAt:           review.CreatedAt,
+		UpdatedAt:           review.UpdatedAt,
+	}
+}
+
+func ConvertToDoctorReviewListResponse(reviews []schema.DoctorReview) []web.DoctorReviewResponse {
+	var results []web.DoctorReviewResponse
+	for _, review := range reviews {
+		results = append(results, ConvertToDoctorReviewResponse(&review))
+	}
+	return results
+}
+
+func ConvertToDoctorPublicReviewResponse(review schema.DoctorReview, user schema.User) web.DoctorPublicReviewResponse {
+	return web.DoctorPublicReviewResponse{
+		ID:        review.ID,
+		Fullname:  user.Fullname,
+		Rating:    review.Rating,
+		Comment:   review.Comment,
+		CreatedAt: review.CreatedAt,
+		UpdatedAt: review.UpdatedAt,
+	}
+}
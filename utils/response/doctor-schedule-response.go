@@ -0,0 +1,54 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToDoctorScheduleResponse(schedule *schema.DoctorSchedule) web.DoctorScheduleResponse {
+	return web.DoctorScheduleResponse{
+		ID:           schedule.ID,
+		DayOfWeek:    schedule.DayOfWeek,
+		StartTime:    schedule.StartTime,
+		EndTime:      schedule.EndTime,
+		SlotDuration: schedule.SlotDuration,
+	}
+}
+
+func ConvertToDoctorScheduleListResponse(schedules []schema.DoctorSchedule) []web.DoctorScheduleResponse {
+	var results []web.DoctorScheduleResponse
+	for _, schedule := range schedules {
+		results = append(results, ConvertToDoctorScheduleResponse(&schedule))
+	}
+	return results
+}
+
+func ConvertToDoctorScheduleExceptionResponse(exception *schema.DoctorScheduleException) web.DoctorScheduleExceptionResponse {
+	return web.DoctorScheduleExceptionResponse{
+		ID:        exception.ID,
+		Date:      exception.Date.Format("2006-01-02"),
+		StartTime: exception.StartTime,
+		EndTime:   exception.EndTime,
+		Reason:    exception.Reason,
+	}
+}
+
+func ConvertToDoctorScheduleExceptionListResponse(exceptions []schema.DoctorScheduleException) []web.DoctorScheduleExceptionResponse {
+	var results []web.DoctorScheduleExceptionResponse
+	for _, exception := range exceptions {
+		results = append(results, ConvertToDoctorScheduleExceptionResponse(&exception))
+	}
+	return results
+}
+
+func ConvertToDoctorSlotListResponse(slots []schema.DoctorSlot) []web.DoctorSlotResponse {
+	var results []web.DoctorSlotResponse
+	for _, slot := range slots {
+		results = append(results, web.DoctorSlotResponse{
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Available: slot.DoctorTransactionID == 0,
+		})
+	}
+	return results
+}
@@ -10,10 +10,19 @@ func ConvertToCreateDoctorTransactionResponse(doctorTransaction *schema.DoctorTr
 		ID:                  doctorTransaction.ID,
 		Fullname:            doctor.Fullname,
 		Specialist:          doctor.Specialist,
-		Price:               doctor.Price,
+		Price:               doctorTransaction.Price + doctorTransaction.Discount,
+		VoucherCode:         doctorTransaction.VoucherCode,
+		Discount:            doctorTransaction.Discount,
+		TotalPrice:          doctorTransaction.Price,
 		PaymentMethod:       doctorTransaction.PaymentMethod,
+		PaymentProvider:     doctorTransaction.PaymentProvider,
+		PaymentReference:    doctorTransaction.PaymentReference,
 		PaymentStatus:       doctorTransaction.PaymentStatus,
+		RefundStatus:        doctorTransaction.RefundStatus,
+		ConsultationStatus:  doctorTransaction.ConsultationStatus,
 		PaymentConfirmation: doctorTransaction.PaymentConfirmation,
+		ScheduleStart:       doctorTransaction.ScheduleStart,
+		ScheduleEnd:         doctorTransaction.ScheduleEnd,
 		CreatedAt:           doctorTransaction.CreatedAt,
 	}
 }
@@ -23,10 +32,19 @@ func ConvertToGetDoctorTransactionResponse(doctorTransaction schema.DoctorTransa
 		ID:                  doctorTransaction.ID,
 		Fullname:            doctor.Fullname,
 		Specialist:          doctor.Specialist,
-		Price:               doctor.Price,
+		Price:               doctorTransaction.Price + doctorTransaction.Discount,
+		VoucherCode:         doctorTransaction.VoucherCode,
+		Discount:            doctorTransaction.Discount,
+		TotalPrice:          doctorTransaction.Price,
 		PaymentMethod:       doctorTransaction.PaymentMethod,
+		PaymentProvider:     doctorTransaction.PaymentProvider,
+		PaymentReference:    doctorTransaction.PaymentReference,
 		PaymentStatus:       doctorTransaction.PaymentStatus,
+		RefundStatus:        doctorTransaction.RefundStatus,
+		ConsultationStatus:  doctorTransaction.ConsultationStatus,
 		PaymentConfirmation: doctorTransaction.PaymentConfirmation,
+		ScheduleStart:       doctorTransaction.ScheduleStart,
+		ScheduleEnd:         doctorTransaction.ScheduleEnd,
 		CreatedAt:           doctorTransaction.CreatedAt,
 	}
 }
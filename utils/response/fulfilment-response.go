@@ -0,0 +1,24 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+// ConvertToFulfilmentEventListResponse keeps the admin id out of the timeline users can see
+func ConvertToFulfilmentEventListResponse(events []schema.FulfilmentEvent) []web.FulfilmentEventResponse {
+	var results []web.FulfilmentEventResponse
+	for _, event := range events {
+		results = append(results, web.FulfilmentEventResponse{
+			ID:             event.ID,
+			FromStatus:     event.FromStatus,
+			ToStatus:       event.ToStatus,
+			Courier:        event.Courier,
+			TrackingNumber: event.TrackingNumber,
+			ActorRole:      event.ActorRole,
+			Note:           event.Note,
+			CreatedAt:      event.CreatedAt,
+		})
+	}
+	return results
+}
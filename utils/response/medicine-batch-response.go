@@ -0,0 +1,32 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+	"healthcare/utils/helper/stock"
+	"math"
+)
+
+func ConvertToMedicineBatchResponse(batch *schema.MedicineBatch) *web.MedicineBatchResponse {
+	return &web.MedicineBatchResponse{
+		ID:              batch.ID,
+		MedicineID:      batch.MedicineID,
+		MedicineCode:    batch.Medicine.Code,
+		MedicineName:    batch.Medicine.Name,
+		BatchNumber:     batch.BatchNumber,
+		ExpiryDate:      batch.ExpiryDate.Format("2006-01-02"),
+		DaysToExpiry:    int(math.Round(batch.ExpiryDate.Sub(stock.Today()).Hours() / 24)),
+		Expired:         stock.Expired(*batch),
+		InitialQuantity: batch.InitialQuantity,
+		Quantity:        batch.Quantity,
+		CreatedAt:       batch.CreatedAt,
+	}
+}
+
+func ConvertToMedicineBatchListResponse(batches []schema.MedicineBatch) []web.MedicineBatchResponse {
+	var results []web.MedicineBatchResponse
+	for i := range batches {
+		results = append(results, *ConvertToMedicineBatchResponse(&batches[i]))
+	}
+	return results
+}
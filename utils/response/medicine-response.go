@@ -7,32 +7,40 @@ import (
 
 func ConvertToAdminMedicineResponse(medicine *schema.Medicine) web.MedicineResponse {
 	return web.MedicineResponse{
-		ID:        medicine.ID,
-		Code:      medicine.Code,
-		Name:      medicine.Name,
-		Merk:      medicine.Merk,
-		Category:  medicine.Category,
-		Type:      medicine.Type,
-		Stock:     medicine.Stock,
-		Price:     medicine.Price,
-		Details:   medicine.Details,
-		Image:     medicine.Image,
-		CreatedAt: medicine.CreatedAt,
+		ID:               medicine.ID,
+		Code:             medicine.Code,
+		Name:             medicine.Name,
+		Merk:             medicine.Merk,
+		CategoryID:       medicine.CategoryID,
+		Category:         medicine.Category,
+		TypeID:           medicine.TypeID,
+		Type:             medicine.Type,
+		Stock:            medicine.Stock,
+		ReorderThreshold: medicine.ReorderThreshold,
+		Weight:           medicine.Weight,
+		Price:            medicine.Price,
+		Details:          medicine.Details,
+		Image:            medicine.Image,
+		CreatedAt:        medicine.CreatedAt,
 	}
 }
 
 func ConvertToAdminMedicineUpdateResponse(medicine *schema.Medicine) web.MedicineUpdateResponse {
 	return web.MedicineUpdateResponse{
-		ID:        medicine.ID,
-		Code:      medicine.Code,
-		Name:      medicine.Name,
-		Merk:      medicine.Merk,
-		Category:  medicine.Category,
-		Type:      medicine.Type,
-		Stock:     medicine.Stock,
-		Price:     medicine.Price,
-		Details:   medicine.Details,
-		CreatedAt: medicine.CreatedAt,
+		ID:               medicine.ID,
+		Code:             medicine.Code,
+		Name:             medicine.Name,
+		Merk:             medicine.Merk,
+		CategoryID:       medicine.CategoryID,
+		Category:         medicine.Category,
+		TypeID:           medicine.TypeID,
+		Type:             medicine.Type,
+		Stock:            medicine.Stock,
+		ReorderThreshold: medicine.ReorderThreshold,
+		Weight:           medicine.Weight,
+		Price:            medicine.Price,
+		Details:          medicine.Details,
+		CreatedAt:        medicine.CreatedAt,
 	}
 }
 
@@ -40,17 +48,21 @@ func ConvertToAdminGetAllMedicinesResponse(medicines []schema.Medicine) []web.Me
 	var results []web.MedicineResponse
 	for _, medicine := range medicines {
 		medicineResponse := web.MedicineResponse{
-			ID:        medicine.ID,
-			Code:      medicine.Code,
-			Name:      medicine.Name,
-			Merk:      medicine.Merk,
-			Category:  medicine.Category,
-			Type:      medicine.Type,
-			Stock:     medicine.Stock,
-			Price:     medicine.Price,
-			Details:   medicine.Details,
-			Image:     medicine.Image,
-			CreatedAt: medicine.CreatedAt,
+			ID:               medicine.ID,
+			Code:             medicine.Code,
+			Name:             medicine.Name,
+			Merk:             medicine.Merk,
+			CategoryID:       medicine.CategoryID,
+			Category:         medicine.Category,
+			TypeID:           medicine.TypeID,
+			Type:             medicine.Type,
+			Stock:            medicine.Stock,
+			ReorderThreshold: medicine.ReorderThreshold,
+			Weight:           medicine.Weight,
+			Price:            medicine.Price,
+			Details:          medicine.Details,
+			Image:            medicine.Image,
+			CreatedAt:        medicine.CreatedAt,
 		}
 		results = append(results, medicineResponse)
 	}
@@ -65,16 +77,18 @@ func ConvertToAdminMedicineImageResponse(medicine *schema.Medicine) web.Medicine
 
 func ConvertToUserMedicineResponse(medicine *schema.Medicine) web.MedicineUserResponse {
 	return web.MedicineUserResponse{
-		ID:       medicine.ID,
-		Name:     medicine.Name,
-		Code:     medicine.Code,
-		Merk:     medicine.Merk,
-		Category: medicine.Category,
-		Type:     medicine.Type,
-		Stock:    medicine.Stock,
-		Price:    medicine.Price,
-		Details:  medicine.Details,
-		Image:    medicine.Image,
+		ID:         medicine.ID,
+		Name:       medicine.Name,
+		Code:       medicine.Code,
+		Merk:       medicine.Merk,
+		CategoryID: medicine.CategoryID,
+		Category:   medicine.Category,
+		TypeID:     medicine.TypeID,
+		Type:       medicine.Type,
+		Stock:      medicine.Stock,
+		Price:      medicine.Price,
+		Details:    medicine.Details,
+		Image:      medicine.Image,
 	}
 }
 
@@ -82,16 +96,18 @@ func ConvertToUserGetAllMedicinesResponse(medicines []schema.Medicine) []web.Med
 	var results []web.MedicineUserResponse
 	for _, medicine := range medicines {
 		medicineResponse := web.MedicineUserResponse{
-			ID:       medicine.ID,
-			Code:     medicine.Code,
-			Name:     medicine.Name,
-			Merk:     medicine.Merk,
-			Category: medicine.Category,
-			Type:     medicine.Type,
-			Stock:    medicine.Stock,
-			Price:    medicine.Price,
-			Details:  medicine.Details,
-			Image:    medicine.Image,
+			ID:         medicine.ID,
+			Code:       medicine.Code,
+			Name:       medicine.Name,
+			Merk:       medicine.Merk,
+			CategoryID: medicine.CategoryID,
+			Category:   medicine.Category,
+			TypeID:     medicine.TypeID,
+			Type:       medicine.Type,
+			Stock:      medicine.Stock,
+			Price:      medicine.Price,
+			Details:    medicine.Details,
+			Image:      medicine.Image,
 		}
 		results = append(results, medicineResponse)
 	}
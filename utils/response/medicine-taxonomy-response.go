@@ -0,0 +1,38 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToMedicineCategoryResponse(category *schema.MedicineCategory) *web.MedicineCategoryResponse {
+	return &web.MedicineCategoryResponse{
+		ID:        category.ID,
+		Name:      category.Name,
+		CreatedAt: category.CreatedAt,
+	}
+}
+
+func ConvertToMedicineCategoryListResponse(categories []schema.MedicineCategory) []web.MedicineCategoryResponse {
+	var results []web.MedicineCategoryResponse
+	for i := range categories {
+		results = append(results, *ConvertToMedicineCategoryResponse(&categories[i]))
+	}
+	return results
+}
+
+func ConvertToMedicineTypeResponse(medicineType *schema.MedicineType) *web.MedicineTypeResponse {
+	return &web.MedicineTypeResponse{
+		ID:        medicineType.ID,
+		Name:      medicineType.Name,
+		CreatedAt: medicineType.CreatedAt,
+	}
+}
+
+func ConvertToMedicineTypeListResponse(medicineTypes []schema.MedicineType) []web.MedicineTypeResponse {
+	var results []web.MedicineTypeResponse
+	for i := range medicineTypes {
+		results = append(results, *ConvertToMedicineTypeResponse(&medicineTypes[i]))
+	}
+	return results
+}
@@ -19,13 +19,29 @@ func ConvertToMedicineTransactionResponse(mt *schema.MedicineTransaction) *web.M
 	return &web.MedicineTransactionResponse{
 		ID:                      mt.ID,
 		UserID:                  mt.UserID,
+		PrescriptionID:          mt.PrescriptionID,
 		Name:                    mt.Name,
 		Address:                 mt.Address,
 		HP:                      mt.HP,
+		AddressID:               mt.AddressID,
+		Province:                mt.Province,
+		City:                    mt.City,
+		District:                mt.District,
+		PostalCode:              mt.PostalCode,
+		AddressNotes:            mt.AddressNotes,
 		PaymentMethod:           mt.PaymentMethod,
 		MedicineDetailsResponse: medicineDetailsResponse,
+		Subtotal:                mt.TotalPrice - mt.ShippingFee + mt.Discount,
+		ShippingProvider:        mt.ShippingProvider,
+		ShippingService:         mt.ShippingService,
+		ShippingWeight:          mt.ShippingWeight,
+		ShippingFee:             mt.ShippingFee,
+		VoucherCode:             mt.VoucherCode,
+		Discount:                mt.Discount,
 		TotalPrice:              mt.TotalPrice,
 		StatusTransaction:       mt.StatusTransaction,
+		ReservationStatus:       mt.ReservationStatus,
+		ReservedUntil:           mt.ReservedUntil,
 		CreatedAt:               mt.CreatedAt,
 	}
 }
@@ -44,16 +60,32 @@ func ConvertToMedicineTransactionListResponse(medicineTransactions []schema.Medi
 		medicineTransactionResponse := web.MedicineTransactionResponse{
 			ID:                      mt.ID,
 			UserID:                  mt.UserID,
+			PrescriptionID:          mt.PrescriptionID,
 			Name:                    mt.Name,
 			Address:                 mt.Address,
 			HP:                      mt.HP,
+			AddressID:               mt.AddressID,
+			Province:                mt.Province,
+			City:                    mt.City,
+			District:                mt.District,
+			PostalCode:              mt.PostalCode,
+			AddressNotes:            mt.AddressNotes,
 			PaymentMethod:           mt.PaymentMethod,
 			MedicineDetailsResponse: medicineDetailsResponse,
+			Subtotal:                mt.TotalPrice - mt.ShippingFee + mt.Discount,
+			ShippingProvider:        mt.ShippingProvider,
+			ShippingService:         mt.ShippingService,
+			ShippingWeight:          mt.ShippingWeight,
+			ShippingFee:             mt.ShippingFee,
+			VoucherCode:             mt.VoucherCode,
+			Discount:                mt.Discount,
 			TotalPrice:              mt.TotalPrice,
 			StatusTransaction:       mt.StatusTransaction,
+			ReservationStatus:       mt.ReservationStatus,
+			ReservedUntil:           mt.ReservedUntil,
 			CreatedAt:               mt.CreatedAt,
 		}
 		results = append(results, medicineTransactionResponse)
 	}
 	return results
-}
\ No newline at end of file
+}
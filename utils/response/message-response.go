@@ -17,3 +17,11 @@ func ConvertToCreateMessageResponse(message *schema.Message) web.CreateMessageRe
 		CreatedAt:  message.CreatedAt,
 	}
 }
+
+func ConvertToMessageListResponse(messages []schema.Message) []web.CreateMessageResponse {
+	results := make([]web.CreateMessageResponse, len(messages))
+	for i := range messages {
+		results[i] = ConvertToCreateMessageResponse(&messages[i])
+	}
+	return results
+}
@@ -0,0 +1,24 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToPaymentEventListResponse(paymentEvents []schema.PaymentEvent) []web.PaymentEventResponse {
+	var results []web.PaymentEventResponse
+	for _, paymentEvent := range paymentEvents {
+		results = append(results, web.PaymentEventResponse{
+			ID:                paymentEvent.ID,
+			Provider:          paymentEvent.Provider,
+			EventID:           paymentEvent.EventID,
+			ProviderReference: paymentEvent.ProviderReference,
+			Status:            paymentEvent.Status,
+			Deliveries:        paymentEvent.Deliveries,
+			ProcessedAt:       paymentEvent.ProcessedAt,
+			Error:             paymentEvent.Error,
+			CreatedAt:         paymentEvent.CreatedAt,
+		})
+	}
+	return results
+}
@@ -1,2 +1,40 @@
 package response
 
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToPrescriptionResponse(prescription *schema.Prescription) web.PrescriptionResponse {
+	prescriptionDetailsResponse := make([]web.PrescriptionDetailsResponse, len(prescription.PrescriptionDetails))
+
+	for i, pd := range prescription.PrescriptionDetails {
+		prescriptionDetailsResponse[i] = web.PrescriptionDetailsResponse{
+			MedicineID: pd.MedicineID,
+			Name:       pd.Medicine.Name,
+			Image:      pd.Medicine.Image,
+			Quantity:   pd.Quantity,
+			Dosage:     pd.Dosage,
+			Frequency:  pd.Frequency,
+			Duration:   pd.Duration,
+		}
+	}
+
+	return web.PrescriptionResponse{
+		ID:                  prescription.ID,
+		TransactionID:       prescription.DoctorTransactionID,
+		DoctorID:            prescription.DoctorID,
+		UserID:              prescription.UserID,
+		Notes:               prescription.Notes,
+		PrescriptionDetails: prescriptionDetailsResponse,
+		CreatedAt:           prescription.CreatedAt,
+	}
+}
+
+func ConvertToPrescriptionListResponse(prescriptions []schema.Prescription) []web.PrescriptionResponse {
+	var results []web.PrescriptionResponse
+	for _, prescription := range prescriptions {
+		results = append(results, ConvertToPrescriptionResponse(&prescription))
+	}
+	return results
+}
@@ -0,0 +1,32 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToRefundResponse(refund *schema.Refund) web.RefundResponse {
+	return web.RefundResponse{
+		ID:            refund.ID,
+		TransactionID: refund.DoctorTransactionID,
+		CheckoutID:    refund.CheckoutID,
+		ExtensionID:   refund.RoomchatExtensionID,
+		UserID:        refund.UserID,
+		Amount:        refund.Amount,
+		Reason:        refund.Reason,
+		Method:        refund.Method,
+		Status:        refund.Status,
+		RequestedBy:   refund.RequestedBy,
+		Note:          refund.Note,
+		ProcessedAt:   refund.ProcessedAt,
+		CreatedAt:     refund.CreatedAt,
+	}
+}
+
+func ConvertToRefundListResponse(refunds []schema.Refund) []web.RefundResponse {
+	var results []web.RefundResponse
+	for _, refund := range refunds {
+		results = append(results, ConvertToRefundResponse(&refund))
+	}
+	return results
+}
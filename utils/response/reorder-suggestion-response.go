@@ -0,0 +1,31 @@
+package response
+
+import (
+	"healthcare/models/web"
+	"healthcare/utils/helper/stock"
+	"math"
+)
+
+func ConvertToReorderSuggestionListResponse(suggestions []stock.Suggestion) []web.ReorderSuggestionResponse {
+	var results []web.ReorderSuggestionResponse
+	for _, suggestion := range suggestions {
+		var daysOfStock *float64
+		if suggestion.DaysOfStock != nil {
+			rounded := math.Round(*suggestion.DaysOfStock*10) / 10
+			daysOfStock = &rounded
+		}
+
+		results = append(results, web.ReorderSuggestionResponse{
+			MedicineID:        suggestion.Medicine.ID,
+			Code:              suggestion.Medicine.Code,
+			Name:              suggestion.Medicine.Name,
+			Stock:             suggestion.Medicine.Stock,
+			ReorderThreshold:  suggestion.Medicine.ReorderThreshold,
+			Sold:              suggestion.Sold,
+			DailySales:        math.Round(suggestion.DailySales*100) / 100,
+			DaysOfStock:       daysOfStock,
+			SuggestedQuantity: suggestion.SuggestedQuantity,
+		})
+	}
+	return results
+}
@@ -4,6 +4,7 @@ import (
 	"healthcare/models/schema"
 	"healthcare/models/web"
 	"healthcare/utils/helper"
+	"time"
 )
 
 func ConvertToCreateRoomchatResponse(roomchat *schema.Roomchat) web.CreateRoomchatResponse {
@@ -23,6 +24,7 @@ func ConvertToRoomchatUserResponse(roomchat *schema.Roomchat, doctor *schema.Doc
 		CreatedAt:      roomchat.CreatedAt,
 		Status:         roomchat.Status,
 		ExpirationTime: roomchat.ExpirationTime,
+		ClosingSummary: roomchat.ClosingSummary,
 	}
 
 	var results []web.CreateMessageResponse
@@ -59,6 +61,7 @@ func ConvertToRoomchatDoctorResponse(roomchat *schema.Roomchat, user *schema.Use
 		CreatedAt:      roomchat.CreatedAt,
 		Status:         roomchat.Status,
 		ExpirationTime: roomchat.ExpirationTime,
+		ClosingSummary: roomchat.ClosingSummary,
 	}
 
 	var results []web.CreateMessageResponse
@@ -87,7 +90,7 @@ func ConvertToRoomchatDoctorResponse(roomchat *schema.Roomchat, user *schema.Use
 	return roomchats
 }
 
-func ConvertToGetAllRoomchats(user schema.User, roomchat schema.Roomchat, lastMessage schema.Message) web.RoomchatListResponse {
+func ConvertToGetAllRoomchats(user schema.User, roomchat schema.Roomchat, lastMessage schema.Message, unreadCount int64) web.RoomchatListResponse {
 	lastMessageContent := helper.GetMessageContent(lastMessage)
 
 	return web.RoomchatListResponse{
@@ -95,8 +98,102 @@ func ConvertToGetAllRoomchats(user schema.User, roomchat schema.Roomchat, lastMe
 		Fullname:       user.Fullname,
 		ProfilePicture: user.ProfilePicture,
 		LastMessage:    lastMessageContent,
+		UnreadCount:    unreadCount,
 		CreatedAt:      lastMessage.CreatedAt,
 		Status:         roomchat.Status,
 		ExpirationTime: roomchat.ExpirationTime,
 	}
 }
+
+func ConvertToGetAllUserRoomchats(doctor schema.Doctor, roomchat schema.Roomchat, lastMessage schema.Message, unreadCount int64) web.RoomchatListResponse {
+	lastMessageContent := helper.GetMessageContent(lastMessage)
+
+	createdAt := roomchat.CreatedAt
+	if lastMessage.ID != 0 {
+		createdAt = lastMessage.CreatedAt
+	}
+
+	return web.RoomchatListResponse{
+		ID:             roomchat.ID,
+		Fullname:       doctor.Fullname,
+		ProfilePicture: doctor.ProfilePicture,
+		LastMessage:    lastMessageContent,
+		UnreadCount:    unreadCount,
+		CreatedAt:      createdAt,
+		Status:         roomchat.Status,
+		ExpirationTime: roomchat.ExpirationTime,
+	}
+}
+
+func ConvertToRoomchatReadResponse(read *schema.RoomchatRead, unreadCount int64) web.RoomchatReadResponse {
+	return web.RoomchatReadResponse{
+		RoomchatID:        read.RoomchatID,
+		LastReadMessageID: read.LastReadMessageID,
+		UnreadCount:       unreadCount,
+		UpdatedAt:         read.UpdatedAt,
+	}
+}
+
+func ConvertToRoomchatEventResponse(eventType string, roomchatID uint, role string, senderID uint) web.RoomchatEventResponse {
+	return web.RoomchatEventResponse{
+		Type:       eventType,
+		RoomchatID: roomchatID,
+		Role:       role,
+		SenderID:   senderID,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func ConvertToRoomchatMessageEventResponse(message *schema.Message) web.RoomchatEventResponse {
+	role, senderID := "user", message.UserID
+	if message.DoctorID != 0 {
+		role, senderID = "doctor", message.DoctorID
+	}
+
+	messageResponse := ConvertToCreateMessageResponse(message)
+
+	return web.RoomchatEventResponse{
+		Type:       "message",
+		RoomchatID: message.RoomchatID,
+		Role:       role,
+		SenderID:   senderID,
+		Message:    &messageResponse,
+		CreatedAt:  message.CreatedAt,
+	}
+}
+
+func ConvertToRoomchatExtensionResponse(extension *schema.RoomchatExtension) web.RoomchatExtensionResponse {
+	return web.RoomchatExtensionResponse{
+		ID:                  extension.ID,
+		RoomchatID:          extension.RoomchatID,
+		Minutes:             extension.Minutes,
+		Price:               extension.Price,
+		PaymentMethod:       extension.PaymentMethod,
+		PaymentProvider:     extension.PaymentProvider,
+		PaymentConfirmation: extension.PaymentConfirmation,
+		PaymentStatus:       extension.PaymentStatus,
+		AppliedAt:           extension.AppliedAt,
+		CreatedAt:           extension.CreatedAt,
+	}
+}
+
+func ConvertToRoomchatExtensionListResponse(extensions []schema.RoomchatExtension) []web.RoomchatExtensionResponse {
+	var results []web.RoomchatExtensionResponse
+	for _, extension := range extensions {
+		results = append(results, ConvertToRoomchatExtensionResponse(&extension))
+	}
+	return results
+}
+
+func ConvertToRoomchatExtensionEventResponse(eventType string, extension *schema.RoomchatExtension, roomchat *schema.Roomchat) web.RoomchatEventResponse {
+	extensionResponse := ConvertToRoomchatExtensionResponse(extension)
+	return web.RoomchatEventResponse{
+		Type:       eventType,
+		RoomchatID: roomchat.ID,
+		Role:       "doctor",
+		SenderID:   extension.DoctorID,
+		Extension:  &extensionResponse,
+		ExpiresAt:  roomchat.ExpirationTime,
+		CreatedAt:  time.Now(),
+	}
+}
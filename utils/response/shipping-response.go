@@ -0,0 +1,28 @@
+package response
+
+import (
+	"healthcare/models/web"
+	"healthcare/utils/helper/shipping"
+)
+
+func ConvertToShippingRatesResponse(parcel shipping.Parcel, options []shipping.Option) *web.ShippingRatesResponse {
+	results := make([]web.ShippingOptionResponse, len(options))
+	for i, option := range options {
+		results[i] = web.ShippingOptionResponse{
+			Provider: option.Provider,
+			Service:  option.Service,
+			Name:     option.Name,
+			Fee:      option.Fee,
+			MinDays:  option.MinDays,
+			MaxDays:  option.MaxDays,
+		}
+	}
+
+	return &web.ShippingRatesResponse{
+		Province:   parcel.Province,
+		City:       parcel.City,
+		PostalCode: parcel.PostalCode,
+		Weight:     parcel.Weight,
+		Options:    results,
+	}
+}
@@ -0,0 +1,30 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToStockMovementResponse(movement *schema.StockMovement) *web.StockMovementResponse {
+	return &web.StockMovementResponse{
+		ID:            movement.ID,
+		MedicineID:    movement.MedicineID,
+		Type:          movement.Type,
+		Quantity:      movement.Quantity,
+		StockAfter:    movement.StockAfter,
+		TransactionID: movement.MedicineTransactionID,
+		BatchID:       movement.MedicineBatchID,
+		ActorRole:     movement.ActorRole,
+		ActorID:       movement.ActorID,
+		Reason:        movement.Reason,
+		CreatedAt:     movement.CreatedAt,
+	}
+}
+
+func ConvertToStockMovementListResponse(movements []schema.StockMovement) []web.StockMovementResponse {
+	var results []web.StockMovementResponse
+	for i := range movements {
+		results = append(results, *ConvertToStockMovementResponse(&movements[i]))
+	}
+	return results
+}
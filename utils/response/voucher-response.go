@@ -0,0 +1,53 @@
+package response
+
+import (
+	"healthcare/models/schema"
+	"healthcare/models/web"
+)
+
+func ConvertToVoucherResponse(voucher *schema.Voucher, used int) *web.VoucherResponse {
+
+	doctorIDs := []uint{}
+	specialists := []string{}
+	categoryIDs := []uint{}
+
+	for _, target := range voucher.Targets {
+		switch {
+		case target.DoctorID != nil:
+			doctorIDs = append(doctorIDs, *target.DoctorID)
+		case target.MedicineCategoryID != nil:
+			categoryIDs = append(categoryIDs, *target.MedicineCategoryID)
+		case target.Specialist != "":
+			specialists = append(specialists, target.Specialist)
+		}
+	}
+
+	return &web.VoucherResponse{
+		ID:           voucher.ID,
+		Code:         voucher.Code,
+		Description:  voucher.Description,
+		Scope:        voucher.Scope,
+		DiscountType: voucher.DiscountType,
+		Value:        voucher.Value,
+		MinSpend:     voucher.MinSpend,
+		MaxDiscount:  voucher.MaxDiscount,
+		StartsAt:     voucher.StartsAt,
+		EndsAt:       voucher.EndsAt,
+		UsageLimit:   voucher.UsageLimit,
+		PerUserLimit: voucher.PerUserLimit,
+		Used:         used,
+		IsActive:     voucher.IsActive,
+		DoctorIDs:    doctorIDs,
+		Specialists:  specialists,
+		CategoryIDs:  categoryIDs,
+		CreatedAt:    voucher.CreatedAt,
+	}
+}
+
+func ConvertToVoucherListResponse(vouchers []schema.Voucher, used map[uint]int) []web.VoucherResponse {
+	var results []web.VoucherResponse
+	for i := range vouchers {
+		results = append(results, *ConvertToVoucherResponse(&vouchers[i], used[vouchers[i].ID]))
+	}
+	return results
+}